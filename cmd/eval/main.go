@@ -7,8 +7,10 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/identify"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
@@ -19,15 +21,23 @@ func main() {
 		target   string
 		targetV  string
 		maxTerms int64
+		ceiling  int64
 		prec     uint
+		ident    bool
+		form     string
+		consts   string
 	)
 
 	flag.StringVar(&formula, "formula", "", "LaTeX formula to evaluate")
 	flag.StringVar(&file, "file", "", "file containing LaTeX formula")
 	flag.StringVar(&target, "target", "", "named constant to compare ("+strings.Join(constants.Names(), ", ")+")")
 	flag.StringVar(&targetV, "target-value", "", "explicit target value (decimal string)")
+	flag.StringVar(&consts, "constants-file", "", "file of named constants -target can name (JSON or lines name = digits)")
 	flag.Int64Var(&maxTerms, "maxterms", 4096, "max terms to sum")
+	flag.Int64Var(&ceiling, "maxterms-ceiling", 0, "max terms a converging series that has not settled is extended to (0 = never)")
 	flag.UintVar(&prec, "precision", 512, "precision in bits")
+	flag.BoolVar(&ident, "identify", false, "run PSLQ to express the limit in known constants")
+	flag.StringVar(&form, "form", "", "read the formula's two trees as a sum, product or cf (default: from the formula's notation)")
 	flag.Parse()
 
 	// Read formula from flag or file.
@@ -51,25 +61,62 @@ func main() {
 		fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
 		os.Exit(1)
 	}
+	if form != "" {
+		f, err := series.ParseForm(form)
+		if err == nil && (cand.Composite != nil || cand.Correction != nil) {
+			err = fmt.Errorf("-form applies to a single series")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		cand.Form = f
+	}
 
 	fmt.Fprintf(os.Stderr, "Parsed: %s\n", cand.String())
 	fmt.Fprintf(os.Stderr, "Evaluating up to %d terms at %d-bit precision...\n", maxTerms, prec)
 
 	// Evaluate.
-	result := series.EvaluateCandidate(cand, maxTerms, prec)
+	result := series.EvaluateCandidateBudget(cand, series.Budget{Terms: maxTerms, Ceiling: ceiling}, prec)
 	if !result.OK {
 		fmt.Fprintln(os.Stderr, "evaluation failed (not enough terms or timeout)")
 		os.Exit(1)
 	}
 
-	fmt.Printf("Terms computed: %d\n", result.TermsComputed)
-	fmt.Printf("Converged:     %v\n", result.Converged)
+	fmt.Printf("Terms computed: %d in %v\n", result.TermsComputed, result.Elapsed.Round(time.Microsecond))
+	fmt.Printf("Converged:     %v (%s, rate %.4g)\n", result.Converged, result.Convergence, result.DecayRate)
 	fmt.Printf("Partial sum:   %s\n", result.PartialSum.Text('g', 50))
+	fmt.Printf("Tail bound:    %.3g (%s)\n", result.TailBound, result.TailMethod)
+	if result.Acceleration != series.AccelNone {
+		fmt.Printf("Accelerated:   %s (%s)\n", result.AcceleratedSum.Text('g', 50), result.Acceleration)
+	}
+	if cand.Composite != nil {
+		for i, t := range cand.Composite.Terms {
+			r := series.EvaluateCandidate(t.Series, maxTerms, prec)
+			if r.OK {
+				fmt.Printf("Series %d (x%d): %s\n", i+1, t.Weight, r.AcceleratedSum.Text('g', 30))
+			}
+		}
+	}
+	if ident {
+		fmt.Printf("Stable digits: %.1f\n", result.StableDigits)
+		if rel, ok := identify.Identify(result.AcceleratedSum, result.StableDigits, identify.Basis(prec)); ok {
+			fmt.Printf("Identified:    %s\n", rel.Expr)
+		} else {
+			fmt.Println("Identified:    (no relation found)")
+		}
+	}
 
 	// Compare against target if provided.
 	var tv *big.Float
+	if consts != "" {
+		if _, err := constants.LoadFile(consts, prec); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	if target != "" {
-		c := constants.Get(target)
+		c := constants.Get(target, prec)
 		if c == nil {
 			fmt.Fprintf(os.Stderr, "unknown target: %s\n", target)
 			os.Exit(1)
@@ -87,19 +134,22 @@ func main() {
 	}
 
 	if tv != nil {
-		diff := new(big.Float).SetPrec(prec).Sub(result.PartialSum, tv)
-		diff.Abs(diff)
-		fmt.Printf("Error:         %s\n", diff.Text('e', 15))
-
-		absTgt := new(big.Float).Abs(tv)
-		if absTgt.Sign() > 0 {
-			relErr := new(big.Float).SetPrec(prec).Quo(diff, absTgt)
-			re, _ := relErr.Float64()
-			if re > 0 {
-				fmt.Printf("Correct digits: %.1f\n", -math.Log10(re))
-			} else {
-				fmt.Printf("Correct digits: 50+ (exact at this precision)\n")
-			}
+		printDigits("Error:", "Correct digits:", result.PartialSum, tv, prec)
+		if result.Acceleration != series.AccelNone {
+			printDigits("Accel error:", "Accel digits:", result.AcceleratedSum, tv, prec)
 		}
 	}
 }
+
+// printDigits prints the absolute error and correct digits of value against target.
+func printDigits(errLabel, digitsLabel string, value, target *big.Float, prec uint) {
+	diff := new(big.Float).SetPrec(prec).Sub(value, target)
+	diff.Abs(diff)
+	fmt.Printf("%-14s %s\n", errLabel, diff.Text('e', 15))
+
+	if diff.Sign() == 0 {
+		fmt.Printf("%s %.0f+ (exact at this precision)\n", digitsLabel, float64(prec)*math.Log10(2))
+		return
+	}
+	fmt.Printf("%s %.1f\n", digitsLabel, series.CorrectDigits(value, target))
+}
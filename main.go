@@ -15,14 +15,18 @@ import (
 func main() {
 	cfg := engine.DefaultConfig()
 	outdir := "."
+	var targets, islands string
 
-	flag.StringVar(&cfg.Target, "target", cfg.Target, "target constant ("+strings.Join(constants.Names(), ", ")+")")
+	flag.StringVar(&cfg.Target, "target", cfg.Target, "target constant ("+strings.Join(constants.Names(), ", ")+", or one from -constants-file)")
+	flag.StringVar(&cfg.TargetValue, "target-value", "", "digits of a target constant not in the list, named by -target (default: custom)")
+	flag.StringVar(&cfg.ConstantsFile, "constants-file", "", "file of named target constants: JSON {\"name\": \"digits\"} or lines name = digits")
 	flag.UintVar(&cfg.Precision, "precision", cfg.Precision, "precision in bits")
 	flag.StringVar(&cfg.Pool, "pool", cfg.Pool, "gene pool ("+strings.Join(pool.Names(), ", ")+")")
 	flag.StringVar(&cfg.Strategy, "strategy", cfg.Strategy, "evolution strategy ("+strings.Join(strategy.Names(), ", ")+")")
 	flag.IntVar(&cfg.Population, "population", cfg.Population, "population size")
 	flag.IntVar(&cfg.Generations, "generations", cfg.Generations, "number of generations")
-	flag.Int64Var(&cfg.MaxTerms, "maxterms", cfg.MaxTerms, "max terms to evaluate per series")
+	flag.Int64Var(&cfg.MaxTerms, "maxterms", cfg.MaxTerms, "terms to evaluate per series (fewer once the terms vanish below the precision)")
+	flag.Int64Var(&cfg.MaxTermsCeiling, "maxterms-ceiling", cfg.MaxTermsCeiling, "max terms a converging series that has not settled is extended to (<= maxterms = never)")
 	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed (0 = random)")
 	flag.StringVar(&cfg.Format, "format", cfg.Format, "output format (text, json)")
 	flag.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "verbose output per generation")
@@ -32,14 +36,41 @@ func main() {
 	flag.Float64Var(&cfg.F64PromotionThreshold, "f64threshold", cfg.F64PromotionThreshold, "min float64 digits to promote to big.Float (0 = disabled)")
 	flag.StringVar(&cfg.SeedFormula, "seed-formula", "", "LaTeX seed formula for constant-tuning strategy")
 	flag.StringVar(&outdir, "outdir", outdir, "output directory for generated files")
+	flag.StringVar(&cfg.Resume, "resume", "", "resume from a checkpoint snapshot file")
+	flag.StringVar(&cfg.CheckpointFile, "checkpoint", "", "checkpoint snapshot path (default: none, no snapshots unless resuming)")
+	flag.DurationVar(&cfg.CheckpointInterval, "checkpoint-every", cfg.CheckpointInterval, "interval between checkpoint snapshots (0 = only on SIGINT/SIGTERM)")
+	flag.StringVar(&targets, "targets", "", "score against several constants at once: comma list or \"all\" (overrides -target)")
+	flag.StringVar(&cfg.TargetSelect, "target-select", cfg.TargetSelect, "multi-target selection: best (best single match) or sum (sum over targets)")
+	flag.BoolVar(&cfg.Transforms, "transforms", cfg.Transforms, "also credit matches to transforms of the target (1/C, C^2, sqrt(C), ln(C), times small p/q, plus sqrt(p/q*C) and ln(p/q*C))")
+	flag.Float64Var(&cfg.IdentifyDigits, "identify-digits", cfg.IdentifyDigits, "min stable digits to run PSLQ on a candidate that misses the target (0 = disabled)")
+	flag.IntVar(&cfg.MaxSeries, "max-series", cfg.MaxSeries, "max weighted sub-series per candidate, e.g. 4*sum(...) - sum(...) (1 = plain series only)")
+	flag.BoolVar(&cfg.Difference, "difference", cfg.Difference, "evolve limits lim_{N->inf} [sum_{n=s}^{N} f(n) - g(N)], e.g. H_N - ln N for euler_gamma (not with -max-series)")
+	flag.StringVar(&cfg.Form, "form", cfg.Form, "candidate form: sum, product (prod_{n=s}^inf A(n)/B(n)) or cf (b(s) + a(s+1)/(b(s+1) + ...))")
+	flag.StringVar(&islands, "islands", "", "evolve one island per pool:strategy pair, e.g. conservative:hillclimb,kitchensink:tournament (population is per island)")
+	flag.IntVar(&cfg.MigrateEvery, "migrate-every", cfg.MigrateEvery, "generations between island migrations (0 = never)")
+	flag.IntVar(&cfg.Migrants, "migrants", cfg.Migrants, "best candidates each island sends per migration")
+	flag.StringVar(&cfg.Topology, "topology", cfg.Topology, "island migration topology: ring or random")
+	flag.StringVar(&cfg.Diversity, "diversity", cfg.Diversity, "diversity pressure: none, sharing (divide fitness among similar behaviour) or novelty (reward new behaviour)")
+	flag.Float64Var(&cfg.ShareRadius, "share-radius", cfg.ShareRadius, "behaviour distance within which -diversity sharing divides fitness")
+	flag.Float64Var(&cfg.NoveltyWeight, "novelty-weight", cfg.NoveltyWeight, "fitness added per unit of novelty with -diversity novelty")
 	flag.Parse()
 
+	if cfg.TargetValue != "" {
+		named := false
+		flag.Visit(func(f *flag.Flag) { named = named || f.Name == "target" })
+		if !named {
+			cfg.Target = "custom"
+		}
+	}
+
 	// Create output directory and wire it into config so the engine can write during the run
 	if err := os.MkdirAll(outdir, 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "error creating output dir: %v\n", err)
 		os.Exit(1)
 	}
 	cfg.OutDir = outdir
+	cfg.Targets = engine.ParseTargets(targets)
+	cfg.Islands = engine.ParseIslands(islands)
 
 	e, err := engine.New(cfg)
 	if err != nil {
@@ -0,0 +1,248 @@
+package constants
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// Computed constants. The built-in constants are computed to whatever
+// precision is asked for, at guardBits more, and rounded:
+//
+//   - pi by the Chudnovsky series, summed by binary splitting;
+//   - e as the sum of 1/k!;
+//   - ln 2 as 2 atanh(1/3);
+//   - zeta(3) by the Amdeberhan-Zeilberger series, three digits a term;
+//   - Catalan's G by Ramanujan's pi/8 ln(2+sqrt 3) + 3/8 sum 1/((2k+1)^2 C(2k,k));
+//   - gamma by the Brent-McMillan algorithm.
+//
+// Ln, which gamma and Catalan's G need, is exported for the expression
+// evaluator too.
+
+// guardBits is the working precision kept beyond the precision asked for,
+// enough to absorb the rounding of a few thousand operations.
+const guardBits = 64
+
+// epsilon returns 2^-prec at prec bits.
+func epsilon(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec).SetMantExp(big.NewFloat(1), -int(prec))
+}
+
+// round returns x rounded to prec bits.
+func round(x *big.Float, prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec).Set(x)
+}
+
+// Chudnovsky series constants: 1/pi = 12 sum_k (-1)^k (6k)! (A + Bk) /
+// ((3k)! (k!)^3 C^(3k+3/2)).
+const (
+	chudA = 13591409
+	chudB = 545140134
+	chudC = 640320
+	// chudBitsPerTerm is how many bits each term adds, log2(C^3/1728).
+	chudBitsPerTerm = 47.11
+)
+
+// computePi sums the Chudnovsky series by binary splitting, all in integers
+// until the final division.
+func computePi(prec uint) *big.Float {
+	wp := prec + guardBits
+	n := int64(float64(wp)/chudBitsPerTerm) + 2
+	c3over24 := new(big.Int).Exp(big.NewInt(chudC), big.NewInt(3), nil)
+	c3over24.Quo(c3over24, big.NewInt(24))
+
+	var split func(a, b int64) (p, q, t *big.Int)
+	split = func(a, b int64) (p, q, t *big.Int) {
+		if b == a+1 {
+			if a == 0 {
+				p, q = big.NewInt(1), big.NewInt(1)
+			} else {
+				p = big.NewInt(6*a - 5)
+				p.Mul(p, big.NewInt(2*a-1))
+				p.Mul(p, big.NewInt(6*a-1))
+				q = big.NewInt(a)
+				q.Mul(q, q).Mul(q, big.NewInt(a))
+				q.Mul(q, c3over24)
+			}
+			t = new(big.Int).Mul(p, big.NewInt(chudA+chudB*a))
+			if a&1 == 1 {
+				t.Neg(t)
+			}
+			return p, q, t
+		}
+		m := (a + b) / 2
+		p1, q1, t1 := split(a, m)
+		p2, q2, t2 := split(m, b)
+		t = new(big.Int).Mul(t1, q2)
+		t.Add(t, new(big.Int).Mul(p1, t2))
+		return p1.Mul(p1, p2), q1.Mul(q1, q2), t
+	}
+	_, q, t := split(0, n)
+
+	// pi = 426880 sqrt(10005) Q / T
+	root := new(big.Float).SetPrec(wp).SetInt64(10005)
+	root.Sqrt(root)
+	pi := new(big.Float).SetPrec(wp).SetInt(q)
+	pi.Mul(pi, big.NewFloat(426880))
+	pi.Mul(pi, root)
+	pi.Quo(pi, new(big.Float).SetPrec(wp).SetInt(t))
+	return round(pi, prec)
+}
+
+// computeOneOverPi is 1/pi.
+func computeOneOverPi(prec uint) *big.Float {
+	wp := prec + guardBits
+	one := new(big.Float).SetPrec(wp).SetInt64(1)
+	return round(one.Quo(one, computed("pi", wp)), prec)
+}
+
+// computeE sums 1/k! until the terms drop below the working precision.
+func computeE(prec uint) *big.Float {
+	wp := prec + guardBits
+	eps := epsilon(wp)
+	sum := new(big.Float).SetPrec(wp).SetInt64(1)
+	term := new(big.Float).SetPrec(wp).SetInt64(1)
+	for k := int64(1); term.Cmp(eps) >= 0; k++ {
+		term.Quo(term, new(big.Float).SetInt64(k))
+		sum.Add(sum, term)
+	}
+	return round(sum, prec)
+}
+
+// computeLn2 sums ln 2 = 2 atanh(1/3) = 2 sum_k 1/((2k+1) 3^(2k+1)).
+func computeLn2(prec uint) *big.Float {
+	wp := prec + guardBits
+	eps := epsilon(wp)
+	nine := new(big.Float).SetInt64(9)
+	sum := new(big.Float).SetPrec(wp)
+	power := new(big.Float).SetPrec(wp).SetInt64(1)
+	power.Quo(power, new(big.Float).SetInt64(3))
+	for k := int64(0); power.Cmp(eps) >= 0; k++ {
+		term := new(big.Float).SetPrec(wp).Quo(power, new(big.Float).SetInt64(2*k+1))
+		sum.Add(sum, term)
+		power.Quo(power, nine)
+	}
+	return round(sum.Mul(sum, big.NewFloat(2)), prec)
+}
+
+// computeZeta3 sums zeta(3) = 1/64 sum_k (-1)^k (k!)^10 (205k^2 + 250k + 77)
+// / ((2k+1)!)^5 (Amdeberhan and Zeilberger). The factorial part f_k shrinks
+// by (k+1)^5 / (32 (2k+3)^5), about 1/1024, each term.
+func computeZeta3(prec uint) *big.Float {
+	wp := prec + guardBits
+	eps := epsilon(wp)
+	sum := new(big.Float).SetPrec(wp)
+	f := new(big.Float).SetPrec(wp).SetInt64(1)
+	for k := int64(0); f.Cmp(eps) >= 0; k++ {
+		term := new(big.Float).SetPrec(wp).Mul(f, new(big.Float).SetInt64(205*k*k+250*k+77))
+		if k&1 == 1 {
+			term.Neg(term)
+		}
+		sum.Add(sum, term)
+		for range 5 {
+			f.Mul(f, new(big.Float).SetInt64(k+1))
+			f.Quo(f, new(big.Float).SetInt64(2*k+3))
+		}
+		f.SetMantExp(f, -5)
+	}
+	return round(sum.SetMantExp(sum, -6), prec)
+}
+
+// computeCatalan sums G = pi/8 ln(2 + sqrt 3) + 3/8 sum_k 1/((2k+1)^2 C(2k,k))
+// (Ramanujan), the sum gaining a factor 4 a term.
+func computeCatalan(prec uint) *big.Float {
+	wp := prec + guardBits
+	eps := epsilon(wp)
+	sum := new(big.Float).SetPrec(wp)
+	inv := new(big.Float).SetPrec(wp).SetInt64(1) // 1/C(2k,k)
+	for k := int64(0); inv.Cmp(eps) >= 0; k++ {
+		term := new(big.Float).SetPrec(wp).Quo(inv, new(big.Float).SetInt64((2*k+1)*(2*k+1)))
+		sum.Add(sum, term)
+		// C(2k+2, k+1) = C(2k, k) 2(2k+1)/(k+1)
+		inv.Mul(inv, new(big.Float).SetInt64(k+1))
+		inv.Quo(inv, new(big.Float).SetInt64(2*(2*k+1)))
+	}
+	sum.Mul(sum, big.NewFloat(3))
+
+	x := new(big.Float).SetPrec(wp).SetInt64(3)
+	x.Sqrt(x).Add(x, big.NewFloat(2))
+	g, _ := Ln(x, wp)
+	g.Mul(g, computed("pi", wp))
+	g.Add(g, sum)
+	return round(g.SetMantExp(g, -3), prec)
+}
+
+// computeGamma runs the Brent-McMillan algorithm: with A_0 = -ln n, B_0 = 1,
+// B_k = B_{k-1} n^2/k^2 and A_k = (A_{k-1} n^2/k + B_k)/k, gamma = sum A_k /
+// sum B_k up to an error of order e^(-4n).
+func computeGamma(prec uint) *big.Float {
+	wp := prec + guardBits
+	n := int64(math.Ceil(float64(wp)*math.Ln2/4)) + 1
+	n2 := new(big.Float).SetInt64(n * n)
+
+	a, _ := Ln(new(big.Float).SetPrec(wp).SetInt64(n), wp)
+	a.Neg(a)
+	b := new(big.Float).SetPrec(wp).SetInt64(1)
+	u := new(big.Float).SetPrec(wp).Set(a)
+	v := new(big.Float).SetPrec(wp).SetInt64(1)
+	for k := int64(1); ; k++ {
+		kf := new(big.Float).SetInt64(k)
+		b.Mul(b, n2).Quo(b, kf).Quo(b, kf)
+		a.Mul(a, n2).Quo(a, kf).Add(a, b).Quo(a, kf)
+		u.Add(u, a)
+		v.Add(v, b)
+		// Past the peak at k = n both terms shrink; stop once neither shows.
+		if k > n && b.MantExp(nil) < v.MantExp(nil)-int(wp) && a.MantExp(nil) < u.MantExp(nil)-int(wp) {
+			break
+		}
+	}
+	return round(u.Quo(u, v), prec)
+}
+
+// Ln returns ln x to prec bits, or false unless x > 0. With x = m 2^k and m
+// in [1/sqrt 2, sqrt 2), ln x = k ln 2 + 2^(s+1) atanh((r-1)/(r+1)) where
+// r = m^(1/2^s): the s square roots shrink the atanh argument so its series
+// converges in few terms.
+func Ln(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.Sign() <= 0 || x.IsInf() {
+		return nil, false
+	}
+	s := int(math.Sqrt(float64(prec))) / 4
+	wp := prec + guardBits + uint(s)
+	m := new(big.Float)
+	k := x.MantExp(m)
+	m.SetPrec(wp) // MantExp gives m the precision of x
+	if m.Cmp(big.NewFloat(math.Sqrt2/2)) < 0 {
+		m.SetMantExp(m, 1)
+		k--
+	}
+	wp += uint(bits.Len(uint(max(k, -k))))
+
+	for range s {
+		m.Sqrt(m)
+	}
+
+	// atanh z = z + z^3/3 + z^5/5 + ..., z = (m-1)/(m+1).
+	one := big.NewFloat(1)
+	z := new(big.Float).SetPrec(wp).Sub(m, one)
+	z.Quo(z, new(big.Float).SetPrec(wp).Add(m, one))
+	z2 := new(big.Float).SetPrec(wp).Mul(z, z)
+	sum := new(big.Float).SetPrec(wp).Set(z)
+	power := new(big.Float).SetPrec(wp).Set(z)
+	term := new(big.Float).SetPrec(wp)
+	for j := int64(3); sum.Sign() != 0; j += 2 {
+		power.Mul(power, z2)
+		term.Quo(power, new(big.Float).SetInt64(j))
+		if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(wp) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	sum.SetMantExp(sum, s+1)
+
+	if k != 0 {
+		kln2 := new(big.Float).SetPrec(wp).Mul(big.NewFloat(float64(k)), computed("ln2", wp))
+		sum.Add(sum, kln2)
+	}
+	return new(big.Float).SetPrec(prec).Set(sum), true
+}
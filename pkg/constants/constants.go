@@ -1,6 +1,9 @@
 package constants
 
-import "math/big"
+import (
+	"math/big"
+	"sync"
+)
 
 // DefaultPrecision is the default precision in bits (~154 decimal digits).
 const DefaultPrecision = 512
@@ -8,93 +11,73 @@ const DefaultPrecision = 512
 // Constant represents a named mathematical constant with a high-precision value.
 type Constant struct {
 	Name         string
+	LaTeX        string // symbol used when printing identities
 	Value        *big.Float
 	Float64Value float64
 }
 
-var registry = map[string]Constant{}
-
-func init() {
-	// Euler-Mascheroni gamma
-	register("euler_gamma",
-		"0.5772156649015328606065120900824024310421"+
-			"5933593992359880576723488486772677766467"+
-			"0936947063291746749514631447249807082480"+
-			"9600265022796782354781636009341721641219"+
-			"9245863150302861829745557067498385054945"+
-			"8858692699569092721079750930295532116534")
-
-	// pi
-	register("pi",
-		"3.1415926535897932384626433832795028841971"+
-			"6939937510582097494459230781640628620899"+
-			"8628034825342117067982148086513282306647"+
-			"0938446095505822317253594081284811174502"+
-			"8410270193852110555964462294895493038196"+
-			"4428810975665933446128475648233786783165")
+// definition is a registered constant: a built-in computed to any
+// precision, or a user-defined one given to a fixed precision.
+type definition struct {
+	latex   string
+	compute func(prec uint) *big.Float // nil for a fixed value
+	value   *big.Float
+}
 
-	// 1/pi
-	register("one_over_pi",
-		"0.3183098861837906715377675267450287240689"+
-			"1929148091289749533468811779359526845307"+
-			"0180227605532506171912145685453515916767"+
-			"3089137555998655553977456851355543069437"+
-			"4838801885528043515624860753515250535751"+
-			"6461625897666954163289939900838308356625")
+var registry = map[string]definition{}
 
-	// e (Euler's number)
-	register("e",
-		"2.7182818284590452353602874713526624977572"+
-			"4709369995957496696762772407663035354759"+
-			"4571382178525166427427466391932003059921"+
-			"8174135966290435729003342952605956307381"+
-			"3232862794349076323382988075319525101901"+
-			"1573834187930702154089149934884167509244")
+// cache holds computed values by name and precision.
+var cache sync.Map // cacheKey -> *big.Float
 
-	// natural log of 2
-	register("ln2",
-		"0.6931471805599453094172321214581765680755"+
-			"0013436025525412068000949339362196969471"+
-			"5605863326996418687542001481020570685733"+
-			"6855202357581305570326707516350759619307"+
-			"2757082837143519030703862389167347112335"+
-			"0115364497955239120475174897943149409978")
+type cacheKey struct {
+	name string
+	prec uint
+}
 
-	// Catalan's constant
-	register("catalan",
-		"0.9159655941772190150546035149323841107741"+
-			"4937428167213426649811962176301977625476"+
-			"9479356512926115106248574422619196199579"+
-			"0358988033258590594315947374811584069953"+
-			"3202877331946051903872747816408786590902"+
-			"4706484152163000228727640942388259957741")
+func init() {
+	builtin("euler_gamma", `\gamma`, computeGamma)
+	builtin("pi", `\pi`, computePi)
+	builtin("one_over_pi", `\frac{1}{\pi}`, computeOneOverPi)
+	builtin("e", `e`, computeE)
+	builtin("ln2", `\ln 2`, computeLn2)
+	builtin("catalan", `G`, computeCatalan)
+	builtin("apery", `\zeta(3)`, computeZeta3)
+}
 
-	// Apery's constant (zeta(3))
-	register("apery",
-		"1.2020569031595942853997381615114499907649"+
-			"8629234049888179227155534183820578631309"+
-			"0186455873609335258146199157795260719418"+
-			"4925066265788304366421542293787082365828"+
-			"1175663006524234158764758002549537812485"+
-			"0654059421297375648142983530828308522096")
+func builtin(name, latex string, compute func(prec uint) *big.Float) {
+	registry[name] = definition{latex: latex, compute: compute}
 }
 
-func register(name, value string) {
-	f, _, err := big.ParseFloat(value, 10, DefaultPrecision, big.ToNearestEven)
-	if err != nil {
-		panic("bad constant " + name + ": " + err.Error())
+// computed returns a built-in constant to prec bits, computing it on first
+// use at that precision.
+func computed(name string, prec uint) *big.Float {
+	key := cacheKey{name, prec}
+	if v, ok := cache.Load(key); ok {
+		return v.(*big.Float)
 	}
-	f64, _ := f.Float64()
-	registry[name] = Constant{Name: name, Value: f, Float64Value: f64}
+	v, _ := cache.LoadOrStore(key, registry[name].compute(prec))
+	return v.(*big.Float)
 }
 
-// Get returns the constant with the given name, or nil if not found.
-func Get(name string) *Constant {
-	c, ok := registry[name]
+// Get returns the constant with the given name to prec bits, or nil if not
+// found. A user-defined constant has the bits its digits carry, if fewer.
+// Value is the caller's own copy, so changing it leaves the cache intact.
+func Get(name string, prec uint) *Constant {
+	d, ok := registry[name]
 	if !ok {
 		return nil
 	}
-	return &c
+	var v *big.Float
+	switch {
+	case d.compute != nil:
+		v = computed(name, prec)
+	case d.value.Prec() > prec:
+		v = round(d.value, prec)
+	default:
+		v = d.value
+	}
+	f64, _ := v.Float64()
+	return &Constant{Name: name, LaTeX: d.latex, Value: new(big.Float).Copy(v), Float64Value: f64}
 }
 
 // Names returns all registered constant names.
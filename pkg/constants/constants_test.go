@@ -0,0 +1,211 @@
+package constants
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransforms_KnownValues(t *testing.T) {
+	pi := Get("pi", DefaultPrecision).Float64Value
+	want := map[string]float64{
+		"pi":         pi,
+		"pi^2/6":     pi * pi / 6,
+		"1/pi":       1 / pi,
+		"1/(2*pi)":   1 / (2 * pi),
+		"3*pi/4":     3 * pi / 4,
+		"sqrt(pi)/2": math.Sqrt(pi) / 2,
+		"ln(pi)":     math.Log(pi),
+		"sqrt(2*pi)": math.Sqrt(2 * pi),
+		"sqrt(pi/2)": math.Sqrt(pi / 2),
+		"ln(2*pi)":   math.Log(2 * pi),
+	}
+	got := map[string]Transform{}
+	for _, tr := range Transforms("pi", DefaultPrecision) {
+		got[tr.Name] = tr
+	}
+	for name, v := range want {
+		tr, ok := got[name]
+		if !ok {
+			t.Errorf("missing transform %s", name)
+			continue
+		}
+		if math.Abs(tr.Float64Value-v) > 1e-14*math.Abs(v) {
+			t.Errorf("%s = %v, want %v", name, tr.Float64Value, v)
+		}
+	}
+	if first := Transforms("pi", DefaultPrecision)[0]; first.Name != "pi" || first.Complexity != 0 {
+		t.Errorf("first transform = %s (complexity %v), want the identity", first.Name, first.Complexity)
+	}
+	if tr := got["pi^2/6"]; tr.LaTeX != `\frac{\pi^2}{6}` {
+		t.Errorf("pi^2/6 LaTeX = %s", tr.LaTeX)
+	}
+	if tr := got["sqrt(2*pi)"]; tr.LaTeX != `\sqrt{2\pi}` {
+		t.Errorf("sqrt(2*pi) LaTeX = %s", tr.LaTeX)
+	}
+	if tr := got["ln(2*pi)"]; tr.LaTeX != `\ln \left(2\pi\right)` {
+		t.Errorf("ln(2*pi) LaTeX = %s", tr.LaTeX)
+	}
+	if _, ok := got["sqrt(4*pi)"]; ok {
+		t.Error("sqrt(4*pi) duplicates 2*sqrt(pi)")
+	}
+}
+
+// TestTransforms_LogPrecision checks Ln, behind ln(C), at full precision, and that integer transforms such as ln(e) are dropped.
+func TestTransforms_LogPrecision(t *testing.T) {
+	ln2 := Get("ln2", DefaultPrecision).Value
+	two := new(big.Float).SetPrec(ln2.Prec()).SetInt64(2)
+	diff, _ := Ln(two, two.Prec())
+	diff.Sub(diff, ln2)
+	if f, _ := diff.Float64(); math.Abs(f) > 1e-140 {
+		t.Errorf("ln(2) error = %g", f)
+	}
+	if _, ok := LookupTransform("e", "ln(e)", DefaultPrecision); ok {
+		t.Error("ln(e) = 1 should not be a transform")
+	}
+}
+
+// TestGet_Copy checks that changing a returned value does not reach the
+// cache behind later calls.
+func TestGet_Copy(t *testing.T) {
+	want := Get("pi", DefaultPrecision).Float64Value
+	v := Get("pi", DefaultPrecision).Value
+	v.Mul(v, big.NewFloat(2))
+	if got, _ := Get("pi", DefaultPrecision).Value.Float64(); got != want {
+		t.Errorf("pi = %v after changing a returned copy, want %v", got, want)
+	}
+}
+
+// TestLoadFile checks both file formats, the digits check against the
+// precision, and that a registered name cannot change value.
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	toml := filepath.Join(dir, "consts.toml")
+	os.WriteFile(toml, []byte(`# user constants
+test_zeta5 = 1.0369277551433699263313654864570  # zeta(5)
+
+[test_glaisher]
+value = "1.2824271291006226368753425688697917"
+latex = 'A'
+`), 0o644)
+	names, err := LoadFile(toml, 64)
+	if err != nil || strings.Join(names, ",") != "test_zeta5,test_glaisher" {
+		t.Fatalf("LoadFile: %v, %v", names, err)
+	}
+	if c := Get("test_zeta5", 64); c == nil || math.Abs(c.Float64Value-1.0369277551433699) > 1e-15 || c.LaTeX != `\mathrm{test\_zeta5}` {
+		t.Errorf("test_zeta5 = %+v", c)
+	}
+	if c := Get("test_glaisher", 64); c == nil || c.LaTeX != "A" || c.Value.Prec() != 64 {
+		t.Errorf("test_glaisher = %+v", c)
+	}
+
+	js := filepath.Join(dir, "consts.json")
+	os.WriteFile(js, []byte(`{"test_khinchin": {"value": "2.685452001065306445309714835", "latex": "K_0"},
+		"test_half": "0.50000000000000000000000"}`), 0o644)
+	if names, err := LoadFile(js, 64); err != nil || strings.Join(names, ",") != "test_half,test_khinchin" {
+		t.Fatalf("LoadFile JSON: %v, %v", names, err)
+	}
+	if c := Get("test_khinchin", 64); c == nil || c.LaTeX != "K_0" {
+		t.Errorf("test_khinchin = %+v", c)
+	}
+
+	if err := Register("test_short", "", "1.5", 64); err == nil {
+		t.Error("1.5 should have too few digits for 64 bits")
+	}
+	pi := Get("pi", DefaultPrecision).Value
+	if err := Register("pi", "", pi.Text('g', 200), pi.Prec()); err != nil {
+		t.Errorf("re-registering pi with its own digits: %v", err)
+	}
+	if err := Register("pi", "", Get("e", DefaultPrecision).Value.Text('g', 200), pi.Prec()); err == nil {
+		t.Error("redefining pi should fail")
+	}
+}
+
+// reference holds 80 published digits of each built-in constant.
+var reference = map[string]string{
+	"euler_gamma": "0.5772156649015328606065120900824024310421" +
+		"5933593992359880576723488486772677766467",
+	"pi": "3.1415926535897932384626433832795028841971" +
+		"6939937510582097494459230781640628620899",
+	"one_over_pi": "0.3183098861837906715377675267450287240689" +
+		"1929148091289749533468811779359526845307",
+	"e": "2.7182818284590452353602874713526624977572" +
+		"4709369995957496696762772407663035354759",
+	"ln2": "0.6931471805599453094172321214581765680755" +
+		"0013436025525412068000949339362196969471",
+	"catalan": "0.9159655941772190150546035149323841107741" +
+		"4937428167213426649811962176301977625476",
+	"apery": "1.2020569031595942853997381615114499907649" +
+		"8629234049888179227155534183820578631309",
+}
+
+// TestComputed_Reference checks each computed constant against its published
+// digits and against itself at a higher precision, and zeta(3) and ln 2
+// against slower series.
+func TestComputed_Reference(t *testing.T) {
+	const prec = 256 // within the 265 bits of 80 digits
+	for name, digits := range reference {
+		want, _, err := big.ParseFloat(digits, 10, prec+16, big.ToNearestEven)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := Get(name, prec).Value
+		if got.Prec() != prec {
+			t.Errorf("%s: got %d bits, want %d", name, got.Prec(), prec)
+		}
+		if err := relErr(got, want); err > -prec+2 {
+			t.Errorf("%s at %d bits: relative error 2^%.1f", name, prec, err)
+		}
+		lo, hi := Get(name, 2048).Value, Get(name, 3000).Value
+		if err := relErr(lo, hi); err > -2048+1 {
+			t.Errorf("%s: 2048 and 3000 bits differ by 2^%.1f", name, err)
+		}
+	}
+
+	const hi = 2048
+	wp := uint(hi + 64)
+	// ln(e) = 1 ties e, ln 2 and the log series together.
+	lnE, _ := Ln(Get("e", hi).Value, hi)
+	if err := relErr(lnE, big.NewFloat(1)); err > -hi+8 {
+		t.Errorf("ln(e) - 1 = 2^%.1f at %d bits", err, hi)
+	}
+	// ln 2 = sum 1/(k 2^k)
+	ln2 := new(big.Float).SetPrec(wp)
+	for k := 1; k < hi+64; k++ {
+		term := new(big.Float).SetPrec(wp).SetInt64(1)
+		term.SetMantExp(term, -k)
+		ln2.Add(ln2, term.Quo(term, big.NewFloat(float64(k))))
+	}
+	if err := relErr(Get("ln2", hi).Value, ln2); err > -hi+8 {
+		t.Errorf("ln 2 off sum 1/(k 2^k) by 2^%.1f", err)
+	}
+	// zeta(3) = 5/2 sum (-1)^(k+1) / (k^3 C(2k,k)) (Apery)
+	zeta3 := new(big.Float).SetPrec(wp)
+	binom := new(big.Int).SetInt64(1)
+	for k := int64(1); k < hi/2+64; k++ {
+		binom.Mul(binom, big.NewInt(2*(2*k-1))).Quo(binom, big.NewInt(k))
+		den := new(big.Int).Mul(binom, big.NewInt(k*k*k))
+		term := new(big.Float).SetPrec(wp).Quo(big.NewFloat(1), new(big.Float).SetInt(den))
+		if k%2 == 0 {
+			term.Neg(term)
+		}
+		zeta3.Add(zeta3, term)
+	}
+	zeta3.Mul(zeta3, big.NewFloat(2.5))
+	if err := relErr(Get("apery", hi).Value, zeta3); err > -hi+8 {
+		t.Errorf("zeta(3) off Apery's series by 2^%.1f", err)
+	}
+}
+
+// relErr returns log2 |a - b| / |b|.
+func relErr(a, b *big.Float) float64 {
+	d := new(big.Float).SetPrec(a.Prec()+64).Sub(a, b)
+	if d.Sign() == 0 {
+		return math.Inf(-1)
+	}
+	d.Quo(d, b)
+	return float64(d.MantExp(nil))
+}
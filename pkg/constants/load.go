@@ -0,0 +1,219 @@
+package constants
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+)
+
+// User-defined constants. Targets beyond the built-in ones are registered at
+// run time, from -target-value or from a constants file in one of two
+// formats. JSON maps names to digit strings or to objects with "value" and
+// "latex":
+//
+//	{"zeta5": "1.0369277551433699263...", "glaisher": {"value": "1.28242712910062263...", "latex": "A"}}
+//
+// The TOML-like format has one name = value per line, or a [name] table with
+// value and latex keys. Values may be quoted; nothing inside quotes is
+// escaped, so LaTeX can be written as is. # starts a comment.
+//
+//	zeta5 = 1.0369277551433699263...
+//
+//	[glaisher]
+//	value = "1.28242712910062263..."
+//	latex = 'A'
+
+// Register adds a constant given as a decimal string, parsed at prec bits. The
+// string must carry the significant digits prec calls for, so that digits
+// counted against it are real; exact values can be padded with zeros. An
+// empty latex prints the name. Registering a name again is an error unless
+// the value is the same.
+func Register(name, latex, value string, prec uint) error {
+	if !validName(name) {
+		return fmt.Errorf("bad constant name %q: want letters, digits and _", name)
+	}
+	value = strings.TrimSpace(value)
+	f, _, err := big.ParseFloat(value, 10, prec, big.ToNearestEven)
+	if err != nil || f.IsInf() {
+		return fmt.Errorf("bad value for constant %s: %q", name, value)
+	}
+	if have, need := significantDigits(value), digitsFor(prec); have < need {
+		return fmt.Errorf("constant %s has %d significant digits, precision %d needs %d", name, have, prec, need)
+	}
+	if latex == "" {
+		latex = `\mathrm{` + strings.ReplaceAll(name, "_", `\_`) + `}`
+	}
+	if old := Get(name, prec); old != nil {
+		if !sameValue(old.Value, f) {
+			return fmt.Errorf("constant %s is already defined with a different value", name)
+		}
+		return nil
+	}
+	registry[name] = definition{latex: latex, value: f}
+	return nil
+}
+
+// LoadFile registers the constants in a JSON or TOML-like file (see above)
+// at prec bits and returns their names.
+func LoadFile(path string, prec uint) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var defs []constantDef
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		defs, err = parseJSONConstants(trimmed)
+	} else {
+		defs, err = parseTextConstants(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var names []string
+	for _, d := range defs {
+		if err := Register(d.name, d.latex, d.value, prec); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		names = append(names, d.name)
+	}
+	return names, nil
+}
+
+type constantDef struct {
+	name, latex, value string
+}
+
+// parseJSONConstants reads the JSON format, in name order.
+func parseJSONConstants(data []byte) ([]constantDef, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	var defs []constantDef
+	for name, msg := range raw {
+		d := constantDef{name: name}
+		if err := json.Unmarshal(msg, &d.value); err != nil {
+			var obj struct {
+				Value string `json:"value"`
+				LaTeX string `json:"latex"`
+			}
+			if err := json.Unmarshal(msg, &obj); err != nil || obj.Value == "" {
+				return nil, fmt.Errorf("constant %s: want a digit string or {\"value\", \"latex\"}", name)
+			}
+			d.value, d.latex = obj.Value, obj.LaTeX
+		}
+		defs = append(defs, d)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].name < defs[j].name })
+	return defs, nil
+}
+
+// parseTextConstants reads the TOML-like format, in file order.
+func parseTextConstants(data []byte) ([]constantDef, error) {
+	var defs []constantDef
+	table := -1 // index in defs of the current [name] table
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(stripComment(sc.Text()))
+		switch {
+		case s == "":
+			continue
+		case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+			defs = append(defs, constantDef{name: strings.TrimSpace(s[1 : len(s)-1])})
+			table = len(defs) - 1
+			continue
+		}
+		key, val, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want name = value", line)
+		}
+		key, val = strings.TrimSpace(key), unquote(strings.TrimSpace(val))
+		switch {
+		case table < 0:
+			defs = append(defs, constantDef{name: key, value: val})
+		case key == "value":
+			defs[table].value = val
+		case key == "latex":
+			defs[table].latex = val
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q in [%s]", line, key, defs[table].name)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for _, d := range defs {
+		if d.value == "" {
+			return nil, fmt.Errorf("constant %s has no value", d.name)
+		}
+	}
+	return defs, nil
+}
+
+// stripComment drops a # comment that is not inside quotes.
+func stripComment(s string) string {
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// significantDigits counts the significant digits of a decimal string:
+// those of its mantissa after any leading zeros.
+func significantDigits(s string) int {
+	s = strings.TrimLeft(s, "+-")
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimLeft(strings.Replace(s, ".", "", 1), "0")
+	return len(s)
+}
+
+// digitsFor is the number of decimal digits prec bits hold.
+func digitsFor(prec uint) int {
+	return int(math.Ceil(float64(prec) * math.Log10(2)))
+}
+
+// sameValue reports whether a and b agree to the lesser of their precisions.
+func sameValue(a, b *big.Float) bool {
+	prec := min(a.Prec(), b.Prec())
+	x := new(big.Float).SetPrec(prec).Set(a)
+	y := new(big.Float).SetPrec(prec).Set(b)
+	return x.Cmp(y) == 0
+}
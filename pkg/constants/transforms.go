@@ -0,0 +1,244 @@
+package constants
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Transform is a target derived from a base constant C, such as pi^2/6 or
+// 1/pi. Complexity is the transform's cost in decimal digits: a match to a
+// transform is worth that many digits less than a match to C itself, which
+// offsets the extra chances to match by coincidence.
+type Transform struct {
+	Name         string
+	LaTeX        string
+	Value        *big.Float
+	Float64Value float64
+	Complexity   float64
+}
+
+// Rational multipliers p/q applied to each transform form.
+const (
+	maxTransformNum = 4
+	maxTransformDen = 8
+)
+
+// transformForm is one of the unary maps C -> f(C) that the rational
+// multipliers are applied to. Forms marked inner also take p/q inside,
+// f(p/q*C), which for sqrt and ln is not a rational multiple of f(C).
+type transformForm struct {
+	cost  float64
+	inner bool
+	apply func(c *big.Float) (*big.Float, bool)
+	name  func(c string) string
+	latex func(c string) string
+}
+
+// Indices of 1/C and sqrt(C) in transformForms.
+const (
+	reciprocalForm = 1
+	sqrtForm       = 3
+)
+
+var transformForms = []transformForm{
+	{0, false, func(c *big.Float) (*big.Float, bool) { return c, true },
+		func(c string) string { return c },
+		func(c string) string { return c }},
+	{0.3, false, func(c *big.Float) (*big.Float, bool) {
+		if c.Sign() == 0 {
+			return nil, false
+		}
+		return new(big.Float).SetPrec(c.Prec()).Quo(new(big.Float).SetInt64(1), c), true
+	},
+		func(c string) string { return "1/" + c },
+		func(c string) string { return `\frac{1}{` + c + `}` }},
+	{0.3, false, func(c *big.Float) (*big.Float, bool) { return new(big.Float).SetPrec(c.Prec()).Mul(c, c), true },
+		func(c string) string { return c + "^2" },
+		func(c string) string { return latexAtom(c) + "^2" }},
+	{0.5, true, func(c *big.Float) (*big.Float, bool) {
+		if c.Sign() <= 0 {
+			return nil, false
+		}
+		return new(big.Float).SetPrec(c.Prec()).Sqrt(c), true
+	},
+		func(c string) string { return "sqrt(" + c + ")" },
+		func(c string) string { return `\sqrt{` + c + `}` }},
+	{0.5, true, func(c *big.Float) (*big.Float, bool) {
+		if c.Sign() <= 0 || c.Cmp(big.NewFloat(1)) == 0 {
+			return nil, false
+		}
+		return Ln(c, c.Prec())
+	},
+		func(c string) string { return "ln(" + c + ")" },
+		func(c string) string { return `\ln ` + latexAtom(c) }},
+}
+
+var transformCache sync.Map // cacheKey -> []Transform
+
+// Transforms returns the transform family of a registered constant at prec
+// bits: C, 1/C, C^2, sqrt(C) and ln(C), each times small rationals p/q, plus
+// sqrt(p/q*C) and ln(p/q*C). The identity comes first. Returns nil for
+// unknown names. The slice and its values are cached and shared: read only.
+func Transforms(name string, prec uint) []Transform {
+	key := cacheKey{name, prec}
+	if cached, ok := transformCache.Load(key); ok {
+		return cached.([]Transform)
+	}
+	c := Get(name, prec)
+	if c == nil {
+		return nil
+	}
+
+	var out []Transform
+	for fi, form := range transformForms {
+		v, ok := form.apply(c.Value)
+		if !ok || (fi > 0 && nearInteger(v)) {
+			continue // integers such as ln(e) are no target at all
+		}
+		for p := int64(1); p <= maxTransformNum; p++ {
+			for q := int64(1); q <= maxTransformDen; q++ {
+				if gcd(p, q) != 1 {
+					continue
+				}
+				t := Transform{
+					Value:      scale(v, p, q),
+					Complexity: form.cost + math.Log10(float64(p)) + math.Log10(float64(q)),
+				}
+				t.Float64Value, _ = t.Value.Float64()
+				if fi == reciprocalForm {
+					// Reciprocals read better as p/(qC) than (p/q)(1/C).
+					t.Name, t.LaTeX = scaledReciprocal(name, c.LaTeX, p, q)
+				} else {
+					t.Name = scaledName(form.name(name), p, q)
+					t.LaTeX = scaledLaTeX(form.latex(c.LaTeX), p, q)
+				}
+				out = append(out, t)
+			}
+		}
+		if form.inner {
+			out = append(out, innerTransforms(form, name, c, fi)...)
+		}
+	}
+	transformCache.Store(key, out)
+	return out
+}
+
+// LookupTransform finds a transform of base at prec bits by name.
+func LookupTransform(base, name string, prec uint) (Transform, bool) {
+	for _, t := range Transforms(base, prec) {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Transform{}, false
+}
+
+// innerTransforms returns f(p/q*C) for a form that takes p/q inside, such as
+// sqrt(2*pi). Pairs whose sqrt is rational are skipped: sqrt(4*pi) is already
+// 2*sqrt(pi).
+func innerTransforms(form transformForm, name string, c *Constant, fi int) []Transform {
+	var out []Transform
+	for p := int64(1); p <= maxTransformNum; p++ {
+		for q := int64(1); q <= maxTransformDen; q++ {
+			if (p == 1 && q == 1) || gcd(p, q) != 1 {
+				continue
+			}
+			if fi == sqrtForm && isSquare(p) && isSquare(q) {
+				continue
+			}
+			v, ok := form.apply(scale(c.Value, p, q))
+			if !ok || nearInteger(v) {
+				continue
+			}
+			t := Transform{
+				Name:       form.name(scaledName(name, p, q)),
+				LaTeX:      form.latex(scaledLaTeX(c.LaTeX, p, q)),
+				Value:      v,
+				Complexity: form.cost + math.Log10(float64(p)) + math.Log10(float64(q)),
+			}
+			t.Float64Value, _ = t.Value.Float64()
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func isSquare(n int64) bool {
+	r := int64(math.Sqrt(float64(n)))
+	return r*r == n
+}
+
+func scale(v *big.Float, p, q int64) *big.Float {
+	if p == 1 && q == 1 {
+		return v
+	}
+	r := new(big.Float).SetPrec(v.Prec()).Mul(v, new(big.Float).SetInt64(p))
+	return r.Quo(r, new(big.Float).SetInt64(q))
+}
+
+func scaledName(x string, p, q int64) string {
+	switch {
+	case p == 1 && q == 1:
+		return x
+	case q == 1:
+		return fmt.Sprintf("%d*%s", p, x)
+	case p == 1:
+		return fmt.Sprintf("%s/%d", x, q)
+	default:
+		return fmt.Sprintf("%d*%s/%d", p, x, q)
+	}
+}
+
+func scaledLaTeX(x string, p, q int64) string {
+	switch {
+	case p == 1 && q == 1:
+		return x
+	case q == 1:
+		return fmt.Sprintf("%d%s", p, x)
+	case p == 1:
+		return fmt.Sprintf(`\frac{%s}{%d}`, x, q)
+	default:
+		return fmt.Sprintf(`\frac{%d%s}{%d}`, p, x, q)
+	}
+}
+
+func scaledReciprocal(name, latex string, p, q int64) (string, string) {
+	den, denTeX := name, latex
+	if q != 1 {
+		den, denTeX = fmt.Sprintf("(%d*%s)", q, name), fmt.Sprintf("%d%s", q, latexAtom(latex))
+	}
+	return fmt.Sprintf("%d/%s", p, den), fmt.Sprintf(`\frac{%d}{%s}`, p, denTeX)
+}
+
+// latexAtom parenthesizes a LaTeX symbol that would not bind as one token,
+// including a scaled one such as 2\pi.
+func latexAtom(s string) string {
+	if strings.ContainsAny(s, " ") || strings.Contains(s, `\frac`) || (s != "" && s[0] >= '0' && s[0] <= '9') {
+		return `\left(` + s + `\right)`
+	}
+	return s
+}
+
+// nearInteger reports whether v is an integer up to rounding error.
+func nearInteger(v *big.Float) bool {
+	i, _ := v.Int(nil)
+	diff := new(big.Float).SetPrec(v.Prec()).Sub(v, new(big.Float).SetInt(i))
+	for _, next := range []*big.Int{big.NewInt(1), big.NewInt(-1)} {
+		alt := new(big.Float).SetPrec(v.Prec()).Sub(diff, new(big.Float).SetInt(next))
+		if alt.Abs(alt).Cmp(new(big.Float).Abs(diff)) < 0 {
+			diff = alt
+		}
+	}
+	tol := new(big.Float).SetMantExp(big.NewFloat(1), -int(v.Prec())+16)
+	return diff.Abs(diff).Cmp(tol) <= 0
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
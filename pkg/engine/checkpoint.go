@@ -0,0 +1,340 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// snapshotVersion is bumped whenever the Snapshot layout changes incompatibly.
+const snapshotVersion = 1
+
+// Snapshot is the on-disk checkpoint of an in-progress run. Candidates are
+// stored as LaTeX so a snapshot stays readable and survives changes to the
+// in-memory tree representation. Generations carries the per-generation
+// reports so a resumed run's report covers the whole run; snapshots written
+// before it was added resume with the history empty.
+type Snapshot struct {
+	Version       int             `json:"version"`
+	SavedAt       time.Time       `json:"saved_at"`
+	Config        Config          `json:"config"`
+	RunTimestamp  string          `json:"run_timestamp"`
+	Attempt       int             `json:"attempt"`
+	TotalGensUsed int             `json:"total_gens_used"`
+	TabuSet       []string        `json:"tabu_set"`
+	TabuPrints    []uint64        `json:"tabu_fingerprints,omitempty"`
+	HallOfFame    []AttemptResult `json:"hall_of_fame"`
+	GlobalBest    *savedBest      `json:"global_best,omitempty"`
+	Pareto        []ParetoEntry   `json:"pareto,omitempty"`
+	RNG           []byte          `json:"rng"`
+
+	Generations []GenerationReport `json:"generations,omitempty"`
+
+	// In-progress attempt. Population is empty when the snapshot was taken
+	// between attempts.
+	Population           []string              `json:"population,omitempty"`
+	Genomes              []*series.HyperGenome `json:"genomes,omitempty"` // parallel to Population; nil for plain trees
+	AttemptBest          *savedBest            `json:"attempt_best,omitempty"`
+	GensSinceImprovement int                   `json:"gens_since_improvement"`
+	BestFoundAtGen       int                   `json:"best_found_at_gen"`
+	AttemptGens          int                   `json:"attempt_gens"`
+	Identified           []Identified          `json:"identified,omitempty"`
+	TargetBests          map[string]*savedBest `json:"target_bests,omitempty"`
+}
+
+// savedBest is a best-so-far candidate together with its score.
+type savedBest struct {
+	LaTeX      string         `json:"latex"`
+	Fitness    series.Fitness `json:"fitness"`
+	PartialSum string         `json:"partial_sum,omitempty"`
+	FoundAtGen int            `json:"found_at_gen,omitempty"`
+}
+
+// runState holds everything Run needs to continue a search.
+type runState struct {
+	runTimestamp  string
+	hallOfFame    []AttemptResult
+	genReports    []GenerationReport
+	totalGensUsed int
+	attempt       int
+	tabu          *tabuList
+	pareto        paretoArchive
+
+	globalBest        *series.Candidate
+	globalBestFitness series.Fitness
+	globalBestResult  series.EvalResult
+
+	// Current attempt (population is nil between attempts).
+	population             []*series.Candidate
+	bestThisAttempt        *series.Candidate
+	bestThisAttemptFitness series.Fitness
+	bestThisAttemptResult  series.EvalResult
+	gensSinceImprovement   int
+	bestFoundAtGen         int
+	attemptGens            int
+	identified             []Identified
+	targetBests            map[string]*targetBest // multi-target runs only
+}
+
+func newRunState() *runState {
+	st := &runState{
+		runTimestamp: fmt.Sprintf("%d", time.Now().Unix()),
+		tabu:         newTabuList(),
+		pareto:       paretoArchive{},
+	}
+	st.globalBestFitness.Combined = -1e18
+	return st
+}
+
+// startAttempt resets the per-attempt bookkeeping for a fresh population.
+func (st *runState) startAttempt(pop []*series.Candidate) {
+	st.population = pop
+	st.bestThisAttempt = nil
+	st.bestThisAttemptFitness = series.Fitness{Combined: -1e18}
+	st.bestThisAttemptResult = series.EvalResult{}
+	st.gensSinceImprovement = 0
+	st.bestFoundAtGen = 0
+	st.attemptGens = 0
+	st.identified = nil
+	st.targetBests = nil
+}
+
+// attemptResult summarizes an attempt's best candidate for one target.
+func (st *runState) attemptResult(tg target, c *series.Candidate, f series.Fitness, r series.EvalResult, foundAtGen int) AttemptResult {
+	ar := AttemptResult{
+		Attempt:        st.attempt,
+		Target:         tg.name,
+		Generations:    st.attemptGens,
+		BestFoundAtGen: foundAtGen,
+		Timestamp:      time.Now().UTC(),
+	}
+	if c != nil {
+		ar.BestCandidate = c.String()
+		ar.BestLaTeX = c.LaTeX()
+		ar.BestFitness = f
+		ar.Fingerprint, _ = series.Fingerprint(c, fingerprintTerms)
+		ar.Transform = f.Transform
+		_, ar.TransformLaTeX = tg.matched(f)
+		if r.OK && r.PartialSum != nil {
+			ar.BestPartialSum = r.PartialSum.Text('g', 20)
+			ar.Acceleration = r.Acceleration.String()
+			ar.TailBound = r.TailMethod.String()
+			ar.TermsUsed = r.TermsComputed
+			ar.EvalMillis = float64(r.Elapsed.Microseconds()) / 1000
+		}
+	}
+	return ar
+}
+
+func newSavedBest(c *series.Candidate, f series.Fitness, r series.EvalResult) *savedBest {
+	if c == nil {
+		return nil
+	}
+	sb := &savedBest{LaTeX: c.LaTeX(), Fitness: f}
+	if r.OK && r.PartialSum != nil {
+		sb.PartialSum = r.PartialSum.Text('g', -1)
+	}
+	return sb
+}
+
+// restore parses a saved best back into a candidate and a minimal EvalResult.
+func (sb *savedBest) restore(prec uint) (*series.Candidate, series.Fitness, series.EvalResult, error) {
+	c, err := series.ParseCandidateLatex(sb.LaTeX)
+	if err != nil {
+		return nil, series.Fitness{}, series.EvalResult{}, err
+	}
+	var r series.EvalResult
+	if sb.PartialSum != "" {
+		ps, _, err := big.ParseFloat(sb.PartialSum, 10, prec, big.ToNearestEven)
+		if err == nil {
+			r = series.EvalResult{PartialSum: ps, OK: true}
+		}
+	}
+	return c, sb.Fitness, r, nil
+}
+
+// snapshot captures the run state and RNG position.
+func (e *Engine) snapshot(st *runState) (*Snapshot, error) {
+	rngState, err := e.src.pcg.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	snap := &Snapshot{
+		Version:              snapshotVersion,
+		SavedAt:              time.Now().UTC(),
+		Config:               e.cfg,
+		RunTimestamp:         st.runTimestamp,
+		Attempt:              st.attempt,
+		TotalGensUsed:        st.totalGensUsed,
+		HallOfFame:           st.hallOfFame,
+		GlobalBest:           newSavedBest(st.globalBest, st.globalBestFitness, st.globalBestResult),
+		Pareto:               st.pareto.entries(e.targets),
+		RNG:                  rngState,
+		Generations:          st.genReports,
+		AttemptBest:          newSavedBest(st.bestThisAttempt, st.bestThisAttemptFitness, st.bestThisAttemptResult),
+		GensSinceImprovement: st.gensSinceImprovement,
+		BestFoundAtGen:       st.bestFoundAtGen,
+		AttemptGens:          st.attemptGens,
+		Identified:           st.identified,
+	}
+	for name, tb := range st.targetBests {
+		if snap.TargetBests == nil {
+			snap.TargetBests = map[string]*savedBest{}
+		}
+		sb := newSavedBest(tb.candidate, tb.fitness, tb.result)
+		sb.FoundAtGen = tb.foundAtGen
+		snap.TargetBests[name] = sb
+	}
+	for s := range st.tabu.strs {
+		snap.TabuSet = append(snap.TabuSet, s)
+	}
+	sort.Strings(snap.TabuSet)
+	for fp := range st.tabu.prints {
+		snap.TabuPrints = append(snap.TabuPrints, fp)
+	}
+	sort.Slice(snap.TabuPrints, func(i, j int) bool { return snap.TabuPrints[i] < snap.TabuPrints[j] })
+	hasGenomes := false
+	for _, c := range st.population {
+		snap.Population = append(snap.Population, c.LaTeX())
+		hasGenomes = hasGenomes || c.Hyper != nil
+	}
+	if hasGenomes {
+		for _, c := range st.population {
+			snap.Genomes = append(snap.Genomes, c.Hyper)
+		}
+	}
+	return snap, nil
+}
+
+// restore rebuilds the run state from a snapshot and rewinds the RNG.
+func (e *Engine) restore(snap *Snapshot) (*runState, error) {
+	if err := e.src.pcg.UnmarshalBinary(snap.RNG); err != nil {
+		return nil, fmt.Errorf("restoring rng: %w", err)
+	}
+	st := newRunState()
+	st.runTimestamp = snap.RunTimestamp
+	st.attempt = snap.Attempt
+	st.totalGensUsed = snap.TotalGensUsed
+	st.hallOfFame = snap.HallOfFame
+	st.genReports = snap.Generations
+	for i := range st.hallOfFame {
+		// Snapshots from before multi-target runs leave Target unset.
+		if st.hallOfFame[i].Target == "" && len(e.targets) == 1 {
+			st.hallOfFame[i].Target = e.targets[0].name
+		}
+	}
+	for _, s := range snap.TabuSet {
+		st.tabu.strs[s] = true
+	}
+	for _, fp := range snap.TabuPrints {
+		st.tabu.prints[fp] = true
+	}
+	for _, p := range snap.Pareto {
+		st.pareto[p.Target] = append(st.pareto[p.Target], p)
+	}
+	if snap.GlobalBest != nil {
+		c, f, r, err := snap.GlobalBest.restore(e.cfg.Precision)
+		if err != nil {
+			return nil, fmt.Errorf("restoring global best: %w", err)
+		}
+		st.globalBest, st.globalBestFitness, st.globalBestResult = c, f, r
+	}
+	if len(snap.Population) == 0 {
+		return st, nil
+	}
+
+	pop := make([]*series.Candidate, len(snap.Population))
+	for i, s := range snap.Population {
+		c, err := series.ParseCandidateLatex(s)
+		if err != nil {
+			return nil, fmt.Errorf("restoring population[%d]: %w", i, err)
+		}
+		if i < len(snap.Genomes) && snap.Genomes[i] != nil {
+			c.Hyper = snap.Genomes[i]
+		}
+		pop[i] = c
+	}
+	st.startAttempt(pop)
+	if snap.AttemptBest != nil {
+		c, f, r, err := snap.AttemptBest.restore(e.cfg.Precision)
+		if err != nil {
+			return nil, fmt.Errorf("restoring attempt best: %w", err)
+		}
+		st.bestThisAttempt, st.bestThisAttemptFitness, st.bestThisAttemptResult = c, f, r
+	}
+	st.gensSinceImprovement = snap.GensSinceImprovement
+	st.bestFoundAtGen = snap.BestFoundAtGen
+	st.attemptGens = snap.AttemptGens
+	st.identified = snap.Identified
+	for name, sb := range snap.TargetBests {
+		c, f, r, err := sb.restore(e.cfg.Precision)
+		if err != nil {
+			return nil, fmt.Errorf("restoring %s best: %w", name, err)
+		}
+		if st.targetBests == nil {
+			st.targetBests = map[string]*targetBest{}
+		}
+		st.targetBests[name] = &targetBest{candidate: c, fitness: f, result: r, foundAtGen: sb.FoundAtGen}
+	}
+	return st, nil
+}
+
+// WriteSnapshot writes a snapshot as JSON, replacing path atomically so a
+// crash mid-write never leaves a truncated checkpoint behind.
+func WriteSnapshot(path string, snap *Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadSnapshot loads a snapshot written by WriteSnapshot.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s: %w", path, err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot %s has version %d, want %d", path, snap.Version, snapshotVersion)
+	}
+	return &snap, nil
+}
+
+// resumeConfig merges a snapshot's search parameters with the run-control
+// settings of the resuming invocation (budget, workers, output).
+func resumeConfig(saved, cur Config) Config {
+	cfg := saved
+	cfg.Generations = cur.Generations
+	cfg.Workers = cur.Workers
+	cfg.Verbose = cur.Verbose
+	cfg.Format = cur.Format
+	cfg.OutDir = cur.OutDir
+	cfg.CheckpointFile = cur.CheckpointFile
+	cfg.CheckpointInterval = cur.CheckpointInterval
+	cfg.Resume = cur.Resume
+	return cfg
+}
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"runtime"
+	"time"
 
 	"github.com/wildfunctions/genetic_series/pkg/constants"
 	"github.com/wildfunctions/genetic_series/pkg/series"
@@ -14,7 +15,7 @@ type Config struct {
 	Strategy    string
 	Population  int
 	Generations int
-	MaxTerms    int64
+	MaxTerms    int64 // terms summed per series before deciding whether to go on
 	MaxDepth    int
 	Precision   uint
 	Seed        int64
@@ -26,6 +27,26 @@ type Config struct {
 	OutDir                string
 	F64PromotionThreshold float64 // min float64 digits to promote to big.Float (0 = disabled)
 	SeedFormula           string  // LaTeX formula for constant-tuning (empty = normal init)
+	CheckpointFile        string        // snapshot path (empty = no snapshots unless resuming)
+	CheckpointInterval    time.Duration // time between periodic snapshots (0 = only on SIGINT/SIGTERM)
+	Resume                string        // snapshot to resume from (empty = fresh run)
+	Targets               []string      // score against all of these at once (empty = Target only)
+	TargetSelect          string        // multi-target selection: "best" or "sum"
+	Transforms            bool          // also credit matches to transforms of each target (1/C, C^2, p/q*C, ...)
+	IdentifyDigits        float64       // min stable digits before PSLQ tries to identify a near-miss (0 = disabled)
+	MaxSeries             int           // max weighted sub-series per candidate (1 = plain series only)
+	Difference            bool          // evolve lim_{N->inf} [partial sum - g(N)] instead of plain sums
+	Form                  string        // candidate form: "sum", "product" or "cf"
+	Islands               []string      // pool:strategy per island (empty = one population from Pool and Strategy)
+	MigrateEvery          int           // generations between island migrations (0 = islands never exchange)
+	Migrants              int           // best candidates each island sends per migration
+	Topology              string        // where migrants go: "ring" or "random"
+	Diversity             string        // selection pressure toward new behaviour: "none", "sharing" or "novelty"
+	ShareRadius           float64       // descriptor distance within which fitness sharing applies
+	NoveltyWeight         float64       // fitness added per unit of novelty
+	MaxTermsCeiling       int64         // most terms a converging but unsettled series is extended to (<= MaxTerms = never)
+	ConstantsFile         string        // file of user-defined constants to register (see constants.LoadFile)
+	TargetValue           string        // digits of Target, registered under that name (empty = Target is a known constant)
 }
 
 // DefaultConfig returns a config with sensible defaults.
@@ -46,5 +67,28 @@ func DefaultConfig() Config {
 		Weights:               series.DefaultWeights(),
 		StagnationLimit:       200,
 		F64PromotionThreshold: 4.0,
+		CheckpointInterval:    10 * time.Minute,
+		IdentifyDigits:        15,
+		TargetSelect:          SelectBest,
+		MaxSeries:             1,
+		Form:                  "sum",
+		MigrateEvery:          10,
+		Migrants:              2,
+		Topology:              TopologyRing,
+		Diversity:             DiversityNone,
+		ShareRadius:           0.5,
+		NoveltyWeight:         10,
+		MaxTermsCeiling:       4096,
 	}
 }
+
+// budget returns the term budget candidates are evaluated under.
+func (c Config) budget() series.Budget {
+	return series.Budget{Terms: c.MaxTerms, Ceiling: c.MaxTermsCeiling}
+}
+
+// digitCap returns the most correct digits a candidate can score under the
+// run's precision and term budget; a match at the cap ends the search.
+func (c Config) digitCap() float64 {
+	return series.DigitCap(c.Precision, max(c.MaxTerms, c.MaxTermsCeiling))
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Diversity modes: how selection is pushed away from behaviours the
+// population already covers.
+const (
+	DiversityNone    = "none"    // strategies see raw fitness
+	DiversitySharing = "sharing" // fitness is divided among candidates with nearby behaviour
+	DiversityNovelty = "novelty" // fitness gains a bonus for behaviour far from the rest
+)
+
+const (
+	descriptorTerms  = 16 // partial sums in a behavioural descriptor
+	noveltyNeighbors = 10 // nearest neighbours novelty is averaged over
+
+	// noveltyMaxDistance caps each neighbour distance novelty counts, so
+	// series whose sums run off to 1e40 are not rewarded for being far away.
+	noveltyMaxDistance = 2.0
+)
+
+// checkDiversity validates cfg.Diversity.
+func checkDiversity(cfg Config) error {
+	switch cfg.Diversity {
+	case "", DiversityNone, DiversitySharing, DiversityNovelty:
+		return nil
+	}
+	return fmt.Errorf("unknown diversity mode %q (want %s, %s or %s)", cfg.Diversity, DiversityNone, DiversitySharing, DiversityNovelty)
+}
+
+// shaping reports whether selection sees shaped rather than raw fitness.
+func (e *Engine) shaping() bool {
+	return e.cfg.Diversity == DiversitySharing || e.cfg.Diversity == DiversityNovelty
+}
+
+// descriptors computes the behavioural descriptor of every candidate.
+func descriptors(pop []*series.Candidate) [][]float64 {
+	descs := make([][]float64, len(pop))
+	for i, c := range pop {
+		descs[i] = series.Descriptor(c, descriptorTerms)
+	}
+	return descs
+}
+
+// populationDiversity is the mean descriptor distance over all pairs of valid
+// candidates: 0 when the population computes a single sequence, however many
+// ways it is written. Failed candidates are left out, since a divergent sum
+// is far from everything without adding anything to search from.
+func populationDiversity(fitnesses []series.Fitness, descs [][]float64) float64 {
+	var total float64
+	pairs := 0
+	for i := range descs {
+		if descs[i] == nil || !fitnesses[i].Valid() {
+			continue
+		}
+		for j := i + 1; j < len(descs); j++ {
+			if descs[j] != nil && fitnesses[j].Valid() {
+				total += series.DescriptorDistance(descs[i], descs[j])
+				pairs++
+			}
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return total / float64(pairs)
+}
+
+// shape returns the fitnesses a strategy selects on under the diversity
+// mode. Only Combined changes, and failed candidates keep their worst score.
+// With sharing, each candidate's score above the slice's lowest valid score
+// is divided by its niche count sum_j max(0, 1 - d_ij/ShareRadius), which
+// counts itself once. With novelty, NoveltyWeight times the mean (capped)
+// distance to its nearest valid neighbours is added.
+func (e *Engine) shape(fitnesses []series.Fitness, descs [][]float64) []series.Fitness {
+	out := append([]series.Fitness(nil), fitnesses...)
+	var valid []int
+	floor := math.Inf(1)
+	for i, f := range fitnesses {
+		if f.Valid() && descs[i] != nil {
+			valid = append(valid, i)
+			floor = math.Min(floor, f.Combined)
+		}
+	}
+	if len(valid) < 2 {
+		return out
+	}
+
+	dists := make([]float64, len(valid))
+	for _, i := range valid {
+		for k, j := range valid {
+			dists[k] = series.DescriptorDistance(descs[i], descs[j])
+		}
+		switch e.cfg.Diversity {
+		case DiversitySharing:
+			niche := 0.0
+			for _, d := range dists {
+				if e.cfg.ShareRadius > 0 && d < e.cfg.ShareRadius {
+					niche += 1 - d/e.cfg.ShareRadius
+				}
+			}
+			if niche > 1 {
+				out[i].Combined = floor + (fitnesses[i].Combined-floor)/niche
+			}
+		case DiversityNovelty:
+			sort.Float64s(dists) // dists[0] is the candidate itself
+			k := min(noveltyNeighbors, len(dists)-1)
+			novelty := 0.0
+			for _, d := range dists[1 : k+1] {
+				novelty += math.Min(d, noveltyMaxDistance)
+			}
+			out[i].Combined += e.cfg.NoveltyWeight * novelty / float64(k)
+		}
+	}
+	return out
+}
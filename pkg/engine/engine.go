@@ -2,41 +2,95 @@ package engine
 
 import (
 	"fmt"
-	"math/big"
 	"math/rand"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/wildfunctions/genetic_series/pkg/constants"
-	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/identify"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 	"github.com/wildfunctions/genetic_series/pkg/strategy"
 )
 
 // Engine runs the evolutionary search.
 type Engine struct {
-	cfg       Config
-	pool      pool.Pool
-	strategy  strategy.Strategy
-	target    *big.Float
-	targetF64 float64
-	rng       *rand.Rand
+	cfg     Config
+	islands []island // one unless Config.Islands is set
+	targets []target
+	rng     *rand.Rand
+	src     *pcgSource
+	state   *runState   // non-nil when resuming from a snapshot
+	ident   *identifier // nil when identification is disabled
 }
 
-// New creates a new engine from the given config.
+// New creates a new engine from the given config. If cfg.Resume names a
+// snapshot, the search parameters are taken from the snapshot and Run
+// continues where the snapshot left off.
 func New(cfg Config) (*Engine, error) {
-	p, err := pool.Get(cfg.Pool)
+	var snap *Snapshot
+	if cfg.Resume != "" {
+		var err error
+		snap, err = ReadSnapshot(cfg.Resume)
+		if err != nil {
+			return nil, fmt.Errorf("loading snapshot: %w", err)
+		}
+		cfg = resumeConfig(snap.Config, cfg)
+	}
+	if err := registerConstants(cfg); err != nil {
+		return nil, err
+	}
+
+	islands, err := newIslands(cfg)
 	if err != nil {
 		return nil, err
 	}
-	s, err := strategy.Get(cfg.Strategy)
+	if err := checkDiversity(cfg); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Targets) == 1 {
+		cfg.Target, cfg.Targets = cfg.Targets[0], nil
+	}
+	targets, err := resolveTargets(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng, src := newRNG(seed)
+
+	e := &Engine{
+		cfg:     cfg,
+		islands: islands,
+		targets: targets,
+		rng:     rng,
+		src:     src,
+		ident:   newIdentifier(cfg),
+	}
+
+	if snap != nil {
+		st, err := e.restore(snap)
+		if err != nil {
+			return nil, fmt.Errorf("resuming from %s: %w", cfg.Resume, err)
+		}
+		e.state = st
+	}
+
+	return e, nil
+}
+
+// configureStrategy passes the options that change what a strategy builds
+// (seed formula, form, composites, differences) to s, the strategy called
+// name, failing if s does not support one that is set.
+func configureStrategy(s strategy.Strategy, name string, cfg Config) error {
 	// If a seed formula was provided, pass it to the strategy.
 	if cfg.SeedFormula != "" {
 		type seedable interface {
@@ -44,72 +98,165 @@ func New(cfg Config) (*Engine, error) {
 		}
 		if ss, ok := s.(seedable); ok {
 			if err := ss.SetSeedFormula(cfg.SeedFormula); err != nil {
-				return nil, fmt.Errorf("invalid seed formula: %w", err)
+				return fmt.Errorf("invalid seed formula: %w", err)
 			}
 		} else {
-			return nil, fmt.Errorf("strategy %q does not support -seed-formula", cfg.Strategy)
+			return fmt.Errorf("strategy %q does not support -seed-formula", name)
+		}
+	}
+
+	form := series.FormSum
+	if cfg.Form != "" {
+		var err error
+		if form, err = series.ParseForm(cfg.Form); err != nil {
+			return err
 		}
 	}
+	if form != series.FormSum {
+		if cfg.MaxSeries > 1 || cfg.Difference {
+			return fmt.Errorf("-form %s does not combine with -max-series or -difference", form)
+		}
+		type formed interface {
+			SetForm(series.Form)
+		}
+		fs, ok := s.(formed)
+		if !ok {
+			return fmt.Errorf("strategy %q does not support -form %s", name, form)
+		}
+		fs.SetForm(form)
+	}
 
-	c := constants.Get(cfg.Target)
-	if c == nil {
-		return nil, fmt.Errorf("unknown target constant: %s (available: %v)", cfg.Target, constants.Names())
+	if cfg.MaxSeries > 1 && cfg.Difference {
+		return fmt.Errorf("-difference does not combine with -max-series")
+	}
+	if cfg.MaxSeries > 1 {
+		type composable interface {
+			SetMaxSeries(int)
+		}
+		cs, ok := s.(composable)
+		if !ok {
+			return fmt.Errorf("strategy %q does not support -max-series", name)
+		}
+		cs.SetMaxSeries(cfg.MaxSeries)
 	}
 
-	seed := cfg.Seed
-	if seed == 0 {
-		seed = rand.Int63()
+	if cfg.Difference {
+		type differencing interface {
+			SetDifference(bool)
+		}
+		ds, ok := s.(differencing)
+		if !ok {
+			return fmt.Errorf("strategy %q does not support -difference", name)
+		}
+		ds.SetDifference(true)
 	}
 
-	return &Engine{
-		cfg:       cfg,
-		pool:      p,
-		strategy:  s,
-		target:    c.Value,
-		targetF64: c.Float64Value,
-		rng:       rand.New(rand.NewSource(seed)),
-	}, nil
+	return nil
+}
+
+// checkpointPath returns where snapshots are written: the -checkpoint file,
+// else the snapshot being resumed. Checkpointing is opt-in, so "" (disabled)
+// when neither is set.
+func (e *Engine) checkpointPath() string {
+	if e.cfg.CheckpointFile != "" {
+		return e.cfg.CheckpointFile
+	}
+	return e.cfg.Resume
+}
+
+// saveCheckpoint writes a snapshot of st, logging (not failing) on error.
+func (e *Engine) saveCheckpoint(st *runState) {
+	path := e.checkpointPath()
+	if path == "" {
+		return
+	}
+	snap, err := e.snapshot(st)
+	if err == nil {
+		err = WriteSnapshot(path, snap)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing checkpoint %s: %v\n", path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Checkpoint: wrote %s (attempt %d, gen %d)\n", path, st.attempt, st.attemptGens)
 }
 
 // Run executes the evolutionary loop and returns the final report.
+//
+// Snapshots are written every CheckpointInterval and on SIGINT/SIGTERM. They
+// are taken at generation boundaries (before evaluation), so resuming replays
+// the run exactly from that point.
 func (e *Engine) Run() FinalReport {
-	runTimestamp := fmt.Sprintf("%d", time.Now().Unix())
-	var hallOfFame []AttemptResult
-	var genReports []GenerationReport
-	totalGensUsed := 0
-	attempt := 0
-	tabuSet := map[string]bool{}
-
-	// Track best across all attempts
-	var globalBest *series.Candidate
-	var globalBestFitness series.Fitness
-	var globalBestResult series.EvalResult
-	globalBestFitness.Combined = -1e18
+	st := e.state
+	if st == nil {
+		st = newRunState()
+	}
+	e.state = nil
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	lastCheckpoint := time.Now()
+	interrupted := false
 
 	genBudget := "unlimited"
 	if e.cfg.Generations > 0 {
 		genBudget = fmt.Sprintf("%d", e.cfg.Generations)
 	}
-	fmt.Fprintf(os.Stderr, "Timestamp: [%s] Starting target %s, pool %s, strategy %s, population %d, %s gen budget, stagnation %d, workers %d, seed %d\n",
-		runTimestamp, e.cfg.Target, e.cfg.Pool, e.cfg.Strategy, e.cfg.Population, genBudget, e.cfg.StagnationLimit, e.cfg.Workers, e.cfg.Seed)
+	targetDesc := e.targets[0].name
+	if e.multiTarget() {
+		names := make([]string, len(e.targets))
+		for i, tg := range e.targets {
+			names[i] = tg.name
+		}
+		sel := e.cfg.TargetSelect
+		if sel == "" {
+			sel = SelectBest
+		}
+		targetDesc = fmt.Sprintf("%s (select %s)", strings.Join(names, ","), sel)
+	}
+	searchDesc := fmt.Sprintf("pool %s, strategy %s", e.cfg.Pool, e.cfg.Strategy)
+	if len(e.cfg.Islands) > 0 {
+		searchDesc = fmt.Sprintf("islands %s (%s, migrate %d every %d gens)",
+			strings.Join(e.cfg.Islands, ","), e.cfg.Topology, e.cfg.Migrants, e.cfg.MigrateEvery)
+	}
+	fmt.Fprintf(os.Stderr, "Timestamp: [%s] Starting target %s, %s, population %d, %s gen budget, stagnation %d, workers %d, seed %d\n",
+		st.runTimestamp, targetDesc, searchDesc, e.cfg.Population, genBudget, e.cfg.StagnationLimit, e.cfg.Workers, e.cfg.Seed)
 
 	unlimited := e.cfg.Generations <= 0
-	for unlimited || totalGensUsed < e.cfg.Generations {
-		attempt++
-		fmt.Fprintf(os.Stderr, "\n=== Attempt %d ===\n", attempt)
-
-		population := e.strategy.Initialize(e.pool, e.rng, e.cfg.Population)
+	for !interrupted && (unlimited || st.totalGensUsed < e.cfg.Generations) {
+		if st.population == nil {
+			st.attempt++
+			fmt.Fprintf(os.Stderr, "\n=== Attempt %d ===\n", st.attempt)
+			st.startAttempt(e.initialize())
+		} else {
+			fmt.Fprintf(os.Stderr, "\n=== Attempt %d (resumed at gen %d) ===\n", st.attempt, st.attemptGens)
+		}
 
-		var bestThisAttempt *series.Candidate
-		var bestThisAttemptFitness series.Fitness
-		var bestThisAttemptResult series.EvalResult
-		bestThisAttemptFitness.Combined = -1e18
-		gensSinceImprovement := 0
-		bestFoundAtGen := 0
-		attemptGens := 0
+		for unlimited || st.totalGensUsed < e.cfg.Generations {
+			// Generation boundary: the state here is exactly what a snapshot captures.
+			select {
+			case sig := <-sigs:
+				fmt.Fprintf(os.Stderr, "\nReceived %v, stopping\n", sig)
+				e.saveCheckpoint(st)
+				interrupted = true
+			default:
+				if e.cfg.CheckpointInterval > 0 && time.Since(lastCheckpoint) >= e.cfg.CheckpointInterval {
+					e.saveCheckpoint(st)
+					lastCheckpoint = time.Now()
+				}
+			}
+			if interrupted {
+				break
+			}
 
-		for unlimited || totalGensUsed < e.cfg.Generations {
-			fitnesses, results := e.evaluatePopulation(population, tabuSet)
+			population := st.population
+			fitnesses, results, relations, perTarget := e.evaluatePopulation(population, st.tabu)
+			st.recordIdentified(population, relations)
+			st.trackPareto(e.targets, population, fitnesses, perTarget)
+			if perTarget != nil {
+				st.trackTargets(e.targets, population, perTarget, results)
+			}
 
 			// Find best and second-best in this generation
 			bestIdx, secondIdx := 0, -1
@@ -126,24 +273,27 @@ func (e *Engine) Run() FinalReport {
 				}
 			}
 			avgFit /= float64(len(fitnesses))
+			descs := descriptors(population)
+			diversity := populationDiversity(fitnesses, descs)
 
-			improved := fitnesses[bestIdx].Combined > bestThisAttemptFitness.Combined
+			improved := fitnesses[bestIdx].Combined > st.bestThisAttemptFitness.Combined
 			if improved {
-				bestThisAttempt = population[bestIdx].Clone()
-				bestThisAttemptFitness = fitnesses[bestIdx]
-				bestThisAttemptResult = results[bestIdx]
-				bestFoundAtGen = attemptGens
-				gensSinceImprovement = 0
+				st.bestThisAttempt = population[bestIdx].Clone()
+				st.bestThisAttemptFitness = fitnesses[bestIdx]
+				st.bestThisAttemptResult = results[bestIdx]
+				st.bestFoundAtGen = st.attemptGens
+				st.gensSinceImprovement = 0
 			} else {
-				gensSinceImprovement++
+				st.gensSinceImprovement++
 			}
 
 			report := GenerationReport{
-				Generation:    attemptGens,
+				Generation:    st.attemptGens,
 				BestFitness:   fitnesses[bestIdx],
 				BestCandidate: population[bestIdx].String(),
 				BestLaTeX:     population[bestIdx].LaTeX(),
 				AvgFitness:    avgFit,
+				Diversity:     diversity,
 			}
 			if results[bestIdx].OK && results[bestIdx].PartialSum != nil {
 				report.BestPartialSum = results[bestIdx].PartialSum.Text('g', 20)
@@ -152,40 +302,41 @@ func (e *Engine) Run() FinalReport {
 			if e.cfg.Verbose {
 				WriteTextReport(os.Stderr, report)
 			} else if improved {
-				fmt.Fprintf(os.Stderr, "[gen %d] NEW BEST %.1f digits | fitness %.4f\n",
-					attemptGens, bestThisAttemptFitness.CorrectDigits, bestThisAttemptFitness.Combined)
-				fmt.Fprintf(os.Stderr, "  #1: %s\n", bestThisAttempt.String())
+				fmt.Fprintf(os.Stderr, "[gen %d] NEW BEST %.1f digits%s | fitness %.4f\n",
+					st.attemptGens, st.bestThisAttemptFitness.CorrectDigits, transformSuffix(st.bestThisAttemptFitness.Transform),
+					st.bestThisAttemptFitness.Combined)
+				fmt.Fprintf(os.Stderr, "  #1: %s\n", st.bestThisAttempt.String())
 				if secondIdx >= 0 && results[secondIdx].OK {
 					fmt.Fprintf(os.Stderr, "  #2: %.1f digits | %s\n",
 						fitnesses[secondIdx].CorrectDigits, population[secondIdx].String())
 				}
-			} else if attemptGens%20 == 0 {
-				fmt.Fprintf(os.Stderr, "[gen %d]\n", attemptGens)
-				if bestThisAttempt != nil {
+			} else if st.attemptGens%20 == 0 {
+				fmt.Fprintf(os.Stderr, "[gen %d] diversity %.3f\n", st.attemptGens, diversity)
+				if st.bestThisAttempt != nil {
 					fmt.Fprintf(os.Stderr, "  #1: %.1f digits | %s\n",
-						bestThisAttemptFitness.CorrectDigits, bestThisAttempt.String())
+						st.bestThisAttemptFitness.CorrectDigits, st.bestThisAttempt.String())
 				}
 				if secondIdx >= 0 && results[secondIdx].OK {
 					fmt.Fprintf(os.Stderr, "  #2: %.1f digits | %s\n",
 						fitnesses[secondIdx].CorrectDigits, population[secondIdx].String())
 				}
 			}
-			genReports = append(genReports, report)
+			st.genReports = append(st.genReports, report)
 
-			totalGensUsed++
-			attemptGens++
+			st.totalGensUsed++
+			st.attemptGens++
 
 			// Hit the digit cap — nothing left to find, move on.
-			if bestThisAttemptFitness.CorrectDigits >= float64(series.MaxDigits) {
-				fmt.Fprintf(os.Stderr, "[gen %d] Hit %d digit cap, done\n",
-					attemptGens, series.MaxDigits)
+			if st.bestThisAttemptFitness.CorrectDigits >= e.cfg.digitCap() {
+				fmt.Fprintf(os.Stderr, "[gen %d] Hit %.0f digit cap, done\n",
+					st.attemptGens, e.cfg.digitCap())
 				break
 			}
 
 			// Check stagnation — patience scales with best digits found so far.
 			// Low-digit matches get a short leash; high-digit matches get full patience.
 			if e.cfg.StagnationLimit > 0 {
-				digits := bestThisAttemptFitness.CorrectDigits
+				digits := st.bestThisAttemptFitness.CorrectDigits
 				scale := digits / 10.0
 				if scale > 1.0 {
 					scale = 1.0
@@ -194,123 +345,102 @@ func (e *Engine) Run() FinalReport {
 				if effectiveLimit < 20 {
 					effectiveLimit = 20
 				}
-				if gensSinceImprovement >= effectiveLimit {
+				if st.gensSinceImprovement >= effectiveLimit {
 					fmt.Fprintf(os.Stderr, "[gen %d] Stagnated after %d generations (%.1f digits, patience %d)\n",
-						attemptGens, gensSinceImprovement, digits, effectiveLimit)
+						st.attemptGens, st.gensSinceImprovement, digits, effectiveLimit)
 					break
 				}
 			}
 
 			// Evolve
-			population = e.strategy.Evolve(population, fitnesses, e.pool, e.rng)
+			st.population = e.evolve(population, fitnesses, descs, st.attemptGens)
 		}
-
-		// Save attempt result
-		ar := AttemptResult{
-			Attempt:        attempt,
-			Generations:    attemptGens,
-			BestFoundAtGen: bestFoundAtGen,
-			Timestamp:      time.Now().UTC(),
-		}
-		if bestThisAttempt != nil {
-			ar.BestCandidate = bestThisAttempt.String()
-			ar.BestLaTeX = bestThisAttempt.LaTeX()
-			ar.BestFitness = bestThisAttemptFitness
-			if bestThisAttemptResult.OK && bestThisAttemptResult.PartialSum != nil {
-				ar.BestPartialSum = bestThisAttemptResult.PartialSum.Text('g', 20)
+		st.population = nil
+
+		// Save attempt result (one per target in multi-target runs)
+		bestThisAttempt := st.bestThisAttempt
+		if e.multiTarget() {
+			for _, tg := range e.targets {
+				tb := st.targetBests[tg.name]
+				if tb == nil || tb.fitness.Combined <= series.WorstFitness().Combined {
+					continue
+				}
+				ar := st.attemptResult(tg, tb.candidate, tb.fitness, tb.result, tb.foundAtGen)
+				if st.identified != nil {
+					ar.Identified, st.identified = st.identified, nil
+				}
+				st.hallOfFame = append(st.hallOfFame, ar)
 			}
+		} else if bestThisAttempt != nil || !interrupted {
+			ar := st.attemptResult(e.targets[0], bestThisAttempt, st.bestThisAttemptFitness, st.bestThisAttemptResult, st.bestFoundAtGen)
+			ar.Identified = st.identified
+			st.hallOfFame = append(st.hallOfFame, ar)
 		}
-		hallOfFame = append(hallOfFame, ar)
 
 		// Add best candidate to tabu set so future restarts avoid it
 		if bestThisAttempt != nil {
-			s := bestThisAttempt.String()
-			if !tabuSet[s] {
-				tabuSet[s] = true
-				fmt.Fprintf(os.Stderr, "Tabu: added %q\n", s)
+			if st.tabu.add(bestThisAttempt) {
+				fmt.Fprintf(os.Stderr, "Tabu: added %q\n", bestThisAttempt.String())
 			}
 		}
 
 		// Update global best
-		if bestThisAttempt != nil && bestThisAttemptFitness.Combined > globalBestFitness.Combined {
-			globalBest = bestThisAttempt
-			globalBestFitness = bestThisAttemptFitness
-			globalBestResult = bestThisAttemptResult
+		if bestThisAttempt != nil && st.bestThisAttemptFitness.Combined > st.globalBestFitness.Combined {
+			st.globalBest = bestThisAttempt
+			st.globalBestFitness = st.bestThisAttemptFitness
+			st.globalBestResult = st.bestThisAttemptResult
 		}
 
-		WriteHallOfFame(os.Stderr, hallOfFame)
+		WriteHallOfFame(os.Stderr, st.hallOfFame)
 
 		// Write LaTeX hall of fame after each attempt so it survives Ctrl+C
 		if e.cfg.OutDir != "" {
-			base := fmt.Sprintf("%s_%s_%s_%s", e.cfg.Target, e.cfg.Pool, e.cfg.Strategy, runTimestamp)
-			tmpDir := os.TempDir()
-			tmpTex := filepath.Join(tmpDir, base+".tex")
-
-			f, createErr := os.Create(tmpTex)
-			if createErr != nil {
-				fmt.Fprintf(os.Stderr, "error creating %s: %v\n", tmpTex, createErr)
-			} else {
-				WriteHallOfFameLatex(f, hallOfFame, e.cfg, e.target)
-				f.Close()
-
-				// Compile to PDF if pdflatex is available
-				if pdflatex, err := exec.LookPath("pdflatex"); err == nil {
-					cmd := exec.Command(pdflatex, "-interaction=nonstopmode", base+".tex")
-					cmd.Dir = tmpDir
-					pdfOut, pdfErr := cmd.CombinedOutput()
-					if pdfErr != nil {
-						fmt.Fprintf(os.Stderr, "pdflatex failed: %v\n%s\n", pdfErr, pdfOut)
-					}
-				}
-
-				// Copy outputs to outdir using absolute path
-				absOut, _ := filepath.Abs(e.cfg.OutDir)
-				for _, ext := range []string{".tex", ".pdf"} {
-					src := filepath.Join(tmpDir, base+ext)
-					if _, err := os.Stat(src); err == nil {
-						dst := filepath.Join(absOut, base+ext)
-						if err := copyFile(src, dst); err != nil {
-							fmt.Fprintf(os.Stderr, "error writing %s: %v\n", dst, err)
-						} else {
-							fmt.Fprintf(os.Stderr, "Wrote %s\n", dst)
-						}
-					}
-				}
-				// Clean up all temp files
-				for _, ext := range []string{".tex", ".aux", ".log", ".pdf"} {
-					os.Remove(filepath.Join(tmpDir, base+ext))
-				}
-			}
+			e.writeHallOfFameFiles(st)
 		}
 
-		// If global best hit the digit cap, no point restarting
-		if globalBestFitness.CorrectDigits >= float64(series.MaxDigits) {
-			fmt.Fprintf(os.Stderr, "Global best hit %d digit cap, stopping\n", series.MaxDigits)
+		// If global best hit the digit cap, no point restarting. Multi-target
+		// runs keep going until every target has been capped.
+		if e.multiTarget() {
+			if allTargetsCapped(e.targets, st.hallOfFame, e.cfg.digitCap()) {
+				fmt.Fprintf(os.Stderr, "Every target hit %.0f digit cap, stopping\n", e.cfg.digitCap())
+				break
+			}
+		} else if st.globalBestFitness.CorrectDigits >= e.cfg.digitCap() {
+			fmt.Fprintf(os.Stderr, "Global best hit %.0f digit cap, stopping\n", e.cfg.digitCap())
 			break
 		}
 	}
 
 	// Dedup and cap attempts for the JSON report
-	dedupedAttempts := dedupAttempts(sortByDigits(hallOfFame))
-	if len(dedupedAttempts) > maxHallOfFame {
-		dedupedAttempts = dedupedAttempts[:maxHallOfFame]
+	var dedupedAttempts []AttemptResult
+	order, groups := groupByTarget(st.hallOfFame)
+	for _, name := range order {
+		deduped := dedupAttempts(sortByDigits(groups[name]))
+		if len(deduped) > maxHallOfFame {
+			deduped = deduped[:maxHallOfFame]
+		}
+		dedupedAttempts = append(dedupedAttempts, deduped...)
 	}
 
 	finalReport := FinalReport{
 		Config:      e.cfg,
-		BestFitness: globalBestFitness,
+		BestFitness: st.globalBestFitness,
 		Attempts:    dedupedAttempts,
+		Pareto:      st.pareto.entries(e.targets),
+	}
+	if e.multiTarget() {
+		finalReport.Targets = targetResults(e.targets, st.hallOfFame)
 	}
 
 	if e.cfg.Verbose {
-		finalReport.Generations = genReports
+		finalReport.Generations = st.genReports
 	}
 
-	if globalBest != nil {
-		finalReport.BestCandidate = globalBest.String()
-		finalReport.BestLaTeX = globalBest.LaTeX()
-		if globalBestResult.OK && globalBestResult.PartialSum != nil {
-			finalReport.BestPartialSum = globalBestResult.PartialSum.Text('g', 20)
+	if st.globalBest != nil {
+		finalReport.BestCandidate = st.globalBest.String()
+		finalReport.BestLaTeX = st.globalBest.LaTeX()
+		if st.globalBestResult.OK && st.globalBestResult.PartialSum != nil {
+			finalReport.BestPartialSum = st.globalBestResult.PartialSum.Text('g', 20)
 		}
 	}
 
@@ -320,11 +450,20 @@ func (e *Engine) Run() FinalReport {
 // evaluatePopulation evaluates all candidates in parallel, using a two-phase
 // float64 fast path when F64PromotionThreshold > 0. Phase 1 evaluates all
 // candidates at float64 speed. Phase 2 promotes only candidates that cleared
-// the digit threshold to the expensive big.Float path.
-func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]bool) ([]series.Fitness, []series.EvalResult) {
+// the digit threshold to the expensive big.Float path. Promoted candidates that
+// miss the target are also run through PSLQ; relations[i] is non-nil for each
+// one identified. In multi-target runs perTarget[i] holds candidate i's fitness
+// against each target and fitnesses[i] their combination; perTarget is nil
+// otherwise.
+func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabu *tabuList) ([]series.Fitness, []series.EvalResult, []*identify.Relation, [][]series.Fitness) {
 	n := len(pop)
 	fitnesses := make([]series.Fitness, n)
 	results := make([]series.EvalResult, n)
+	relations := make([]*identify.Relation, n)
+	var perTarget [][]series.Fitness
+	if e.multiTarget() {
+		perTarget = make([][]series.Fitness, n)
+	}
 
 	// Pre-compute string representations once for tabu lookups.
 	strs := make([]string, n)
@@ -335,8 +474,8 @@ func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]
 	threshold := e.cfg.F64PromotionThreshold
 	if threshold <= 0 {
 		// Disabled — fall through to big.Float for everyone.
-		e.evaluateBigFloat(pop, fitnesses, results, nil, tabuSet, strs)
-		return fitnesses, results
+		e.evaluateBigFloat(pop, fitnesses, results, relations, perTarget, nil, tabu, strs)
+		return fitnesses, results, relations, perTarget
 	}
 
 	workers := e.cfg.Workers
@@ -361,13 +500,16 @@ func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				if tabuSet[j.str] {
+				if tabu.has(j.candidate, j.str) {
 					fitnesses[j.idx] = series.WorstFitness()
 					continue
 				}
-				r64 := series.EvaluateCandidateF64(j.candidate, e.cfg.MaxTerms)
-				f64 := series.ComputeFitnessF64(j.candidate, r64, e.targetF64, e.cfg.Weights)
+				r64 := series.EvaluateCandidateBudgetF64(j.candidate, e.cfg.budget())
+				f64, per := e.scoreF64(j.candidate, r64)
 				fitnesses[j.idx] = f64
+				if perTarget != nil {
+					perTarget[j.idx] = per
+				}
 				if f64.CorrectDigits >= threshold {
 					promote[j.idx] = true
 				}
@@ -382,15 +524,15 @@ func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]
 	wg.Wait()
 
 	// Phase 2: big.Float eval for promoted candidates only.
-	e.evaluateBigFloat(pop, fitnesses, results, promote, tabuSet, strs)
+	e.evaluateBigFloat(pop, fitnesses, results, relations, perTarget, promote, tabu, strs)
 
-	return fitnesses, results
+	return fitnesses, results, relations, perTarget
 }
 
 // evaluateBigFloat runs big.Float evaluation on selected candidates.
 // If promote is nil, all candidates are evaluated. Otherwise only promote[i]==true.
 // strs contains pre-computed String() representations for tabu lookups.
-func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fitness, results []series.EvalResult, promote []bool, tabuSet map[string]bool, strs []string) {
+func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fitness, results []series.EvalResult, relations []*identify.Relation, perTarget [][]series.Fitness, promote []bool, tabu *tabuList, strs []string) {
 	workers := e.cfg.Workers
 	if workers <= 0 {
 		workers = 1
@@ -410,14 +552,18 @@ func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fi
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				if tabuSet[j.str] {
+				if tabu.has(j.candidate, j.str) {
 					fitnesses[j.idx] = series.WorstFitness()
 					continue
 				}
-				result := series.EvaluateCandidate(j.candidate, e.cfg.MaxTerms, e.cfg.Precision)
-				fitness := series.ComputeFitness(j.candidate, result, e.target, e.cfg.Weights)
+				result := series.EvaluateCandidateBudget(j.candidate, e.cfg.budget(), e.cfg.Precision)
+				fitness, per := e.score(j.candidate, result)
 				results[j.idx] = result
 				fitnesses[j.idx] = fitness
+				if perTarget != nil {
+					perTarget[j.idx] = per
+				}
+				relations[j.idx] = e.ident.check(result, fitness)
 			}
 		}()
 	}
@@ -431,6 +577,59 @@ func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fi
 	wg.Wait()
 }
 
+// writeHallOfFameFiles writes the LaTeX hall of fame (and a PDF when pdflatex
+// is available) to OutDir, one document per target.
+func (e *Engine) writeHallOfFameFiles(st *runState) {
+	_, groups := groupByTarget(st.hallOfFame)
+	for _, tg := range e.targets {
+		attempts := groups[tg.name]
+		if len(attempts) == 0 {
+			continue
+		}
+		cfg := e.cfg
+		cfg.Target = tg.name
+		base := fmt.Sprintf("%s_%s_%s", tg.name, e.searchName(), st.runTimestamp)
+		tmpDir := os.TempDir()
+		tmpTex := filepath.Join(tmpDir, base+".tex")
+
+		f, createErr := os.Create(tmpTex)
+		if createErr != nil {
+			fmt.Fprintf(os.Stderr, "error creating %s: %v\n", tmpTex, createErr)
+			continue
+		}
+		WriteHallOfFameLatex(f, attempts, st.pareto[tg.name], cfg, tg.value)
+		f.Close()
+
+		// Compile to PDF if pdflatex is available
+		if pdflatex, err := exec.LookPath("pdflatex"); err == nil {
+			cmd := exec.Command(pdflatex, "-interaction=nonstopmode", base+".tex")
+			cmd.Dir = tmpDir
+			pdfOut, pdfErr := cmd.CombinedOutput()
+			if pdfErr != nil {
+				fmt.Fprintf(os.Stderr, "pdflatex failed: %v\n%s\n", pdfErr, pdfOut)
+			}
+		}
+
+		// Copy outputs to outdir using absolute path
+		absOut, _ := filepath.Abs(e.cfg.OutDir)
+		for _, ext := range []string{".tex", ".pdf"} {
+			src := filepath.Join(tmpDir, base+ext)
+			if _, err := os.Stat(src); err == nil {
+				dst := filepath.Join(absOut, base+ext)
+				if err := copyFile(src, dst); err != nil {
+					fmt.Fprintf(os.Stderr, "error writing %s: %v\n", dst, err)
+				} else {
+					fmt.Fprintf(os.Stderr, "Wrote %s\n", dst)
+				}
+			}
+		}
+		// Clean up all temp files
+		for _, ext := range []string{".tex", ".aux", ".log", ".pdf"} {
+			os.Remove(filepath.Join(tmpDir, base+ext))
+		}
+	}
+}
+
 // copyFile copies src to dst, creating or overwriting dst.
 func copyFile(src, dst string) error {
 	data, err := os.ReadFile(src)
@@ -1,9 +1,19 @@
 package engine
 
 import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/identify"
 	_ "github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/series"
 	_ "github.com/wildfunctions/genetic_series/pkg/strategy"
 )
 
@@ -59,8 +69,12 @@ func TestEngine_Restart(t *testing.T) {
 		t.Errorf("Expected multiple attempts with stagnation limit 5 and 50 gens, got %d", len(report.Attempts))
 	}
 
-	// Verify attempts are populated correctly
-	for i, a := range report.Attempts {
+	// Verify attempts are populated correctly. The report ranks them by
+	// digits, so put them back in run order first.
+	byRun := make([]AttemptResult, len(report.Attempts))
+	copy(byRun, report.Attempts)
+	sort.Slice(byRun, func(i, j int) bool { return byRun[i].Attempt < byRun[j].Attempt })
+	for i, a := range byRun {
 		if a.Attempt != i+1 {
 			t.Errorf("Attempt %d has wrong attempt number %d", i+1, a.Attempt)
 		}
@@ -145,6 +159,92 @@ func TestEngine_InvalidPool(t *testing.T) {
 	}
 }
 
+func TestEngine_Composite(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Strategy = "hypergeom"
+	cfg.MaxSeries = 3
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for -max-series with a strategy that cannot build composites")
+	}
+
+	cfg.Strategy = "tournament"
+	cfg.Population = 30
+	cfg.Generations = 10
+	cfg.MaxTerms = 128
+	cfg.Seed = 42
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := e.Run(); report.BestCandidate == "" {
+		t.Error("Expected a best candidate")
+	}
+}
+
+func TestEngine_Difference(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Strategy = "hypergeom"
+	cfg.Difference = true
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for -difference with a strategy that cannot build differences")
+	}
+	cfg.Strategy = "hillclimb"
+	cfg.MaxSeries = 2
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for -difference with -max-series")
+	}
+	cfg.MaxSeries = 1
+
+	cfg.Target = "euler_gamma"
+	cfg.Generations = 40
+	cfg.Seed = 1
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+	// The tail bound certifies only a few digits of H_N - ln N, so judge the
+	// difference by its accelerated estimate.
+	if !strings.HasPrefix(report.BestCandidate, "lim_{N->inf}") || report.BestFitness.AcceleratedDigits < 8 {
+		t.Errorf("best = %s with %.1f accelerated digits, want a difference close to gamma",
+			report.BestCandidate, report.BestFitness.AcceleratedDigits)
+	}
+}
+
+func TestEngine_Forms(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Form = "spiral"
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for an unknown form")
+	}
+	cfg.Form = "product"
+	cfg.Strategy = "hypergeom"
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for -form with a strategy that only builds sums")
+	}
+	cfg.Strategy = "tournament"
+	cfg.MaxSeries = 2
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for -form product with -max-series")
+	}
+
+	cfg.MaxSeries = 1
+	cfg.Population = 30
+	cfg.Generations = 10
+	cfg.MaxTerms = 128
+	cfg.Seed = 42
+	for _, form := range []struct{ name, prefix string }{{"product", "Prod_"}, {"cf", " + K_"}} {
+		cfg.Form = form.name
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report := e.Run(); !strings.Contains(report.BestCandidate, form.prefix) {
+			t.Errorf("-form %s: best candidate %q is not a %s", form.name, report.BestCandidate, form.name)
+		}
+	}
+}
+
 // TestEngine_F64Disabled verifies that threshold=0 (no float64 fast path) still works.
 func TestEngine_F64Disabled(t *testing.T) {
 	cfg := DefaultConfig()
@@ -196,3 +296,541 @@ func TestEngine_JSONFormat(t *testing.T) {
 		t.Error("Expected a best candidate in JSON mode")
 	}
 }
+
+// TestEngine_CheckpointResume verifies that resuming from a mid-run snapshot
+// reproduces the same search as an uninterrupted run with the same seed.
+func TestEngine_CheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	ckpt := filepath.Join(dir, "run.ckpt.json")
+
+	base := DefaultConfig()
+	base.Target = "e"
+	base.Population = 20
+	base.MaxTerms = 64
+	base.Seed = 7
+	base.StagnationLimit = 5
+
+	// First leg: checkpoint at every generation boundary, stop after 6 gens.
+	first := base
+	first.Generations = 6
+	first.CheckpointFile = ckpt
+	first.CheckpointInterval = time.Nanosecond
+	e, err := New(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run()
+
+	snap, err := ReadSnapshot(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.TotalGensUsed != 5 {
+		t.Fatalf("last snapshot at total gen %d, want 5", snap.TotalGensUsed)
+	}
+
+	// Straight run over the full budget. Verbose reports carry the
+	// per-generation history, which the snapshot must bring back too.
+	straight := base
+	straight.Generations = 14
+	straight.Verbose = true
+	e, err = New(straight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := e.Run()
+
+	// Resumed run over the same budget.
+	resumed := base
+	resumed.Generations = 14
+	resumed.Resume = ckpt
+	resumed.CheckpointInterval = 0
+	resumed.Verbose = true
+	e, err = New(resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := e.Run()
+
+	if got.BestCandidate != want.BestCandidate {
+		t.Errorf("resumed best = %s, want %s", got.BestCandidate, want.BestCandidate)
+	}
+	if len(got.Attempts) != len(want.Attempts) {
+		t.Fatalf("resumed run has %d attempts, want %d", len(got.Attempts), len(want.Attempts))
+	}
+	for i := range want.Attempts {
+		if got.Attempts[i].BestCandidate != want.Attempts[i].BestCandidate {
+			t.Errorf("attempt %d: got %s, want %s", i, got.Attempts[i].BestCandidate, want.Attempts[i].BestCandidate)
+		}
+	}
+	if len(got.Generations) != len(want.Generations) {
+		t.Fatalf("resumed run reports %d generations, want %d", len(got.Generations), len(want.Generations))
+	}
+	for i := range want.Generations {
+		if got.Generations[i].BestCandidate != want.Generations[i].BestCandidate {
+			t.Errorf("generation %d: got %s, want %s", i, got.Generations[i].BestCandidate, want.Generations[i].BestCandidate)
+		}
+	}
+}
+
+// Hypergeometric candidates evaluate through their genome, so a resumed
+// run must get the genomes back, not just the trees.
+func TestEngine_HypergeomResume(t *testing.T) {
+	dir := t.TempDir()
+	ckpt := filepath.Join(dir, "run.ckpt.json")
+
+	base := DefaultConfig()
+	base.Target = "pi"
+	base.Strategy = "hypergeom"
+	base.Population = 20
+	base.MaxTerms = 64
+	base.Seed = 7
+
+	first := base
+	first.Generations = 4
+	first.CheckpointFile = ckpt
+	first.CheckpointInterval = time.Nanosecond
+	e, err := New(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run()
+
+	snap, err := ReadSnapshot(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Genomes) != len(snap.Population) {
+		t.Fatalf("snapshot has %d genomes for %d candidates", len(snap.Genomes), len(snap.Population))
+	}
+
+	straight := base
+	straight.Generations = 8
+	e, err = New(straight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := e.Run()
+
+	resumed := base
+	resumed.Generations = 8
+	resumed.Resume = ckpt
+	e, err = New(resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := e.Run()
+
+	if got.BestCandidate != want.BestCandidate {
+		t.Errorf("resumed best = %s, want %s", got.BestCandidate, want.BestCandidate)
+	}
+}
+
+func TestEngine_Islands(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, bad := range [][]string{{"conservative"}, {"conservative:nope"}, {"nope:hillclimb"}} {
+		cfg.Islands = bad
+		if _, err := New(cfg); err == nil {
+			t.Errorf("Expected error for islands %v", bad)
+		}
+	}
+	cfg.Islands = []string{"conservative:hillclimb", "moderate:tournament"}
+	cfg.Topology = "star"
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for an unknown topology")
+	}
+
+	// Migration copies island 0's best over island 1's worst and back.
+	cfg.Topology = TopologyRing
+	cfg.Migrants = 1
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pop := make([]*series.Candidate, 6)
+	fits := make([]series.Fitness, 6)
+	for i := range pop {
+		pop[i] = &series.Candidate{Numerator: &expr.ConstNode{Val: int64(i)}, Denominator: &expr.VarNode{}}
+		fits[i].Combined = float64(i % 3)
+	}
+	e.migrate(pop, fits, 3)
+	if got := pop[3].String(); got != "Sum_{n=0}^{inf} (2) / (n)" || fits[3].Combined != 2 {
+		t.Errorf("island 1's worst slot holds %s (fitness %v), want island 0's best", got, fits[3].Combined)
+	}
+	if got := pop[0].String(); got != "Sum_{n=0}^{inf} (5) / (n)" {
+		t.Errorf("island 0's worst slot holds %s, want island 1's best", got)
+	}
+}
+
+func TestEngine_IslandsResume(t *testing.T) {
+	dir := t.TempDir()
+	ckpt := filepath.Join(dir, "run.ckpt.json")
+
+	base := DefaultConfig()
+	base.Target = "pi"
+	base.Islands = []string{"conservative:hillclimb", "kitchensink:tournament"}
+	base.MigrateEvery = 3
+	base.Population = 20
+	base.MaxTerms = 64
+	base.Seed = 11
+
+	first := base
+	first.Generations = 4
+	first.CheckpointFile = ckpt
+	first.CheckpointInterval = time.Nanosecond
+	e, err := New(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run()
+
+	snap, err := ReadSnapshot(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Population) != 2*base.Population {
+		t.Fatalf("snapshot holds %d candidates, want %d per island", len(snap.Population), base.Population)
+	}
+
+	straight := base
+	straight.Generations = 8
+	e, err = New(straight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := e.Run()
+
+	resumed := base
+	resumed.Generations = 8
+	resumed.Resume = ckpt
+	e, err = New(resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := e.Run()
+	if got.BestCandidate != want.BestCandidate {
+		t.Errorf("resumed best = %s, want %s", got.BestCandidate, want.BestCandidate)
+	}
+}
+
+// TestEngine_CheckpointOptIn checks that a run writes no snapshot into its
+// output directory unless -checkpoint asks for one.
+func TestEngine_CheckpointOptIn(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Population = 10
+	cfg.Generations = 3
+	cfg.OutDir = dir
+	cfg.CheckpointInterval = time.Nanosecond
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run()
+	if snaps, _ := filepath.Glob(filepath.Join(dir, "*.ckpt.json")); len(snaps) != 0 {
+		t.Errorf("run without -checkpoint wrote %v", snaps)
+	}
+}
+
+func TestReadSnapshot_VersionMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.ckpt.json")
+	if err := WriteSnapshot(path, &Snapshot{Version: snapshotVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSnapshot(path); err == nil {
+		t.Error("Expected error for snapshot version mismatch")
+	}
+}
+
+// TestIdentifier_NearMiss checks that a converged series missing the target
+// is identified and reported in the hall of fame.
+func TestIdentifier_NearMiss(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+	id := newIdentifier(cfg)
+
+	// sum 3(-1)^n/(2n+1) = 3pi/4
+	c, err := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{3 \cdot (-1)^{n}}{2 \cdot n + 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := series.EvaluateCandidate(c, cfg.MaxTerms, cfg.Precision)
+	f := series.ComputeFitness(c, r, constants.Get("e", cfg.Precision).Value, cfg.Weights)
+	rel := id.check(r, f)
+	if rel == nil {
+		t.Fatalf("no relation found (stable %.1f digits, %.1f correct)", r.StableDigits, f.CorrectDigits)
+	}
+	if rel.Expr != "3*pi/4" {
+		t.Errorf("relation = %q, want 3*pi/4", rel.Expr)
+	}
+
+	var st runState
+	st.recordIdentified([]*series.Candidate{c, c}, []*identify.Relation{rel, rel})
+	if len(st.identified) != 1 {
+		t.Fatalf("recorded %d identifications, want 1", len(st.identified))
+	}
+	var buf bytes.Buffer
+	WriteHallOfFame(&buf, []AttemptResult{{Attempt: 1, BestCandidate: "x", Identified: st.identified}})
+	if !strings.Contains(buf.String(), "3*pi/4") {
+		t.Errorf("hall of fame missing identification:\n%s", buf.String())
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	got := ParseTargets(" pi, e ,ln2,")
+	if strings.Join(got, ",") != "pi,e,ln2" {
+		t.Errorf("ParseTargets = %v", got)
+	}
+	if all := ParseTargets("all"); len(all) != len(constants.Names()) {
+		t.Errorf("ParseTargets(all) = %d names, want %d", len(all), len(constants.Names()))
+	}
+	if ParseTargets("") != nil {
+		t.Error("ParseTargets(\"\") should be nil")
+	}
+}
+
+func TestCombineFitness(t *testing.T) {
+	per := []series.Fitness{
+		{Combined: 10, CorrectDigits: 1},
+		{Combined: 30, CorrectDigits: 3},
+		{Combined: 5, CorrectDigits: 0.5},
+	}
+	best := combineFitness(per, SelectBest)
+	if best.Combined != 30 || best.CorrectDigits != 3 {
+		t.Errorf("best = %+v", best)
+	}
+	sum := combineFitness(per, SelectSum)
+	if sum.Combined != 45 || sum.CorrectDigits != 3 {
+		t.Errorf("sum = %+v", sum)
+	}
+}
+
+// TestEngine_MultiTarget checks that a multi-target run keeps a hall of fame
+// for every constant.
+func TestEngine_MultiTarget(t *testing.T) {
+	for _, sel := range []string{SelectBest, SelectSum} {
+		t.Run(sel, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Targets = []string{"pi", "e", "ln2"}
+			cfg.TargetSelect = sel
+			cfg.Population = 30
+			cfg.Generations = 10
+			cfg.MaxTerms = 64
+			cfg.Seed = 42
+
+			e, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := e.Run()
+			if len(report.Targets) != 3 {
+				t.Fatalf("got %d per-target results, want 3", len(report.Targets))
+			}
+			for i, name := range cfg.Targets {
+				if report.Targets[i].Target != name {
+					t.Errorf("targets[%d] = %s, want %s", i, report.Targets[i].Target, name)
+				}
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Targets = []string{"pi", "nope"}
+	if _, err := New(cfg); err == nil {
+		t.Error("expected error for unknown target in -targets")
+	}
+}
+
+// TestWriteHallOfFameLatex_Transform checks that a transform match is printed
+// as the identity found and its error measured against the transform.
+func TestWriteHallOfFameLatex_Transform(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "pi"
+	a := AttemptResult{
+		Attempt:        1,
+		Target:         "pi",
+		Transform:      "pi^2/6",
+		TransformLaTeX: `\frac{\pi^2}{6}`,
+		BestCandidate:  "basel",
+		BestLaTeX:      `\sum_{n=1}^{\infty} \frac{1}{n^{2}}`,
+		BestFitness:    series.Fitness{CorrectDigits: 12, AgreedDigits: 3.2, CertifiedDigits: 3.2, Transform: "pi^2/6"},
+		BestPartialSum: "1.6449340668482264365",
+		TailBound:      "integral",
+		TermsUsed:      4096,
+		EvalMillis:     12.5,
+	}
+	var buf bytes.Buffer
+	WriteHallOfFameLatex(&buf, []AttemptResult{a}, nil, cfg, constants.Get("pi", cfg.Precision).Value)
+	out := buf.String()
+	if !strings.Contains(out, `\frac{\pi^2}{6} = \sum_{n=1}^{\infty} \frac{1}{n^{2}}`) {
+		t.Errorf("missing identity in LaTeX:\n%s", out)
+	}
+	if !strings.Contains(out, "Error: \\verb|") || strings.Contains(out, "e+00") {
+		t.Errorf("error should be measured against pi^2/6:\n%s", out)
+	}
+	if !strings.Contains(out, "3.2 agreeing, 3.2 certified (tail bound: integral)") {
+		t.Errorf("missing raw and certified digits:\n%s", out)
+	}
+	if !strings.Contains(out, "Terms summed: 4096 in 12.5 ms") {
+		t.Errorf("missing terms summed:\n%s", out)
+	}
+}
+
+// TestEngine_ParetoFront checks that the archive drops dominated entries and
+// that an nsga2 run reports a front: sorted by complexity, digits rising.
+func TestEngine_ParetoFront(t *testing.T) {
+	a := paretoArchive{}
+	entry := func(complexity, digits float64) ParetoEntry {
+		return ParetoEntry{Target: "pi", Complexity: complexity, Fitness: series.Fitness{CorrectDigits: digits}}
+	}
+	for _, p := range []ParetoEntry{entry(5, 3), entry(3, 2), entry(8, 10), entry(6, 2.5), entry(4, 3)} {
+		a.add(p)
+	}
+	var got []float64
+	for _, p := range a["pi"] {
+		got = append(got, p.Complexity)
+	}
+	if want := []float64{3, 4, 8}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("front complexities = %v, want %v", got, want)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+	cfg.Strategy = "nsga2"
+	cfg.Population = 50
+	cfg.Generations = 30
+	cfg.MaxTerms = 128
+	cfg.Seed = 1
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+	if len(report.Pareto) == 0 {
+		t.Fatal("Expected a Pareto front")
+	}
+	for i := 1; i < len(report.Pareto); i++ {
+		prev, cur := report.Pareto[i-1], report.Pareto[i]
+		if cur.Complexity <= prev.Complexity || cur.Fitness.CorrectDigits <= prev.Fitness.CorrectDigits {
+			t.Errorf("front entry %d (%.1f, %.1f digits) does not improve on %d (%.1f, %.1f digits)",
+				i, cur.Complexity, cur.Fitness.CorrectDigits, i-1, prev.Complexity, prev.Fitness.CorrectDigits)
+		}
+	}
+	if best := report.Pareto[len(report.Pareto)-1]; best.Fitness.CorrectDigits < report.BestFitness.CorrectDigits {
+		t.Errorf("front tops out at %.1f digits, below the best %.1f", best.Fitness.CorrectDigits, report.BestFitness.CorrectDigits)
+	}
+
+	var buf bytes.Buffer
+	WriteHallOfFameLatex(&buf, report.Attempts, report.Pareto, cfg, constants.Get("e", cfg.Precision).Value)
+	if !strings.Contains(buf.String(), `\section*{Pareto front}`) {
+		t.Error("LaTeX report is missing the Pareto front")
+	}
+}
+
+func TestEngine_Diversity(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Diversity = "crowds"
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected error for an unknown diversity mode")
+	}
+
+	// Candidates 0 and 1 behave identically; 2 is far away; 3 failed.
+	descs := [][]float64{{0, 0}, {0, 0}, {3, 3}, {1, 1}}
+	fitnesses := []series.Fitness{{Combined: 20}, {Combined: 20}, {Combined: 10}, series.WorstFitness()}
+	e := &Engine{cfg: cfg}
+
+	e.cfg.Diversity = DiversitySharing
+	shared := e.shape(fitnesses, descs)
+	if shared[0].Combined != 15 || shared[1].Combined != 15 || shared[2].Combined != 10 {
+		t.Errorf("sharing: got %.1f, %.1f, %.1f, want 15, 15, 10", shared[0].Combined, shared[1].Combined, shared[2].Combined)
+	}
+	if shared[3].Combined != fitnesses[3].Combined || fitnesses[0].Combined != 20 {
+		t.Error("sharing must leave failed candidates and its input alone")
+	}
+
+	e.cfg.Diversity = DiversityNovelty
+	novel := e.shape(fitnesses, descs)
+	if novel[2].Combined-fitnesses[2].Combined <= novel[0].Combined-fitnesses[0].Combined {
+		t.Errorf("novelty bonus of the outlier (%.2f) should exceed the duplicates' (%.2f)",
+			novel[2].Combined-fitnesses[2].Combined, novel[0].Combined-fitnesses[0].Combined)
+	}
+
+	if d := populationDiversity(fitnesses, descs); d <= 0 {
+		t.Errorf("diversity = %g, want positive", d)
+	}
+	if d := populationDiversity(fitnesses[:2], descs[:2]); d != 0 {
+		t.Errorf("diversity of identical behaviours = %g, want 0", d)
+	}
+
+	for _, mode := range []string{DiversitySharing, DiversityNovelty} {
+		cfg := DefaultConfig()
+		cfg.Target = "e"
+		cfg.Population = 30
+		cfg.Generations = 10
+		cfg.MaxTerms = 128
+		cfg.Seed = 42
+		cfg.Verbose = true
+		cfg.Diversity = mode
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := e.Run()
+		if len(report.Generations) == 0 || report.Generations[0].Diversity <= 0 {
+			t.Errorf("%s: expected a positive diversity in the generation reports", mode)
+		}
+	}
+}
+
+// TestTabu_Behavioural checks that tabu and hall-of-fame dedup catch a
+// rewritten formula, not just the exact string.
+func TestTabu_Behavioural(t *testing.T) {
+	orig, _ := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	rewritten, _ := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{n + 1}{(n + 1) \cdot n!}`)
+	other, _ := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{(n + 1)!}`)
+
+	tabu := newTabuList()
+	if !tabu.add(orig) || tabu.add(rewritten) {
+		t.Error("add should report only the first of two equivalent candidates as new")
+	}
+	if !tabu.has(rewritten, rewritten.String()) {
+		t.Errorf("%s should be blocked by %s", rewritten, orig)
+	}
+	if tabu.has(other, other.String()) {
+		t.Errorf("%s should not be blocked", other)
+	}
+
+	st := newRunState()
+	tg := target{name: "e"}
+	var attempts []AttemptResult
+	for _, c := range []*series.Candidate{orig, rewritten, other} {
+		attempts = append(attempts, st.attemptResult(tg, c, series.Fitness{CorrectDigits: 10}, series.EvalResult{}, 0))
+	}
+	if got := dedupAttempts(attempts); len(got) != 2 {
+		t.Errorf("dedupAttempts kept %d entries, want 2", len(got))
+	}
+}
+
+// TestEngine_TargetValue checks that -target-value registers the target at
+// the configured precision and rejects digits too few for it.
+func TestEngine_TargetValue(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "test_my_e"
+	cfg.TargetValue = constants.Get("e", cfg.Precision).Value.Text('g', 160)
+	cfg.Population = 10
+	cfg.Generations = 2
+	cfg.MaxTerms = 64
+	cfg.Seed = 3
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.targets) != 1 || e.targets[0].name != "test_my_e" || e.targets[0].value.Cmp(constants.Get("e", cfg.Precision).Value) != 0 {
+		t.Errorf("targets = %+v", e.targets)
+	}
+
+	cfg.Target, cfg.TargetValue = "test_short", "2.718281828"
+	if _, err := New(cfg); err == nil {
+		t.Error("10 digits should not pass at 512 bits")
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/identify"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// maxIdentifiedPerAttempt caps how many distinct relations one attempt keeps.
+const maxIdentifiedPerAttempt = 20
+
+// Identified is a candidate whose limit PSLQ expressed in basis constants.
+type Identified struct {
+	Candidate string            `json:"candidate"`
+	LaTeX     string            `json:"latex"`
+	Relation  identify.Relation `json:"relation"`
+}
+
+// identifier runs PSLQ on near-miss partial sums. Results are cached by value
+// since the same limit tends to reappear under many tree shapes.
+type identifier struct {
+	minDigits float64
+	digitCap  float64
+	basis     []identify.Constant
+
+	mu    sync.Mutex
+	cache map[string]*identify.Relation // nil entry = tried, nothing found
+}
+
+func newIdentifier(cfg Config) *identifier {
+	if cfg.IdentifyDigits <= 0 {
+		return nil
+	}
+	return &identifier{
+		minDigits: cfg.IdentifyDigits,
+		digitCap:  cfg.digitCap(),
+		basis:     identify.Basis(cfg.Precision),
+		cache:     map[string]*identify.Relation{},
+	}
+}
+
+// check returns a relation for a converged result that is stable to at least
+// minDigits but agrees with the target on noticeably fewer, or nil. Safe for
+// concurrent use.
+func (id *identifier) check(r series.EvalResult, f series.Fitness) *identify.Relation {
+	if id == nil || !r.OK || !r.Converged || r.AcceleratedSum == nil {
+		return nil
+	}
+	// A match at the digit cap is as close to the target as scoring can tell.
+	if r.StableDigits < id.minDigits || f.CorrectDigits >= math.Min(r.StableDigits-1, id.digitCap) {
+		return nil
+	}
+	key := r.AcceleratedSum.Text('g', 30)
+	id.mu.Lock()
+	rel, seen := id.cache[key]
+	id.mu.Unlock()
+	if seen {
+		return rel
+	}
+
+	if found, ok := identify.Identify(r.AcceleratedSum, r.StableDigits, id.basis); ok {
+		rel = &found
+	}
+	id.mu.Lock()
+	id.cache[key] = rel
+	id.mu.Unlock()
+	return rel
+}
+
+// recordIdentified adds newly identified candidates to the current attempt,
+// skipping relations the attempt already holds.
+func (st *runState) recordIdentified(pop []*series.Candidate, relations []*identify.Relation) {
+	for i, rel := range relations {
+		if rel == nil || len(st.identified) >= maxIdentifiedPerAttempt {
+			continue
+		}
+		dup := false
+		for _, id := range st.identified {
+			if id.Relation.Expr == rel.Expr {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		st.identified = append(st.identified, Identified{
+			Candidate: pop[i].String(),
+			LaTeX:     pop[i].LaTeX(),
+			Relation:  *rel,
+		})
+		fmt.Fprintf(os.Stderr, "[gen %d] IDENTIFIED %s = %s (%.1f digits)\n",
+			st.attemptGens, pop[i].String(), rel.Expr, rel.Digits)
+	}
+}
+
+// uniqueIdentified collects identifications across attempts, keeping the
+// first candidate seen for each relation.
+func uniqueIdentified(attempts []AttemptResult) []Identified {
+	seen := map[string]bool{}
+	var out []Identified
+	for _, a := range attempts {
+		for _, id := range a.Identified {
+			if seen[id.Relation.Expr] {
+				continue
+			}
+			seen[id.Relation.Expr] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
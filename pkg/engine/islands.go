@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/strategy"
+)
+
+// Island topologies: where each island's migrants go.
+const (
+	TopologyRing   = "ring"   // island i sends to island i+1
+	TopologyRandom = "random" // each island sends to a random other island
+)
+
+// island is one subpopulation, evolved by its own pool and strategy. A run
+// without Config.Islands is a single island built from Pool and Strategy.
+// Islands occupy consecutive, equal slices of the engine's population, so
+// evaluation, the hall of fame and snapshots see one population.
+type island struct {
+	name     string // pool:strategy
+	pool     pool.Pool
+	strategy strategy.Strategy
+}
+
+// ParseIslands splits an -islands list of pool:strategy pairs.
+func ParseIslands(s string) []string {
+	var specs []string
+	for _, spec := range strings.Split(s, ",") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// newIslands builds the islands a run evolves: one per cfg.Islands entry, or
+// the single cfg.Pool/cfg.Strategy island.
+func newIslands(cfg Config) ([]island, error) {
+	specs := cfg.Islands
+	if len(specs) == 0 {
+		specs = []string{cfg.Pool + ":" + cfg.Strategy}
+	} else {
+		switch cfg.Topology {
+		case "", TopologyRing, TopologyRandom:
+		default:
+			return nil, fmt.Errorf("unknown island topology %q (want %s or %s)", cfg.Topology, TopologyRing, TopologyRandom)
+		}
+	}
+	var out []island
+	for _, spec := range specs {
+		poolName, stratName, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid island %q (want pool:strategy)", spec)
+		}
+		p, err := pool.Get(poolName)
+		if err != nil {
+			return nil, err
+		}
+		s, err := strategy.Get(stratName)
+		if err != nil {
+			return nil, err
+		}
+		if err := configureStrategy(s, stratName, cfg); err != nil {
+			return nil, err
+		}
+		out = append(out, island{name: spec, pool: p, strategy: s})
+	}
+	return out, nil
+}
+
+// searchName describes what evolves the population, for logs and file names:
+// "pool_strategy", or "islands" for island-model runs.
+func (e *Engine) searchName() string {
+	if len(e.cfg.Islands) > 0 {
+		return "islands"
+	}
+	return e.cfg.Pool + "_" + e.cfg.Strategy
+}
+
+// initialize creates a fresh population of cfg.Population candidates per island.
+func (e *Engine) initialize() []*series.Candidate {
+	var pop []*series.Candidate
+	for _, isl := range e.islands {
+		pop = append(pop, isl.strategy.Initialize(isl.pool, e.rng, e.cfg.Population)...)
+	}
+	return pop
+}
+
+// evolve breeds the next generation, each island from its own slice. Every
+// MigrateEvery generations of an attempt, copies of each island's best
+// Migrants candidates first replace the worst of a neighbouring island.
+// descs are the candidates' behavioural descriptors; under a diversity mode
+// each island selects on its fitnesses shaped by them.
+func (e *Engine) evolve(pop []*series.Candidate, fitnesses []series.Fitness, descs [][]float64, gen int) []*series.Candidate {
+	if len(e.islands) == 1 {
+		isl := e.islands[0]
+		if e.shaping() {
+			fitnesses = e.shape(fitnesses, descs)
+		}
+		return isl.strategy.Evolve(pop, fitnesses, isl.pool, e.rng)
+	}
+	size := len(pop) / len(e.islands)
+	pop = append([]*series.Candidate(nil), pop...)
+	fitnesses = append([]series.Fitness(nil), fitnesses...)
+	if e.cfg.MigrateEvery > 0 && gen > 0 && gen%e.cfg.MigrateEvery == 0 {
+		e.migrate(pop, fitnesses, size)
+		if e.shaping() {
+			descs = descriptors(pop)
+		}
+	}
+
+	var next []*series.Candidate
+	for i, isl := range e.islands {
+		lo, hi := i*size, (i+1)*size
+		fit := fitnesses[lo:hi]
+		if e.shaping() {
+			fit = e.shape(fit, descs[lo:hi])
+		}
+		next = append(next, isl.strategy.Evolve(pop[lo:hi], fit, isl.pool, e.rng)...)
+	}
+	return next
+}
+
+// migrate copies each island's best candidates over the worst of its
+// destination in place. Fitnesses move with the migrants so the destination's
+// selection sees them. All emigrants are chosen before any slot is replaced.
+func (e *Engine) migrate(pop []*series.Candidate, fitnesses []series.Fitness, size int) {
+	n := len(e.islands)
+	m := min(e.cfg.Migrants, size/2)
+	if m <= 0 {
+		return
+	}
+	ranked := make([][]int, n) // population indices of each island, best first
+	for i := range ranked {
+		ranked[i] = make([]int, size)
+		for j := range ranked[i] {
+			ranked[i][j] = i*size + j
+		}
+		sort.SliceStable(ranked[i], func(a, b int) bool {
+			return fitnesses[ranked[i][a]].Combined > fitnesses[ranked[i][b]].Combined
+		})
+	}
+
+	type migrant struct {
+		c *series.Candidate
+		f series.Fitness
+	}
+	arrivals := make([][]migrant, n)
+	for i := range e.islands {
+		dest := (i + 1) % n
+		if e.cfg.Topology == TopologyRandom {
+			if dest = e.rng.Intn(n - 1); dest >= i {
+				dest++
+			}
+		}
+		for _, idx := range ranked[i][:m] {
+			arrivals[dest] = append(arrivals[dest], migrant{pop[idx].Clone(), fitnesses[idx]})
+		}
+	}
+
+	var bests []string
+	for i := range e.islands {
+		for k, mg := range arrivals[i] {
+			if k >= size/2 {
+				break // random topologies can send one island more than it should lose
+			}
+			idx := ranked[i][size-1-k]
+			pop[idx], fitnesses[idx] = mg.c, mg.f
+		}
+		bests = append(bests, fmt.Sprintf("%s %.1f", e.islands[i].name, fitnesses[ranked[i][0]].CorrectDigits))
+	}
+	if e.cfg.Verbose {
+		fmt.Fprintf(os.Stderr, "Migration (%d per island): best digits %s\n", m, strings.Join(bests, " | "))
+	}
+}
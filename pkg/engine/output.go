@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/wildfunctions/genetic_series/pkg/constants"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
@@ -19,18 +20,28 @@ type GenerationReport struct {
 	BestCandidate string         `json:"best_candidate"`
 	BestLaTeX     string         `json:"best_latex,omitempty"`
 	AvgFitness    float64        `json:"avg_fitness"`
+	Diversity     float64        `json:"diversity"` // mean behavioural distance between candidates
 	BestPartialSum string        `json:"best_partial_sum,omitempty"`
 }
 
 // AttemptResult summarizes one restart attempt.
 type AttemptResult struct {
 	Attempt        int            `json:"attempt"`
+	Target         string         `json:"target,omitempty"`
+	Transform      string         `json:"transform,omitempty"`       // transform of Target the candidate matched
+	TransformLaTeX string         `json:"transform_latex,omitempty"` // left-hand side of the identity found
 	Generations    int            `json:"generations"`
 	BestFoundAtGen int            `json:"best_found_at_gen"`
 	BestCandidate  string         `json:"best_candidate"`
 	BestLaTeX      string         `json:"best_latex"`
 	BestFitness    series.Fitness `json:"best_fitness"`
 	BestPartialSum string         `json:"best_partial_sum"`
+	Fingerprint    uint64         `json:"fingerprint,omitempty"`  // behavioural fingerprint of the best candidate
+	Acceleration   string         `json:"acceleration,omitempty"` // method behind the accelerated digits
+	TailBound      string         `json:"tail_bound,omitempty"`   // test behind the certified digits
+	TermsUsed      int64          `json:"terms_used,omitempty"`   // terms the best candidate's evaluation summed
+	EvalMillis     float64        `json:"eval_ms,omitempty"`      // time that evaluation took
+	Identified     []Identified   `json:"identified,omitempty"`   // near-misses PSLQ matched to basis constants
 	Timestamp      time.Time      `json:"timestamp"`
 }
 
@@ -43,13 +54,15 @@ type FinalReport struct {
 	BestFitness   series.Fitness     `json:"best_fitness"`
 	BestPartialSum string            `json:"best_partial_sum"`
 	Attempts      []AttemptResult    `json:"attempts,omitempty"`
+	Targets       []TargetResult     `json:"targets,omitempty"` // per-target bests of a multi-target run
+	Pareto        []ParetoEntry      `json:"pareto,omitempty"`  // non-dominated formulas by complexity, per target
 }
 
 // WriteTextReport writes a generation report in human-readable format.
 func WriteTextReport(w io.Writer, r GenerationReport) {
-	fmt.Fprintf(w, "Gen %4d | Best: %.4f (%.1f digits) | Avg: %.4f | %s\n",
+	fmt.Fprintf(w, "Gen %4d | Best: %.4f (%.1f digits) | Avg: %.4f | Div: %.3f | %s\n",
 		r.Generation, r.BestFitness.Combined, r.BestFitness.CorrectDigits,
-		r.AvgFitness, r.BestCandidate)
+		r.AvgFitness, r.Diversity, r.BestCandidate)
 }
 
 // WriteAttemptSummary writes a single attempt result.
@@ -60,6 +73,14 @@ func WriteAttemptSummary(w io.Writer, a AttemptResult) {
 
 const maxHallOfFame = 100
 
+// transformSuffix describes a matched transform after a digit count.
+func transformSuffix(transform string) string {
+	if transform == "" {
+		return ""
+	}
+	return " of " + transform
+}
+
 // sortByDigits returns a copy of attempts sorted by CorrectDigits descending.
 func sortByDigits(attempts []AttemptResult) []AttemptResult {
 	sorted := make([]AttemptResult, len(attempts))
@@ -74,21 +95,29 @@ func sortByDigits(attempts []AttemptResult) []AttemptResult {
 }
 
 // dedupAttempts removes duplicate candidates, keeping only the first (best) entry.
-// Deduplicates on both the candidate string AND the partial sum value, so algebraically
-// equivalent formulas with different tree structures are also caught.
+// Deduplicates on the candidate string, the behavioural fingerprint AND the
+// partial sum value, so algebraically equivalent formulas with different tree
+// structures are also caught.
 // Input must already be sorted by quality descending.
 func dedupAttempts(sorted []AttemptResult) []AttemptResult {
 	seenExpr := map[string]bool{}
+	seenPrint := map[uint64]bool{}
 	seenSum := map[string]bool{}
 	result := make([]AttemptResult, 0, len(sorted))
 	for _, a := range sorted {
 		if seenExpr[a.BestCandidate] {
 			continue
 		}
+		if a.Fingerprint != 0 && seenPrint[a.Fingerprint] {
+			continue
+		}
 		if a.BestPartialSum != "" && seenSum[a.BestPartialSum] {
 			continue
 		}
 		seenExpr[a.BestCandidate] = true
+		if a.Fingerprint != 0 {
+			seenPrint[a.Fingerprint] = true
+		}
 		if a.BestPartialSum != "" {
 			seenSum[a.BestPartialSum] = true
 		}
@@ -97,17 +126,40 @@ func dedupAttempts(sorted []AttemptResult) []AttemptResult {
 	return result
 }
 
-// WriteHallOfFame writes the sorted hall of fame across attempts.
+// WriteHallOfFame writes the sorted hall of fame across attempts. Multi-target
+// runs get a separate hall for each constant.
 func WriteHallOfFame(w io.Writer, attempts []AttemptResult) {
+	order, groups := groupByTarget(attempts)
+	if len(order) > 1 {
+		for _, name := range order {
+			writeHallOfFameEntries(w, "\n--- Hall of Fame: "+name+" ---", groups[name])
+		}
+	} else {
+		writeHallOfFameEntries(w, "\n--- Hall of Fame ---", attempts)
+	}
+
+	if ids := uniqueIdentified(attempts); len(ids) > 0 {
+		fmt.Fprintln(w, "\n--- Identified ---")
+		for _, id := range ids {
+			fmt.Fprintf(w, "  %s (%.1f digits) | %s\n", id.Relation.Expr, id.Relation.Digits, id.Candidate)
+		}
+	}
+}
+
+func writeHallOfFameEntries(w io.Writer, header string, attempts []AttemptResult) {
 	sorted := sortByDigits(attempts)
 	sorted = dedupAttempts(sorted)
 	if len(sorted) > maxHallOfFame {
 		sorted = sorted[:maxHallOfFame]
 	}
-	fmt.Fprintln(w, "\n--- Hall of Fame ---")
+	fmt.Fprintln(w, header)
 	for i, a := range sorted {
-		fmt.Fprintf(w, "  #%d: [attempt %d, gen %d] %5.1f digits | %s\n",
-			i+1, a.Attempt, a.BestFoundAtGen, a.BestFitness.CorrectDigits, a.BestCandidate)
+		accel := ""
+		if a.Acceleration != "" && a.Acceleration != "none" {
+			accel = fmt.Sprintf(" (raw %.1f, %s)", a.BestFitness.RawDigits, a.Acceleration)
+		}
+		fmt.Fprintf(w, "  #%d: [attempt %d, gen %d] %5.1f digits%s%s | %s\n",
+			i+1, a.Attempt, a.BestFoundAtGen, a.BestFitness.CorrectDigits, transformSuffix(a.Transform), accel, a.BestCandidate)
 	}
 }
 
@@ -116,8 +168,13 @@ func WriteTextFinal(w io.Writer, r FinalReport) {
 	if len(r.Attempts) > 0 {
 		WriteHallOfFame(w, r.Attempts)
 	}
+	WriteParetoFront(w, r.Pareto)
 	fmt.Fprintln(w, "\n========== FINAL RESULT ==========")
-	fmt.Fprintf(w, "Target:    %s\n", r.Config.Target)
+	if len(r.Targets) > 0 {
+		fmt.Fprintf(w, "Targets:   %s (select %s)\n", strings.Join(r.Config.Targets, ","), r.Config.TargetSelect)
+	} else {
+		fmt.Fprintf(w, "Target:    %s\n", r.Config.Target)
+	}
 	fmt.Fprintf(w, "Strategy:  %s\n", r.Config.Strategy)
 	fmt.Fprintf(w, "Pool:      %s\n", r.Config.Pool)
 	fmt.Fprintf(w, "Best:      %s\n", r.BestCandidate)
@@ -125,6 +182,9 @@ func WriteTextFinal(w io.Writer, r FinalReport) {
 	fmt.Fprintf(w, "Fitness:   %.4f\n", r.BestFitness.Combined)
 	fmt.Fprintf(w, "Digits:    %.1f\n", r.BestFitness.CorrectDigits)
 	fmt.Fprintf(w, "Partial:   %s\n", r.BestPartialSum)
+	for _, t := range r.Targets {
+		fmt.Fprintf(w, "  %-12s %5.1f digits | %s\n", t.Target+":", t.BestFitness.CorrectDigits, t.BestCandidate)
+	}
 	fmt.Fprintln(w, "==================================")
 }
 
@@ -140,8 +200,9 @@ func latexEscape(s string) string {
 	return strings.ReplaceAll(s, "_", `\_`)
 }
 
-// WriteHallOfFameLatex writes a compilable LaTeX document of the hall of fame.
-func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, targetValue *big.Float) {
+// WriteHallOfFameLatex writes a compilable LaTeX document of the hall of fame,
+// followed by the target's Pareto front.
+func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, front []ParetoEntry, cfg Config, targetValue *big.Float) {
 	sorted := sortByDigits(attempts)
 	sorted = dedupAttempts(sorted)
 	if len(sorted) > maxHallOfFame {
@@ -175,13 +236,32 @@ func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, tar
 			i+1, a.BestFitness.CorrectDigits, a.Attempt, a.BestFoundAtGen,
 			a.Timestamp.Format("2006-01-02 15:04:05 UTC"))
 		fmt.Fprintln(w, `\[`)
-		fmt.Fprintf(w, "  %s\n", a.BestLaTeX)
+		if a.TransformLaTeX != "" {
+			fmt.Fprintf(w, "  %s = %s\n", a.TransformLaTeX, a.BestLaTeX)
+		} else {
+			fmt.Fprintf(w, "  %s\n", a.BestLaTeX)
+		}
 		fmt.Fprintln(w, `\]`)
+		if a.Acceleration != "" && a.Acceleration != "none" {
+			fmt.Fprintf(w, "\\noindent Digits: %.1f raw, %.1f accelerated (%s)\\\\\n",
+				a.BestFitness.RawDigits, a.BestFitness.AcceleratedDigits, a.Acceleration)
+		}
+		if a.TailBound != "" {
+			fmt.Fprintf(w, "\\noindent Raw digits: %.1f agreeing, %.1f certified (tail bound: %s)\\\\\n",
+				a.BestFitness.AgreedDigits, a.BestFitness.CertifiedDigits, a.TailBound)
+		}
+		if a.TermsUsed > 0 {
+			fmt.Fprintf(w, "\\noindent Terms summed: %d in %.1f ms\\\\\n", a.TermsUsed, a.EvalMillis)
+		}
 		if a.BestPartialSum != "" {
 			// Compute error = |partial_sum - target|
 			partialSum, _, err := big.ParseFloat(a.BestPartialSum, 10, targetValue.Prec(), big.ToNearestEven)
+			matched := targetValue
+			if t, ok := constants.LookupTransform(cfg.Target, a.Transform, targetValue.Prec()); ok && a.Transform != "" {
+				matched = t.Value
+			}
 			if err == nil {
-				diff := new(big.Float).Sub(partialSum, targetValue)
+				diff := new(big.Float).Sub(partialSum, matched)
 				diff.Abs(diff)
 				fmt.Fprintf(w, "\\noindent Partial sum: \\verb|%s|\\\\\n", a.BestPartialSum)
 				fmt.Fprintf(w, "Error: \\verb|%s|\n\n", diff.Text('e', 10))
@@ -191,5 +271,32 @@ func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, tar
 		}
 	}
 
+	if ids := uniqueIdentified(attempts); len(ids) > 0 {
+		fmt.Fprintln(w, `\section*{Identified limits}`)
+		fmt.Fprintln(w, `\noindent Candidates that missed the target but whose limits PSLQ matched to known constants.`)
+		for _, id := range ids {
+			fmt.Fprintln(w, `\[`)
+			fmt.Fprintf(w, "  %s = %s\n", id.LaTeX, id.Relation.LaTeX)
+			fmt.Fprintln(w, `\]`)
+			fmt.Fprintf(w, "\\noindent Checked to %.1f digits.\n\n", id.Relation.Digits)
+		}
+	}
+
+	if len(front) > 0 {
+		fmt.Fprintln(w, `\section*{Pareto front}`)
+		fmt.Fprintln(w, `\noindent The most accurate formula found at each complexity level: each matches more digits than anything simpler.`)
+		for _, p := range front {
+			fmt.Fprintf(w, "\\subsection*{Complexity %.1f --- %.1f digits (attempt %d, gen %d)}\n",
+				p.Complexity, p.Fitness.CorrectDigits, p.Attempt, p.Generation)
+			fmt.Fprintln(w, `\[`)
+			if p.TransformLaTeX != "" {
+				fmt.Fprintf(w, "  %s = %s\n", p.TransformLaTeX, p.LaTeX)
+			} else {
+				fmt.Fprintf(w, "  %s\n", p.LaTeX)
+			}
+			fmt.Fprintln(w, `\]`)
+		}
+	}
+
 	fmt.Fprintln(w, `\end{document}`)
 }
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// paretoMinDigits keeps near-misses of no digits out of the front, where the
+// simplest random formula would otherwise always sit.
+const paretoMinDigits = 1.0
+
+// ParetoEntry is a formula on the front of correct digits against
+// complexity: nothing simpler found matches as many digits.
+type ParetoEntry struct {
+	Target         string         `json:"target"`
+	Candidate      string         `json:"candidate"`
+	LaTeX          string         `json:"latex"`
+	TransformLaTeX string         `json:"transform_latex,omitempty"`
+	Complexity     float64        `json:"complexity"`
+	Fitness        series.Fitness `json:"fitness"`
+	Attempt        int            `json:"attempt"`
+	Generation     int            `json:"generation"`
+}
+
+// dominates reports whether p is at least as simple and as accurate as q.
+// Ties go to the entry already in the archive, so equal entries don't churn.
+func (p ParetoEntry) dominates(q ParetoEntry) bool {
+	return p.Complexity <= q.Complexity && p.Fitness.CorrectDigits >= q.Fitness.CorrectDigits
+}
+
+// paretoArchive keeps, for each target, the non-dominated formulas seen over
+// the whole run, ordered by complexity. Along the front digits rise with
+// complexity, so it lists the best formula at each complexity level.
+type paretoArchive map[string][]ParetoEntry
+
+// add inserts e unless an archived entry dominates it, dropping the entries
+// it dominates. It reports whether e was added.
+func (a paretoArchive) add(e ParetoEntry) bool {
+	if !a.admits(e) {
+		return false
+	}
+	kept := a[e.Target][:0]
+	for _, p := range a[e.Target] {
+		if !e.dominates(p) {
+			kept = append(kept, p)
+		}
+	}
+	i := sort.Search(len(kept), func(i int) bool { return kept[i].Complexity > e.Complexity })
+	kept = append(kept, ParetoEntry{})
+	copy(kept[i+1:], kept[i:])
+	kept[i] = e
+	a[e.Target] = kept
+	return true
+}
+
+// admits reports whether add would accept e, without building its strings.
+func (a paretoArchive) admits(e ParetoEntry) bool {
+	for _, p := range a[e.Target] {
+		if p.dominates(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// entries returns every target's front, in target order.
+func (a paretoArchive) entries(targets []target) []ParetoEntry {
+	var out []ParetoEntry
+	for _, tg := range targets {
+		out = append(out, a[tg.name]...)
+	}
+	return out
+}
+
+// trackPareto offers one generation to the archive. In multi-target runs
+// each candidate competes on every target's front with its fitness for that
+// target.
+func (st *runState) trackPareto(targets []target, pop []*series.Candidate, fitnesses []series.Fitness, perTarget [][]series.Fitness) {
+	for i, c := range pop {
+		for t, tg := range targets {
+			f := fitnesses[i]
+			if perTarget != nil {
+				if perTarget[i] == nil {
+					continue
+				}
+				f = perTarget[i][t]
+			}
+			if !f.Valid() || f.CorrectDigits < paretoMinDigits {
+				continue
+			}
+			e := ParetoEntry{
+				Target:     tg.name,
+				Complexity: c.Complexity(),
+				Fitness:    f,
+				Attempt:    st.attempt,
+				Generation: st.attemptGens,
+			}
+			if !st.pareto.admits(e) {
+				continue
+			}
+			e.Candidate, e.LaTeX = c.String(), c.LaTeX()
+			_, e.TransformLaTeX = tg.matched(f)
+			st.pareto.add(e)
+		}
+	}
+}
+
+// WriteParetoFront writes the Pareto front archive.
+func WriteParetoFront(w io.Writer, front []ParetoEntry) {
+	if len(front) == 0 {
+		return
+	}
+	target := ""
+	for _, p := range front {
+		if p.Target != target {
+			target = p.Target
+			fmt.Fprintf(w, "\n--- Pareto front: %s ---\n", target)
+		}
+		fmt.Fprintf(w, "  complexity %5.1f | %5.1f digits%s | %s\n",
+			p.Complexity, p.Fitness.CorrectDigits, transformSuffix(p.Fitness.Transform), p.Candidate)
+	}
+}
@@ -0,0 +1,27 @@
+package engine
+
+import (
+	"math/rand"
+	randv2 "math/rand/v2"
+)
+
+// pcgSource adapts math/rand/v2's PCG generator to the math/rand Source64
+// interface. Unlike rand.NewSource, its state can be marshaled, which lets a
+// checkpoint capture the exact RNG position.
+type pcgSource struct {
+	pcg *randv2.PCG
+}
+
+func newPCGSource(seed int64) *pcgSource {
+	return &pcgSource{pcg: randv2.NewPCG(uint64(seed), 0)}
+}
+
+func (s *pcgSource) Int63() int64    { return int64(s.pcg.Uint64() >> 1) }
+func (s *pcgSource) Uint64() uint64  { return s.pcg.Uint64() }
+func (s *pcgSource) Seed(seed int64) { s.pcg.Seed(uint64(seed), 0) }
+
+// newRNG returns a *rand.Rand backed by a marshalable PCG source.
+func newRNG(seed int64) (*rand.Rand, *pcgSource) {
+	src := newPCGSource(seed)
+	return rand.New(src), src
+}
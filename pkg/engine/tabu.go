@@ -0,0 +1,43 @@
+package engine
+
+import "github.com/wildfunctions/genetic_series/pkg/series"
+
+// fingerprintTerms is how many leading terms a behavioural fingerprint hashes.
+const fingerprintTerms = 64
+
+// tabuList holds the attempt bests later restarts must not rediscover. It
+// matches exact strings and behavioural fingerprints (see series.Fingerprint),
+// so 1/n! rewritten as n/(n*n!) is blocked too.
+type tabuList struct {
+	strs   map[string]bool
+	prints map[uint64]bool
+}
+
+func newTabuList() *tabuList {
+	return &tabuList{strs: map[string]bool{}, prints: map[uint64]bool{}}
+}
+
+// add blocks c and reports whether it was not already blocked.
+func (t *tabuList) add(c *series.Candidate) bool {
+	s := c.String()
+	fp, ok := series.Fingerprint(c, fingerprintTerms)
+	added := !t.strs[s] && !(ok && t.prints[fp])
+	t.strs[s] = true
+	if ok {
+		t.prints[fp] = true
+	}
+	return added
+}
+
+// has reports whether c, whose String() is str, is blocked. The fingerprint
+// is only computed once a fingerprint has been blocked.
+func (t *tabuList) has(c *series.Candidate, str string) bool {
+	if t.strs[str] {
+		return true
+	}
+	if len(t.prints) == 0 {
+		return false
+	}
+	fp, ok := series.Fingerprint(c, fingerprintTerms)
+	return ok && t.prints[fp]
+}
@@ -0,0 +1,277 @@
+package engine
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Target selection modes for multi-target runs.
+const (
+	SelectBest = "best" // a candidate scores as its best single-target match
+	SelectSum  = "sum"  // a candidate scores as the sum over all targets
+)
+
+// target is one constant candidates are scored against.
+type target struct {
+	name       string
+	value      *big.Float
+	f64        float64
+	transforms []constants.Transform // nil unless Config.Transforms
+}
+
+// ParseTargets splits a -targets list. "all" expands to every registered
+// constant in name order.
+func ParseTargets(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if s == "all" {
+		names := constants.Names()
+		sort.Strings(names)
+		return names
+	}
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// registerConstants registers the user-defined constants of cfg: those in
+// ConstantsFile, and TargetValue as Target.
+func registerConstants(cfg Config) error {
+	if cfg.ConstantsFile != "" {
+		if _, err := constants.LoadFile(cfg.ConstantsFile, cfg.Precision); err != nil {
+			return fmt.Errorf("loading constants: %w", err)
+		}
+	}
+	if cfg.TargetValue != "" {
+		if err := constants.Register(cfg.Target, "", cfg.TargetValue, cfg.Precision); err != nil {
+			return fmt.Errorf("target value: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveTargets looks up the constants a run scores against: cfg.Targets
+// when set, otherwise the single cfg.Target.
+func resolveTargets(cfg Config) ([]target, error) {
+	names := cfg.Targets
+	if len(names) == 0 {
+		names = []string{cfg.Target}
+	}
+	seen := map[string]bool{}
+	var out []target
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		c := constants.Get(name, cfg.Precision)
+		if c == nil {
+			return nil, fmt.Errorf("unknown target constant: %s (available: %v)", name, constants.Names())
+		}
+		tg := target{name: name, value: c.Value, f64: c.Float64Value}
+		if cfg.Transforms {
+			tg.transforms = constants.Transforms(name, cfg.Precision)
+		}
+		out = append(out, tg)
+	}
+	switch cfg.TargetSelect {
+	case "", SelectBest, SelectSum:
+	default:
+		return nil, fmt.Errorf("unknown target selection %q (want %s or %s)", cfg.TargetSelect, SelectBest, SelectSum)
+	}
+	return out, nil
+}
+
+// multiTarget reports whether candidates are scored against several targets.
+func (e *Engine) multiTarget() bool { return len(e.targets) > 1 }
+
+// runName names the run's output files: the target, or "multi".
+func (e *Engine) runName() string {
+	if e.multiTarget() {
+		return "multi"
+	}
+	return e.targets[0].name
+}
+
+// score computes a candidate's fitness against every target and the combined
+// fitness selection uses. perTarget is nil in single-target runs.
+func (e *Engine) score(c *series.Candidate, r series.EvalResult) (series.Fitness, []series.Fitness) {
+	if !e.multiTarget() {
+		return e.targets[0].fitness(c, r, e.cfg.Weights), nil
+	}
+	per := make([]series.Fitness, len(e.targets))
+	for t, tg := range e.targets {
+		per[t] = tg.fitness(c, r, e.cfg.Weights)
+	}
+	return combineFitness(per, e.cfg.TargetSelect), per
+}
+
+// scoreF64 is score for the float64 fast path.
+func (e *Engine) scoreF64(c *series.Candidate, r series.EvalResultF64) (series.Fitness, []series.Fitness) {
+	if !e.multiTarget() {
+		return e.targets[0].fitnessF64(c, r, e.cfg.Weights), nil
+	}
+	per := make([]series.Fitness, len(e.targets))
+	for t, tg := range e.targets {
+		per[t] = tg.fitnessF64(c, r, e.cfg.Weights)
+	}
+	return combineFitness(per, e.cfg.TargetSelect), per
+}
+
+// fitness scores a candidate against the target, or its best transform.
+func (tg target) fitness(c *series.Candidate, r series.EvalResult, w series.FitnessWeights) series.Fitness {
+	if tg.transforms != nil {
+		return series.ComputeFitnessTransforms(c, r, tg.transforms, w)
+	}
+	return series.ComputeFitness(c, r, tg.value, w)
+}
+
+func (tg target) fitnessF64(c *series.Candidate, r series.EvalResultF64, w series.FitnessWeights) series.Fitness {
+	if tg.transforms != nil {
+		return series.ComputeFitnessTransformsF64(c, r, tg.transforms, w)
+	}
+	return series.ComputeFitnessF64(c, r, tg.f64, w)
+}
+
+// matched returns the value and LaTeX a fitness was scored against: the
+// transform it names, or the target itself.
+func (tg target) matched(f series.Fitness) (*big.Float, string) {
+	if f.Transform != "" {
+		for _, t := range tg.transforms {
+			if t.Name == f.Transform {
+				return t.Value, t.LaTeX
+			}
+		}
+	}
+	return tg.value, ""
+}
+
+// combineFitness folds per-target fitness into one score. The digit fields
+// always describe the best-matching target; only Combined depends on mode.
+func combineFitness(per []series.Fitness, mode string) series.Fitness {
+	best := 0
+	for t := range per {
+		if per[t].Combined > per[best].Combined {
+			best = t
+		}
+	}
+	f := per[best]
+	if mode == SelectSum {
+		f.Combined = 0
+		for _, p := range per {
+			f.Combined += p.Combined
+		}
+	}
+	return f
+}
+
+// targetBest is the best candidate for one target within an attempt.
+type targetBest struct {
+	candidate  *series.Candidate
+	fitness    series.Fitness
+	result     series.EvalResult
+	foundAtGen int
+}
+
+// trackTargets updates each target's attempt best from one generation.
+func (st *runState) trackTargets(targets []target, pop []*series.Candidate, perTarget [][]series.Fitness, results []series.EvalResult) {
+	if st.targetBests == nil {
+		st.targetBests = map[string]*targetBest{}
+	}
+	for t, tg := range targets {
+		bestIdx := -1
+		for i := range pop {
+			if perTarget[i] == nil {
+				continue
+			}
+			if bestIdx < 0 || perTarget[i][t].Combined > perTarget[bestIdx][t].Combined {
+				bestIdx = i
+			}
+		}
+		if bestIdx < 0 {
+			continue
+		}
+		f := perTarget[bestIdx][t]
+		cur := st.targetBests[tg.name]
+		if cur != nil && f.Combined <= cur.fitness.Combined {
+			continue
+		}
+		st.targetBests[tg.name] = &targetBest{
+			candidate:  pop[bestIdx].Clone(),
+			fitness:    f,
+			result:     results[bestIdx],
+			foundAtGen: st.attemptGens,
+		}
+	}
+}
+
+// allTargetsCapped reports whether the hall of fame holds a match at digitCap
+// for every target.
+func allTargetsCapped(targets []target, attempts []AttemptResult, digitCap float64) bool {
+	capped := map[string]bool{}
+	for _, a := range attempts {
+		if a.BestFitness.CorrectDigits >= digitCap {
+			capped[a.Target] = true
+		}
+	}
+	for _, tg := range targets {
+		if !capped[tg.name] {
+			return false
+		}
+	}
+	return true
+}
+
+// TargetResult is the best candidate found for one target of a multi-target run.
+type TargetResult struct {
+	Target         string         `json:"target"`
+	BestCandidate  string         `json:"best_candidate"`
+	BestLaTeX      string         `json:"best_latex"`
+	BestFitness    series.Fitness `json:"best_fitness"`
+	BestPartialSum string         `json:"best_partial_sum"`
+}
+
+// groupByTarget splits a hall of fame into per-target halls, in order of
+// first appearance.
+func groupByTarget(attempts []AttemptResult) ([]string, map[string][]AttemptResult) {
+	var order []string
+	groups := map[string][]AttemptResult{}
+	for _, a := range attempts {
+		if _, ok := groups[a.Target]; !ok {
+			order = append(order, a.Target)
+		}
+		groups[a.Target] = append(groups[a.Target], a)
+	}
+	return order, groups
+}
+
+// targetResults picks the top hall-of-fame entry for each target.
+func targetResults(targets []target, attempts []AttemptResult) []TargetResult {
+	_, groups := groupByTarget(attempts)
+	var out []TargetResult
+	for _, tg := range targets {
+		if len(groups[tg.name]) == 0 {
+			continue
+		}
+		best := sortByDigits(groups[tg.name])[0]
+		out = append(out, TargetResult{
+			Target:         tg.name,
+			BestCandidate:  best.BestCandidate,
+			BestLaTeX:      best.BestLaTeX,
+			BestFitness:    best.BestFitness,
+			BestPartialSum: best.BestPartialSum,
+		})
+	}
+	return out
+}
@@ -0,0 +1,493 @@
+package expr
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Canonical form. A tree is read as a term c * P(n)/Q(n) * a_1^e_1 * ... with
+// c rational, P and Q coprime expanded polynomials with integer coefficients
+// and positive leading ones, and atoms a_i: subtrees that are not rational in
+// n (n!, 2^n, sin(n), ...), with canonical children, to integer powers. Sums
+// of terms with the same atoms are put over a common denominator, so like
+// terms collect and factors shared with the denominator cancel; other sums
+// become atoms themselves, with ordered operands.
+//
+// Rational expressions that are equal thus print the same: (n+1)^2 and
+// n^2+2n+1 both become 1 + 2*n + n^2, and n*(n+1)/(n^2+n) becomes 1.
+// Polynomials are expanded up to maxCanonDegree; beyond it, or when a
+// coefficient leaves int64, there is no canonical form.
+
+const (
+	maxCanonDegree = 12 // largest polynomial degree expanded
+	maxCanonPow    = 12 // largest integer exponent multiplied out
+)
+
+// Canonical returns node in canonical form. ok is false if node has no
+// canonical form within the caps, or divides by zero; node itself is then
+// returned.
+func Canonical(node ExprNode) (ExprNode, bool) {
+	t, ok := canonTerm(node, 0)
+	if !ok {
+		return node, false
+	}
+	c, ok := t.tree()
+	if !ok {
+		return node, false
+	}
+	return c, true
+}
+
+// CanonicalString returns the string of node's canonical form, or of node
+// itself if it has none.
+func CanonicalString(node ExprNode) string {
+	c, _ := Canonical(node)
+	return c.String()
+}
+
+// CancelRatio cancels the factors num and den share and returns the
+// canonical numerator and denominator of num/den, the sign going to the
+// numerator. ok is false if num/den has no canonical form or is zero.
+func CancelRatio(num, den ExprNode) (ExprNode, ExprNode, bool) {
+	t, ok := canonTerm(&BinaryNode{Op: OpDiv, Left: num, Right: den}, 0)
+	if !ok || t.coef.Sign() == 0 {
+		return num, den, false
+	}
+	n, d, ok := t.parts()
+	if !ok {
+		return num, den, false
+	}
+	return n, d, true
+}
+
+// ratPoly is a polynomial in n with rational coefficients, lowest degree
+// first and without trailing zeros. The zero polynomial is empty.
+type ratPoly []*big.Rat
+
+var (
+	polyOne = ratPoly{big.NewRat(1, 1)}
+	polyN   = ratPoly{new(big.Rat), big.NewRat(1, 1)}
+)
+
+func (p ratPoly) deg() int { return len(p) - 1 }
+
+func (p ratPoly) trim() ratPoly {
+	for len(p) > 0 && p[len(p)-1].Sign() == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+func polyConst(r *big.Rat) ratPoly {
+	return ratPoly{new(big.Rat).Set(r)}.trim()
+}
+
+func polyAdd(a, b ratPoly, sign int) ratPoly {
+	out := make(ratPoly, max(len(a), len(b)))
+	for i := range out {
+		out[i] = new(big.Rat)
+		if i < len(a) {
+			out[i].Set(a[i])
+		}
+		if i < len(b) {
+			if sign < 0 {
+				out[i].Sub(out[i], b[i])
+			} else {
+				out[i].Add(out[i], b[i])
+			}
+		}
+	}
+	return out.trim()
+}
+
+func polyMul(a, b ratPoly) ratPoly {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	out := make(ratPoly, len(a)+len(b)-1)
+	for i := range out {
+		out[i] = new(big.Rat)
+	}
+	tmp := new(big.Rat)
+	for i, x := range a {
+		for j, y := range b {
+			out[i+j].Add(out[i+j], tmp.Mul(x, y))
+		}
+	}
+	return out.trim()
+}
+
+// polyDivMod divides a by b, returning the quotient and remainder.
+func polyDivMod(a, b ratPoly) (q, r ratPoly) {
+	r = make(ratPoly, len(a))
+	for i := range a {
+		r[i] = new(big.Rat).Set(a[i])
+	}
+	if len(a) < len(b) {
+		return nil, r
+	}
+	q = make(ratPoly, len(a)-len(b)+1)
+	lead, tmp := b[len(b)-1], new(big.Rat)
+	for i := len(q) - 1; i >= 0; i-- {
+		q[i] = new(big.Rat).Quo(r[i+len(b)-1], lead)
+		for j, c := range b {
+			r[i+j].Sub(r[i+j], tmp.Mul(q[i], c))
+		}
+	}
+	return q.trim(), r.trim()
+}
+
+// polyGCD returns a greatest common divisor of a and b, up to a constant.
+func polyGCD(a, b ratPoly) ratPoly {
+	for len(b) > 0 {
+		_, r := polyDivMod(a, b)
+		a, b = b, r
+	}
+	return a
+}
+
+// primitive splits p into c * P with P's coefficients coprime integers and
+// its leading coefficient positive.
+func (p ratPoly) primitive() (*big.Rat, ratPoly) {
+	num, den := new(big.Int), big.NewInt(1)
+	for _, c := range p {
+		num.GCD(nil, nil, num, c.Num())
+		den.Div(new(big.Int).Mul(den, c.Denom()), new(big.Int).GCD(nil, nil, den, c.Denom()))
+	}
+	content := new(big.Rat).SetFrac(num, den)
+	if p[len(p)-1].Sign() < 0 {
+		content.Neg(content)
+	}
+	out := make(ratPoly, len(p))
+	for i, c := range p {
+		out[i] = new(big.Rat).Quo(c, content)
+	}
+	return content, out
+}
+
+// tree prints an integer polynomial: positive terms by rising degree joined
+// with +, then negative terms subtracted, as in 1 + n^2 - 3*n.
+func (p ratPoly) tree() (ExprNode, bool) {
+	var pos, neg []ExprNode
+	for k, c := range p {
+		if c.Sign() == 0 {
+			continue
+		}
+		if !c.IsInt() || !new(big.Int).Abs(c.Num()).IsInt64() {
+			return nil, false
+		}
+		abs := new(big.Int).Abs(c.Num()).Int64()
+		var mono ExprNode
+		switch k {
+		case 0:
+			mono = &ConstNode{Val: abs}
+		case 1:
+			mono = &VarNode{}
+		default:
+			mono = &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: int64(k)}}
+		}
+		if k > 0 && abs != 1 {
+			mono = &BinaryNode{Op: OpMul, Left: &ConstNode{Val: abs}, Right: mono}
+		}
+		if c.Sign() > 0 {
+			pos = append(pos, mono)
+		} else {
+			neg = append(neg, mono)
+		}
+	}
+	var out ExprNode
+	switch {
+	case len(pos) > 0:
+		out = pos[0]
+		for _, m := range pos[1:] {
+			out = &BinaryNode{Op: OpAdd, Left: out, Right: m}
+		}
+	case len(neg) == 0:
+		return &ConstNode{Val: 0}, true
+	default:
+		if c, ok := neg[0].(*ConstNode); ok {
+			out = &ConstNode{Val: -c.Val}
+		} else {
+			out = &UnaryNode{Op: OpNeg, Child: neg[0]}
+		}
+		neg = neg[1:]
+	}
+	for _, m := range neg {
+		out = &BinaryNode{Op: OpSub, Left: out, Right: m}
+	}
+	return out, true
+}
+
+// atom is a non-rational subtree raised to a nonzero integer power.
+type atom struct {
+	node ExprNode
+	exp  int
+}
+
+// term is coef * num/den * product of atoms, keyed by their strings.
+type term struct {
+	coef     *big.Rat
+	num, den ratPoly
+	atoms    map[string]atom
+}
+
+func constTerm(r *big.Rat) term {
+	return term{coef: r, num: polyOne, den: polyOne, atoms: map[string]atom{}}
+}
+
+func atomTerm(node ExprNode) term {
+	t := constTerm(big.NewRat(1, 1))
+	t.atoms[node.String()] = atom{node: node, exp: 1}
+	return t
+}
+
+// newTerm normalizes coef * num/den: it cancels the common factor of num and
+// den and moves their contents into the coefficient.
+func newTerm(coef *big.Rat, num, den ratPoly, atoms map[string]atom) (term, bool) {
+	if len(den) == 0 {
+		return term{}, false
+	}
+	if coef.Sign() == 0 || len(num) == 0 {
+		return constTerm(new(big.Rat)), true
+	}
+	if g := polyGCD(num, den); g.deg() >= 1 {
+		num, _ = polyDivMod(num, g)
+		den, _ = polyDivMod(den, g)
+	}
+	if num.deg() > maxCanonDegree || den.deg() > maxCanonDegree {
+		return term{}, false
+	}
+	cn, num := num.primitive()
+	cd, den := den.primitive()
+	coef = new(big.Rat).Mul(coef, cn)
+	coef.Quo(coef, cd)
+	return term{coef: coef, num: num, den: den, atoms: atoms}, true
+}
+
+// mulTerms returns a * b^sign, sign being 1 or -1.
+func mulTerms(a, b term, sign int) (term, bool) {
+	coef, num, den := new(big.Rat), polyMul(a.num, b.num), polyMul(a.den, b.den)
+	if sign < 0 {
+		if b.coef.Sign() == 0 {
+			return term{}, false
+		}
+		coef.Quo(a.coef, b.coef)
+		num, den = polyMul(a.num, b.den), polyMul(a.den, b.num)
+	} else {
+		coef.Mul(a.coef, b.coef)
+	}
+	atoms := make(map[string]atom, len(a.atoms)+len(b.atoms))
+	for k, f := range a.atoms {
+		atoms[k] = f
+	}
+	for k, f := range b.atoms {
+		f.exp = atoms[k].exp + sign*f.exp
+		if f.exp == 0 {
+			delete(atoms, k)
+		} else {
+			atoms[k] = f
+		}
+	}
+	return newTerm(coef, num, den, atoms)
+}
+
+func (t term) pow(k int) (term, bool) {
+	out, ok := constTerm(big.NewRat(1, 1)), true
+	sign := 1
+	if k < 0 {
+		k, sign = -k, -1
+	}
+	for ; k > 0 && ok; k-- {
+		out, ok = mulTerms(out, t, sign)
+	}
+	return out, ok
+}
+
+// sameAtoms reports whether a and b have the same atoms to the same powers.
+func sameAtoms(a, b term) bool {
+	if len(a.atoms) != len(b.atoms) {
+		return false
+	}
+	for k, f := range a.atoms {
+		if b.atoms[k].exp != f.exp {
+			return false
+		}
+	}
+	return true
+}
+
+// addTerms returns a + sign*b. Terms with the same atoms are added over a
+// common denominator; any other sum becomes an atom, its operands ordered
+// by string when they commute.
+func addTerms(a, b term, sign int) (term, bool) {
+	switch {
+	case b.coef.Sign() == 0:
+		return a, true
+	case a.coef.Sign() == 0:
+		return mulTerms(constTerm(big.NewRat(int64(sign), 1)), b, 1)
+	case sameAtoms(a, b):
+		left := polyMul(polyConst(a.coef), polyMul(a.num, b.den))
+		right := polyMul(polyConst(b.coef), polyMul(b.num, a.den))
+		return newTerm(big.NewRat(1, 1), polyAdd(left, right, sign), polyMul(a.den, b.den), a.atoms)
+	}
+	left, ok := a.tree()
+	if !ok {
+		return term{}, false
+	}
+	right, ok := b.tree()
+	if !ok {
+		return term{}, false
+	}
+	op := OpSub
+	if sign > 0 {
+		op = OpAdd
+		if left.String() > right.String() {
+			left, right = right, left
+		}
+	}
+	return atomTerm(&BinaryNode{Op: op, Left: left, Right: right}), true
+}
+
+func canonTerm(node ExprNode, depth int) (term, bool) {
+	if depth > maxRecurseDepth {
+		return term{}, false
+	}
+	switch n := node.(type) {
+	case *ConstNode:
+		return constTerm(big.NewRat(n.Val, 1)), true
+	case *RatNode:
+		return constTerm(big.NewRat(n.Num, n.Den)), true
+	case *VarNode:
+		return newTerm(big.NewRat(1, 1), polyN, polyOne, map[string]atom{})
+	case *UnaryNode:
+		if n.Op == OpNeg {
+			t, ok := canonTerm(n.Child, depth+1)
+			if ok {
+				t.coef = new(big.Rat).Neg(t.coef)
+			}
+			return t, ok
+		}
+		child := n.Child
+		if c, ok := Canonical(n.Child); ok {
+			child = c
+		}
+		return atomTerm(&UnaryNode{Op: n.Op, Child: child}), true
+	case *BinaryNode:
+		if n.Op == OpPow {
+			if k, ok := n.Right.(*ConstNode); ok && k.Val >= -maxCanonPow && k.Val <= maxCanonPow {
+				base, ok := canonTerm(n.Left, depth+1)
+				if !ok {
+					return term{}, false
+				}
+				return base.pow(int(k.Val))
+			}
+		}
+		switch n.Op {
+		case OpBinomial, OpPow, OpHarmonicGen, OpPochhammer:
+			left, right := n.Left, n.Right
+			if c, ok := Canonical(n.Left); ok {
+				left = c
+			}
+			if c, ok := Canonical(n.Right); ok {
+				right = c
+			}
+			return atomTerm(&BinaryNode{Op: n.Op, Left: left, Right: right}), true
+		}
+		a, ok := canonTerm(n.Left, depth+1)
+		if !ok {
+			return term{}, false
+		}
+		b, ok := canonTerm(n.Right, depth+1)
+		if !ok {
+			return term{}, false
+		}
+		switch n.Op {
+		case OpAdd:
+			return addTerms(a, b, 1)
+		case OpSub:
+			return addTerms(a, b, -1)
+		case OpMul:
+			return mulTerms(a, b, 1)
+		default: // OpDiv
+			return mulTerms(a, b, -1)
+		}
+	default:
+		return term{}, false
+	}
+}
+
+// tree prints t, as a quotient when it has a denominator: a RatNode when t
+// is a constant.
+func (t term) tree() (ExprNode, bool) {
+	num, den, ok := t.parts()
+	if !ok {
+		return nil, false
+	}
+	if c, isConst := den.(*ConstNode); isConst && c.Val == 1 {
+		return num, true
+	}
+	if p, isConst := num.(*ConstNode); isConst {
+		if q, isConst := den.(*ConstNode); isConst {
+			return &RatNode{Num: p.Val, Den: q.Val}, true
+		}
+	}
+	return &BinaryNode{Op: OpDiv, Left: num, Right: den}, true
+}
+
+// parts prints t's numerator and denominator, each its coefficient times its
+// polynomial times its atoms in key order.
+func (t term) parts() (num, den ExprNode, ok bool) {
+	if t.coef.Sign() == 0 {
+		return &ConstNode{Val: 0}, &ConstNode{Val: 1}, true
+	}
+	keys := make([]string, 0, len(t.atoms))
+	for k := range t.atoms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	product := func(c *big.Int, p ratPoly, exp int) (ExprNode, bool) {
+		if !c.IsInt64() {
+			return nil, false
+		}
+		var factors []ExprNode
+		if p.deg() >= 1 {
+			f, ok := p.tree()
+			if !ok {
+				return nil, false
+			}
+			factors = append(factors, f)
+		}
+		for _, k := range keys {
+			a := t.atoms[k]
+			switch {
+			case a.exp*exp == 1:
+				factors = append(factors, a.node)
+			case a.exp*exp > 1:
+				factors = append(factors, &BinaryNode{Op: OpPow, Left: a.node, Right: &ConstNode{Val: int64(a.exp * exp)}})
+			}
+		}
+		v := c.Int64()
+		if len(factors) == 0 {
+			return &ConstNode{Val: v}, true
+		}
+		out := factors[0]
+		if v != 1 && v != -1 {
+			out = &BinaryNode{Op: OpMul, Left: &ConstNode{Val: v}, Right: out}
+		}
+		for _, f := range factors[1:] {
+			out = &BinaryNode{Op: OpMul, Left: out, Right: f}
+		}
+		if v == -1 {
+			out = &UnaryNode{Op: OpNeg, Child: out}
+		}
+		return out, true
+	}
+	if num, ok = product(t.coef.Num(), t.num, 1); !ok {
+		return nil, nil, false
+	}
+	if den, ok = product(t.coef.Denom(), t.den, -1); !ok {
+		return nil, nil, false
+	}
+	return num, den, true
+}
@@ -8,6 +8,10 @@ func (c *ConstNode) Clone() ExprNode {
 	return &ConstNode{Val: c.Val}
 }
 
+func (r *RatNode) Clone() ExprNode {
+	return &RatNode{Num: r.Num, Den: r.Den}
+}
+
 func (u *UnaryNode) Clone() ExprNode {
 	return &UnaryNode{
 		Op:    u.Op,
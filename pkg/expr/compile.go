@@ -0,0 +1,349 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+)
+
+// Compiled programs. Evaluating a tree node by node allocates a big.Float at
+// every node for every n. Compile lowers the tree to a flat program over
+// registers that are allocated once and reused, identical subtrees sharing
+// one. The sequences n!, k^n, (-1)^n and C(2n, n) get their own instructions,
+// which update the previous value when n has advanced by one, as it does
+// when a series is summed, instead of recomputing it.
+
+type opcode uint8
+
+const (
+	opVar              opcode = iota
+	opConst                   // val, or val/den for den > 1
+	opUnary                   // unary applied to register a
+	opBinary                  // binary applied to registers a and b
+	opTree                    // node evaluated as a tree
+	opFactorialN              // n!
+	opPowN                    // val^n
+	opAltSignN                // (-1)^n
+	opCentralBinomialN        // C(2n, n)
+)
+
+type instr struct {
+	op     opcode
+	a, b   int
+	val    int64
+	den    int64
+	unary  UnaryOp
+	binary BinaryOp
+	node   ExprNode
+}
+
+// noTerm marks sequence state that holds no value yet.
+const noTerm = math.MinInt64
+
+// Program is an expression tree compiled by Compile. Instruction i writes
+// register i, and the last register holds the result. A Program keeps state
+// between calls, so each goroutine needs its own.
+type Program struct {
+	code []instr
+
+	// big.Float evaluation at prec.
+	prec uint
+	regs []*big.Float
+	seq  []*big.Int // exact sequence values
+	seqN []int64    // the n each seq value is for
+
+	// float64 evaluation.
+	f64  []float64
+	f64N []int64 // the n each sequence value in f64 is for
+}
+
+// Compile lowers node to a Program.
+func Compile(node ExprNode) *Program {
+	p := &Program{}
+	p.emit(node, 0)
+	return p
+}
+
+// emit appends the code for node and returns the register holding its value.
+// An instruction identical to an earlier one reuses its register instead.
+func (p *Program) emit(node ExprNode, depth int) int {
+	in := instr{op: opTree, node: node}
+	switch n := node.(type) {
+	case *VarNode:
+		in = instr{op: opVar}
+	case *ConstNode:
+		in = instr{op: opConst, val: n.Val}
+	case *RatNode:
+		in = instr{op: opConst, val: n.Num, den: n.Den}
+	case *UnaryNode:
+		switch {
+		case depth > maxRecurseDepth:
+		case n.Op == OpFactorial && isVar(n.Child):
+			in = instr{op: opFactorialN}
+		case n.Op == OpAltSign && isVar(n.Child):
+			in = instr{op: opAltSignN}
+		default:
+			in = instr{op: opUnary, unary: n.Op, a: p.emit(n.Child, depth+1)}
+		}
+	case *BinaryNode:
+		k, constBase := n.Left.(*ConstNode)
+		switch {
+		case depth > maxRecurseDepth:
+		case n.Op == OpPow && constBase && isVar(n.Right):
+			in = instr{op: opPowN, val: k.Val}
+		case n.Op == OpBinomial && isTwoN(n.Left) && isVar(n.Right):
+			in = instr{op: opCentralBinomialN}
+		default:
+			a := p.emit(n.Left, depth+1)
+			b := p.emit(n.Right, depth+1)
+			in = instr{op: opBinary, binary: n.Op, a: a, b: b}
+		}
+	}
+	if in.op != opTree {
+		for r, prev := range p.code {
+			if prev == in {
+				return r
+			}
+		}
+	}
+	p.code = append(p.code, in)
+	return len(p.code) - 1
+}
+
+func isVar(node ExprNode) bool {
+	_, ok := node.(*VarNode)
+	return ok
+}
+
+// isTwoN reports whether node is 2n written as 2*n, n*2 or n+n.
+func isTwoN(node ExprNode) bool {
+	b, ok := node.(*BinaryNode)
+	if !ok {
+		return false
+	}
+	isTwo := func(x ExprNode) bool {
+		c, ok := x.(*ConstNode)
+		return ok && c.Val == 2
+	}
+	switch b.Op {
+	case OpMul:
+		return isTwo(b.Left) && isVar(b.Right) || isVar(b.Left) && isTwo(b.Right)
+	case OpAdd:
+		return isVar(b.Left) && isVar(b.Right)
+	}
+	return false
+}
+
+// Eval evaluates the program at n like the tree's Eval. The result is one of
+// the program's registers: it is overwritten by the next call, so copy it to
+// keep it.
+func (p *Program) Eval(n int64, prec uint) (*big.Float, bool) {
+	if p.regs == nil || p.prec != prec {
+		p.alloc(prec)
+	}
+	for i, in := range p.code {
+		r := p.regs[i]
+		switch in.op {
+		case opVar:
+			r.SetInt64(n)
+
+		case opConst:
+			// set by alloc
+
+		case opUnary:
+			x := p.regs[in.a]
+			switch in.unary {
+			case OpNeg:
+				r.Neg(x)
+			case OpAbs:
+				r.Abs(x)
+			default:
+				v, ok := evalUnary(in.unary, x, prec)
+				if !ok {
+					return nil, false
+				}
+				p.regs[i] = v
+			}
+
+		case opBinary:
+			x, y := p.regs[in.a], p.regs[in.b]
+			switch in.binary {
+			case OpAdd:
+				r.Add(x, y)
+			case OpSub:
+				r.Sub(x, y)
+			case OpMul:
+				r.Mul(x, y)
+			case OpDiv:
+				if y.Sign() == 0 {
+					return nil, false
+				}
+				r.Quo(x, y)
+			default:
+				v, ok := evalBinary(in.binary, x, y, prec)
+				if !ok {
+					return nil, false
+				}
+				p.regs[i] = v
+			}
+
+		case opTree:
+			v, ok := in.node.Eval(new(big.Float).SetPrec(prec).SetInt64(n), prec)
+			if !ok {
+				return nil, false
+			}
+			p.regs[i] = v
+
+		case opAltSignN:
+			if n < 0 {
+				return nil, false
+			}
+			r.SetInt64(1 - 2*(n&1))
+
+		case opFactorialN, opPowN, opCentralBinomialN:
+			if !p.step(i, in, n) {
+				// Outside what the recurrences cover: as the tree would.
+				v, ok := evalSequence(in, n, prec)
+				if !ok {
+					return nil, false
+				}
+				p.regs[i] = v
+				continue
+			}
+			r.SetInt(p.seq[i])
+		}
+	}
+	return p.regs[len(p.regs)-1], true
+}
+
+// step brings the exact value of sequence instruction i to n, from the
+// previous value when that is for n-1. It reports false when n is outside
+// the range the sequence is computed exactly for.
+func (p *Program) step(i int, in instr, n int64) bool {
+	s, next := p.seq[i], p.seqN[i] == n-1
+	switch in.op {
+	case opFactorialN:
+		if n < 0 || n > maxComputeInput {
+			return false
+		}
+		if next {
+			s.Mul(s, big.NewInt(n))
+		} else if p.seqN[i] != n {
+			s.Set(factorialInt(n))
+		}
+	case opPowN:
+		if n < 0 || n > maxRatPow {
+			return false
+		}
+		if next {
+			s.Mul(s, big.NewInt(in.val))
+		} else if p.seqN[i] != n {
+			s.Exp(big.NewInt(in.val), big.NewInt(n), nil)
+		}
+	case opCentralBinomialN:
+		if n < 0 || 2*n > 1000 {
+			return false
+		}
+		if next {
+			// C(2n, n) = C(2n-2, n-1) * 2(2n-1) / n
+			s.Mul(s, big.NewInt(2*(2*n-1)))
+			s.Quo(s, big.NewInt(n))
+		} else if p.seqN[i] != n {
+			v, _ := binomialInt(2*n, n)
+			s.Set(v)
+		}
+	}
+	p.seqN[i] = n
+	return true
+}
+
+// evalSequence evaluates a sequence instruction as its tree would.
+func evalSequence(in instr, n int64, prec uint) (*big.Float, bool) {
+	nf := new(big.Float).SetPrec(prec).SetInt64(n)
+	switch in.op {
+	case opFactorialN:
+		return bigFactorial(nf, prec)
+	case opPowN:
+		return bigPow(new(big.Float).SetPrec(prec).SetInt64(in.val), nf, prec)
+	default:
+		two := new(big.Float).SetPrec(prec).SetInt64(2 * n)
+		return bigBinomial(two, nf, prec)
+	}
+}
+
+// alloc sets up registers and clears sequence state for prec.
+func (p *Program) alloc(prec uint) {
+	p.prec = prec
+	p.regs = make([]*big.Float, len(p.code))
+	p.seq = make([]*big.Int, len(p.code))
+	p.seqN = make([]int64, len(p.code))
+	for i, in := range p.code {
+		p.regs[i] = new(big.Float).SetPrec(prec)
+		if in.op == opConst {
+			if in.den > 1 {
+				p.regs[i].SetRat(big.NewRat(in.val, in.den))
+			} else {
+				p.regs[i].SetInt64(in.val)
+			}
+		}
+		p.seq[i] = new(big.Int)
+		p.seqN[i] = noTerm
+	}
+}
+
+// EvalF64 evaluates the program at n like the tree's EvalF64.
+func (p *Program) EvalF64(n int64) (float64, bool) {
+	if p.f64 == nil {
+		p.f64 = make([]float64, len(p.code))
+		p.f64N = make([]int64, len(p.code))
+		for i := range p.f64N {
+			p.f64N[i] = noTerm
+		}
+	}
+	r := p.f64
+	for i, in := range p.code {
+		var v float64
+		ok := true
+		switch in.op {
+		case opVar:
+			v = float64(n)
+		case opConst:
+			v = float64(in.val)
+			if in.den > 1 {
+				v /= float64(in.den)
+			}
+		case opUnary:
+			v, ok = evalUnaryF64(in.unary, r[in.a])
+		case opBinary:
+			v, ok = evalBinaryF64(in.binary, r[in.a], r[in.b])
+		case opTree:
+			v, ok = in.node.EvalF64(float64(n))
+		case opAltSignN:
+			v, ok = float64(1-2*(n&1)), n >= 0
+		case opFactorialN:
+			ok = n >= 0 && n < int64(len(factorialF64))
+			if ok {
+				v = factorialF64[n]
+			}
+		case opPowN:
+			if p.f64N[i] == n-1 && n >= 1 && n <= 20 {
+				v = r[i] * float64(in.val)
+				ok = !math.IsInf(v, 0)
+			} else {
+				v, ok = powF64(float64(in.val), float64(n))
+			}
+		case opCentralBinomialN:
+			if p.f64N[i] == n-1 && n >= 1 && 2*n <= 1000 {
+				v = r[i] * float64(2*(2*n-1)) / float64(n)
+				ok = !math.IsInf(v, 0)
+			} else {
+				v, ok = binomialF64(float64(2*n), float64(n))
+			}
+		}
+		if !ok {
+			p.f64N[i] = noTerm
+			return 0, false
+		}
+		r[i], p.f64N[i] = v, n
+	}
+	return r[len(r)-1], true
+}
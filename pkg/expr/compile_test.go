@@ -0,0 +1,107 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// compileTestTrees cover every instruction kind, including the sequences,
+// shared subtrees and patterns that only look like sequences.
+var compileTestTrees = []string{
+	`\frac{1}{n!}`,
+	`\frac{(-1)^{n} \cdot 4^{n}}{\binom{2 \cdot n}{n} \cdot (2 \cdot n + 1)}`,
+	`\frac{n! \cdot n!}{(2 \cdot n)!}`,
+	`\binom{n + n}{n} - \binom{2 \cdot n}{n - 1}`,
+	`(-1)^{n} + (-2)^{n} + 0^{n}`,
+	`\frac{\sin(n) + \sqrt{n}}{\lfloor \frac{n}{3} \rfloor + 1}`,
+	`\frac{n^{2} - 3}{F_{n} + n!!}`,
+	`\frac{n + \frac{1}{3}}{\frac{-5}{7} \cdot n^{2} + 2}`,
+}
+
+func TestProgram_MatchesTree(t *testing.T) {
+	const prec = 512
+	// Runs of consecutive n exercise the recurrences; the jumps and negative
+	// n exercise recomputation and failure.
+	ns := []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 40, 41, 42, 5, -1, 0, 1, 499, 500, 501, 502}
+	for _, s := range compileTestTrees {
+		node, err := ParseExprLatex(s)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", s, err)
+		}
+		prog := Compile(node)
+		for _, n := range ns {
+			want, wantOK := node.Eval(new(big.Float).SetPrec(prec).SetInt64(n), prec)
+			got, gotOK := prog.Eval(n, prec)
+			if gotOK != wantOK {
+				t.Errorf("%s at n=%d: ok = %v, tree %v", node, n, gotOK, wantOK)
+				continue
+			}
+			if wantOK {
+				diff := new(big.Float).Sub(got, want)
+				if diff.Sign() != 0 && diff.Quo(diff, want).Abs(diff).Cmp(big.NewFloat(1e-140)) > 0 {
+					t.Errorf("%s at n=%d: %s, tree %s", node, n, got.Text('g', 20), want.Text('g', 20))
+				}
+			}
+
+			// Incremental float64 updates round once per step, so allow
+			// for a few hundred ulps by n = 500.
+			wantF, wantOK := node.EvalF64(float64(n))
+			gotF, gotOK := prog.EvalF64(n)
+			if gotOK != wantOK || wantOK && math.Abs(gotF-wantF) > 1e-10*math.Abs(wantF) {
+				t.Errorf("%s at n=%d: EvalF64 = %v, %v, tree %v, %v", node, n, gotF, gotOK, wantF, wantOK)
+			}
+		}
+	}
+}
+
+// benchTree is a Ramanujan-style term built from the sequences Compile
+// recognizes: (-1)^n 4^n / (C(2n, n) (2n+1) n!).
+const benchTree = `\frac{(-1)^{n} \cdot 4^{n}}{\binom{2 \cdot n}{n} \cdot (2 \cdot n + 1) \cdot n!}`
+
+func benchNode(b *testing.B) ExprNode {
+	node, err := ParseExprLatex(benchTree)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return node
+}
+
+func BenchmarkEval_Tree(b *testing.B) {
+	node := benchNode(b)
+	nf := new(big.Float).SetPrec(512)
+	for i := 0; i < b.N; i++ {
+		for n := int64(0); n < 256; n++ {
+			node.Eval(nf.SetInt64(n), 512)
+		}
+	}
+}
+
+func BenchmarkEval_Program(b *testing.B) {
+	node := benchNode(b)
+	for i := 0; i < b.N; i++ {
+		prog := Compile(node)
+		for n := int64(0); n < 256; n++ {
+			prog.Eval(n, 512)
+		}
+	}
+}
+
+func BenchmarkEvalF64_Tree(b *testing.B) {
+	node := benchNode(b)
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < 256; n++ {
+			node.EvalF64(float64(n))
+		}
+	}
+}
+
+func BenchmarkEvalF64_Program(b *testing.B) {
+	node := benchNode(b)
+	for i := 0; i < b.N; i++ {
+		prog := Compile(node)
+		for n := int64(0); n < 256; n++ {
+			prog.EvalF64(n)
+		}
+	}
+}
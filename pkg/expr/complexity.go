@@ -4,6 +4,7 @@ import "math"
 
 func (v *VarNode) NodeCount() int { return 1 }
 func (c *ConstNode) NodeCount() int { return 1 }
+func (r *RatNode) NodeCount() int { return 1 }
 func (u *UnaryNode) NodeCount() int { return 1 + u.Child.NodeCount() }
 func (b *BinaryNode) NodeCount() int {
 	return 1 + b.Left.NodeCount() + b.Right.NodeCount()
@@ -11,6 +12,7 @@ func (b *BinaryNode) NodeCount() int {
 
 func (v *VarNode) Depth() int { return 1 }
 func (c *ConstNode) Depth() int { return 1 }
+func (r *RatNode) Depth() int { return 1 }
 func (u *UnaryNode) Depth() int { return 1 + u.Child.Depth() }
 func (b *BinaryNode) Depth() int {
 	ld := b.Left.Depth()
@@ -28,14 +30,10 @@ func WeightedComplexity(node ExprNode) float64 {
 	case *VarNode:
 		return 1.0
 	case *ConstNode:
-		v := n.Val
-		if v < 0 {
-			v = -v
-		}
-		if v <= 10 {
-			return 1.0
-		}
-		return 1.0 + math.Log10(float64(v))
+		return constWeight(n.Val)
+	case *RatNode:
+		// As its numerator and denominator, without the division.
+		return constWeight(n.Num) + constWeight(n.Den)
 	case *UnaryNode:
 		w := unaryWeight(n.Op)
 		return w + WeightedComplexity(n.Child)
@@ -47,6 +45,17 @@ func WeightedComplexity(node ExprNode) float64 {
 	}
 }
 
+// constWeight is 1 for small integers, growing with the digits of larger ones.
+func constWeight(v int64) float64 {
+	if v < 0 {
+		v = -v
+	}
+	if v <= 10 {
+		return 1.0
+	}
+	return 1.0 + math.Log10(float64(v))
+}
+
 func unaryWeight(op UnaryOp) float64 {
 	switch op {
 	case OpNeg, OpAbs:
@@ -61,6 +70,10 @@ func unaryWeight(op UnaryOp) float64 {
 		return 2.0
 	case OpSqrt:
 		return 2.0
+	case OpCatalan, OpLucas, OpHarmonic:
+		return 3.0
+	case OpBernoulli:
+		return 4.0
 	default:
 		return 2.0
 	}
@@ -74,8 +87,10 @@ func binaryWeight(op BinaryOp) float64 {
 		return 1.5
 	case OpPow:
 		return 2.0
-	case OpBinomial:
+	case OpBinomial, OpPochhammer:
 		return 3.0
+	case OpHarmonicGen:
+		return 4.0
 	default:
 		return 1.5
 	}
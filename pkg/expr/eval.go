@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/big"
 	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
 )
 
 var (
@@ -19,13 +21,21 @@ func (c *ConstNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	return new(big.Float).SetPrec(prec).SetInt64(c.Val), true
 }
 
+func (r *RatNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
+	return new(big.Float).SetPrec(prec).SetRat(big.NewRat(r.Num, r.Den)), true
+}
+
 func (u *UnaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	child, ok := u.Child.Eval(n, prec)
 	if !ok {
 		return nil, false
 	}
+	return evalUnary(u.Op, child, prec)
+}
 
-	switch u.Op {
+// evalUnary applies op to an evaluated child.
+func evalUnary(op UnaryOp, child *big.Float, prec uint) (*big.Float, bool) {
+	switch op {
 	case OpNeg:
 		return new(big.Float).SetPrec(prec).Neg(child), true
 
@@ -49,26 +59,28 @@ func (u *UnaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	case OpFibonacci:
 		return bigFibonacci(child, prec)
 
+	case OpHarmonic:
+		return bigHarmonic(child, bigOne, prec)
+
+	case OpBernoulli:
+		return bigBernoulli(child, prec)
+
+	case OpCatalan:
+		return bigCatalan(child, prec)
+
+	case OpLucas:
+		return bigLucas(child, prec)
+
 	case OpSin:
-		f, _ := child.Float64()
-		if math.IsInf(f, 0) || math.IsNaN(f) {
-			return nil, false
-		}
-		return new(big.Float).SetPrec(prec).SetFloat64(math.Sin(f)), true
+		sin, _, ok := bigSinCos(child, prec)
+		return sin, ok
 
 	case OpCos:
-		f, _ := child.Float64()
-		if math.IsInf(f, 0) || math.IsNaN(f) {
-			return nil, false
-		}
-		return new(big.Float).SetPrec(prec).SetFloat64(math.Cos(f)), true
+		_, cos, ok := bigSinCos(child, prec)
+		return cos, ok
 
 	case OpLn:
-		f, _ := child.Float64()
-		if f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
-			return nil, false
-		}
-		return new(big.Float).SetPrec(prec).SetFloat64(math.Log(f)), true
+		return constants.Ln(child, prec)
 
 	case OpFloor:
 		return bigFloor(child, prec), true
@@ -99,8 +111,12 @@ func (b *BinaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	if !ok {
 		return nil, false
 	}
+	return evalBinary(b.Op, left, right, prec)
+}
 
-	switch b.Op {
+// evalBinary applies op to evaluated operands.
+func evalBinary(op BinaryOp, left, right *big.Float, prec uint) (*big.Float, bool) {
+	switch op {
 	case OpAdd:
 		return new(big.Float).SetPrec(prec).Add(left, right), true
 
@@ -122,6 +138,12 @@ func (b *BinaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	case OpBinomial:
 		return bigBinomial(left, right, prec)
 
+	case OpHarmonicGen:
+		return bigHarmonic(left, right, prec)
+
+	case OpPochhammer:
+		return bigPochhammer(left, right, prec)
+
 	default:
 		return nil, false
 	}
@@ -143,23 +165,24 @@ const maxComputeInput = 1000
 
 // Memoized lookup tables that grow on demand.
 var (
-	factorialCache = &mathCache{}
-	dblFactCache   = &mathCache{}
-	fibonacciCache = &mathCache{}
+	factorialCache = &mathCache[*big.Int]{}
+	dblFactCache   = &mathCache[*big.Int]{}
+	fibonacciCache = &mathCache[*big.Int]{}
 )
 
-type mathCache struct {
+type mathCache[T any] struct {
 	mu     sync.RWMutex
-	values []*big.Int
+	values []T
 }
 
-func (c *mathCache) get(n int64) (*big.Int, bool) {
+func (c *mathCache[T]) get(n int64) (T, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	if n < int64(len(c.values)) {
 		return c.values[n], true
 	}
-	return nil, false
+	var zero T
+	return zero, false
 }
 
 func init() {
@@ -192,30 +215,50 @@ func init() {
 	fibonacciCache.values = fibs
 }
 
+// extend returns the n-th value, first appending next(i, values) for each
+// missing index i.
+func (c *mathCache[T]) extend(n int64, next func(i int64, values []T) T) T {
+	if v, ok := c.get(n); ok {
+		return v
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := int64(len(c.values)); i <= n; i++ {
+		c.values = append(c.values, next(i, c.values))
+	}
+	return c.values[n]
+}
+
+// factorialInt returns n! for 0 <= n <= maxComputeInput.
+func factorialInt(n int64) *big.Int {
+	return factorialCache.extend(n, func(i int64, v []*big.Int) *big.Int {
+		return new(big.Int).Mul(v[i-1], big.NewInt(i))
+	})
+}
+
+// doubleFactorialInt returns n!! for 0 <= n <= maxComputeInput.
+func doubleFactorialInt(n int64) *big.Int {
+	return dblFactCache.extend(n, func(i int64, v []*big.Int) *big.Int {
+		if i < 2 {
+			return big.NewInt(1)
+		}
+		return new(big.Int).Mul(v[i-2], big.NewInt(i))
+	})
+}
+
+// fibonacciInt returns F(n) for 0 <= n <= maxComputeInput.
+func fibonacciInt(n int64) *big.Int {
+	return fibonacciCache.extend(n, func(i int64, v []*big.Int) *big.Int {
+		return new(big.Int).Add(v[i-1], v[i-2])
+	})
+}
+
 func bigFactorial(f *big.Float, prec uint) (*big.Float, bool) {
 	iv, ok := toInt64(f)
 	if !ok || iv < 0 || iv > maxComputeInput {
 		return nil, false
 	}
-	if v, ok := factorialCache.get(iv); ok {
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	// Extend cache up to iv
-	factorialCache.mu.Lock()
-	// Re-check after acquiring write lock
-	if iv < int64(len(factorialCache.values)) {
-		v := factorialCache.values[iv]
-		factorialCache.mu.Unlock()
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	cur := int64(len(factorialCache.values))
-	for i := cur; i <= iv; i++ {
-		next := new(big.Int).Mul(factorialCache.values[i-1], big.NewInt(i))
-		factorialCache.values = append(factorialCache.values, next)
-	}
-	v := factorialCache.values[iv]
-	factorialCache.mu.Unlock()
-	return new(big.Float).SetPrec(prec).SetInt(v), true
+	return new(big.Float).SetPrec(prec).SetInt(factorialInt(iv)), true
 }
 
 func bigDoubleFactorial(f *big.Float, prec uint) (*big.Float, bool) {
@@ -223,28 +266,7 @@ func bigDoubleFactorial(f *big.Float, prec uint) (*big.Float, bool) {
 	if !ok || iv < 0 || iv > maxComputeInput {
 		return nil, false
 	}
-	if v, ok := dblFactCache.get(iv); ok {
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	dblFactCache.mu.Lock()
-	if iv < int64(len(dblFactCache.values)) {
-		v := dblFactCache.values[iv]
-		dblFactCache.mu.Unlock()
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	cur := int64(len(dblFactCache.values))
-	for i := cur; i <= iv; i++ {
-		var next *big.Int
-		if i < 2 {
-			next = big.NewInt(1)
-		} else {
-			next = new(big.Int).Mul(dblFactCache.values[i-2], big.NewInt(i))
-		}
-		dblFactCache.values = append(dblFactCache.values, next)
-	}
-	v := dblFactCache.values[iv]
-	dblFactCache.mu.Unlock()
-	return new(big.Float).SetPrec(prec).SetInt(v), true
+	return new(big.Float).SetPrec(prec).SetInt(doubleFactorialInt(iv)), true
 }
 
 func bigFibonacci(f *big.Float, prec uint) (*big.Float, bool) {
@@ -252,23 +274,7 @@ func bigFibonacci(f *big.Float, prec uint) (*big.Float, bool) {
 	if !ok || iv < 0 || iv > maxComputeInput {
 		return nil, false
 	}
-	if v, ok := fibonacciCache.get(iv); ok {
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	fibonacciCache.mu.Lock()
-	if iv < int64(len(fibonacciCache.values)) {
-		v := fibonacciCache.values[iv]
-		fibonacciCache.mu.Unlock()
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	cur := int64(len(fibonacciCache.values))
-	for i := cur; i <= iv; i++ {
-		next := new(big.Int).Add(fibonacciCache.values[i-1], fibonacciCache.values[i-2])
-		fibonacciCache.values = append(fibonacciCache.values, next)
-	}
-	v := fibonacciCache.values[iv]
-	fibonacciCache.mu.Unlock()
-	return new(big.Float).SetPrec(prec).SetInt(v), true
+	return new(big.Float).SetPrec(prec).SetInt(fibonacciInt(iv)), true
 }
 
 // bigSqrt computes sqrt(x) to full precision using Newton's method.
@@ -313,17 +319,7 @@ func bigPow(base, exp *big.Float, prec uint) (*big.Float, bool) {
 		}
 		return intPow(base, ei, prec)
 	}
-	// Fallback to float64 for non-integer exponents
-	bf, _ := base.Float64()
-	ef, _ := exp.Float64()
-	if bf < 0 {
-		return nil, false
-	}
-	result := math.Pow(bf, ef)
-	if math.IsInf(result, 0) || math.IsNaN(result) {
-		return nil, false
-	}
-	return new(big.Float).SetPrec(prec).SetFloat64(result), true
+	return bigRealPow(base, exp, prec)
 }
 
 func intPow(base *big.Float, exp int64, prec uint) (*big.Float, bool) {
@@ -344,11 +340,23 @@ func intPow(base *big.Float, exp int64, prec uint) (*big.Float, bool) {
 
 func bigBinomial(nf, kf *big.Float, prec uint) (*big.Float, bool) {
 	n, ok := toInt64(nf)
-	if !ok || n < 0 || n > 1000 {
+	if !ok {
 		return nil, false
 	}
 	k, ok := toInt64(kf)
-	if !ok || k < 0 || k > n {
+	if !ok {
+		return nil, false
+	}
+	result, ok := binomialInt(n, k)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).SetInt(result), true
+}
+
+// binomialInt returns C(n, k) for 0 <= k <= n <= 1000.
+func binomialInt(n, k int64) (*big.Int, bool) {
+	if n < 0 || n > 1000 || k < 0 || k > n {
 		return nil, false
 	}
 	if k > n-k {
@@ -359,7 +367,7 @@ func bigBinomial(nf, kf *big.Float, prec uint) (*big.Float, bool) {
 		result.Mul(result, big.NewInt(n-i))
 		result.Div(result, big.NewInt(i+1))
 	}
-	return new(big.Float).SetPrec(prec).SetInt(result), true
+	return result, true
 }
 
 func bigFloor(f *big.Float, prec uint) *big.Float {
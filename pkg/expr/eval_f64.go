@@ -41,14 +41,23 @@ func (c *ConstNode) EvalF64(n float64) (float64, bool) {
 	return float64(c.Val), true
 }
 
+// EvalF64 for RatNode returns the quotient.
+func (r *RatNode) EvalF64(n float64) (float64, bool) {
+	return float64(r.Num) / float64(r.Den), true
+}
+
 // EvalF64 for UnaryNode dispatches on op.
 func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 	child, ok := u.Child.EvalF64(n)
 	if !ok {
 		return 0, false
 	}
+	return evalUnaryF64(u.Op, child)
+}
 
-	switch u.Op {
+// evalUnaryF64 applies op to an evaluated child.
+func evalUnaryF64(op UnaryOp, child float64) (float64, bool) {
+	switch op {
 	case OpNeg:
 		return -child, true
 
@@ -83,6 +92,31 @@ func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 		}
 		return fibonacciF64[iv], true
 
+	case OpHarmonic:
+		return harmonicF64At(child, 1)
+
+	case OpBernoulli:
+		b := bernoulliF64()
+		iv, ok := seqIndexF64(child, len(b))
+		if !ok {
+			return 0, false
+		}
+		return b[iv], true
+
+	case OpCatalan:
+		iv, ok := seqIndexF64(child, len(catalanF64))
+		if !ok {
+			return 0, false
+		}
+		return catalanF64[iv], true
+
+	case OpLucas:
+		iv, ok := seqIndexF64(child, len(lucasF64))
+		if !ok {
+			return 0, false
+		}
+		return lucasF64[iv], true
+
 	case OpSin:
 		if math.IsInf(child, 0) || math.IsNaN(child) {
 			return 0, false
@@ -137,8 +171,12 @@ func (b *BinaryNode) EvalF64(n float64) (float64, bool) {
 	if !ok {
 		return 0, false
 	}
+	return evalBinaryF64(b.Op, left, right)
+}
 
-	switch b.Op {
+// evalBinaryF64 applies op to evaluated operands.
+func evalBinaryF64(op BinaryOp, left, right float64) (float64, bool) {
+	switch op {
 	case OpAdd:
 		r := left + right
 		if math.IsInf(r, 0) || math.IsNaN(r) {
@@ -176,6 +214,12 @@ func (b *BinaryNode) EvalF64(n float64) (float64, bool) {
 	case OpBinomial:
 		return binomialF64(left, right)
 
+	case OpHarmonicGen:
+		return harmonicF64At(left, right)
+
+	case OpPochhammer:
+		return pochhammerF64(left, right)
+
 	default:
 		return 0, false
 	}
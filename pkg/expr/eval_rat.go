@@ -0,0 +1,290 @@
+package expr
+
+import "math/big"
+
+// Exact evaluation. Trees built from +, -, *, /, integer powers, the
+// integer-valued functions (factorial, double factorial, fibonacci, binomial,
+// altsign, floor, ceil, Catalan, Lucas) and the rational sequences (harmonic,
+// Bernoulli, rising factorial) have rational values at every integer n. EvalRat
+// computes them exactly, with the same domain as Eval: it fails where Eval
+// does, and on the irrational operations.
+//
+// Operations defined only at integers see an integer in Eval only when binary
+// floating point computed their argument exactly. EvalRat is given the
+// precision Eval would use and checks integer arguments as Eval does (see
+// intArg), so fib((7/n)*n) fails on both paths wherever Eval rounds (7/n)*n
+// off 7.
+
+// maxRatPow caps the exponent EvalRat raises to, as intPow does.
+const maxRatPow = 10000
+
+func (v *VarNode) EvalRat(n *big.Int, prec uint) (*big.Rat, bool) {
+	return new(big.Rat).SetInt(n), true
+}
+
+func (c *ConstNode) EvalRat(n *big.Int, prec uint) (*big.Rat, bool) {
+	return new(big.Rat).SetInt64(c.Val), true
+}
+
+func (r *RatNode) EvalRat(n *big.Int, prec uint) (*big.Rat, bool) {
+	return big.NewRat(r.Num, r.Den), true
+}
+
+func (u *UnaryNode) EvalRat(n *big.Int, prec uint) (*big.Rat, bool) {
+	child, ok := u.Child.EvalRat(n, prec)
+	if !ok {
+		return nil, false
+	}
+
+	switch u.Op {
+	case OpNeg:
+		return child.Neg(child), true
+
+	case OpAbs:
+		return child.Abs(child), true
+
+	case OpFloor:
+		return new(big.Rat).SetInt(ratFloor(child)), true
+
+	case OpCeil:
+		child.Neg(child)
+		floor := ratFloor(child)
+		return new(big.Rat).SetInt(floor.Neg(floor)), true
+
+	case OpAltSign:
+		iv, ok := ratInt64(child)
+		if !ok || iv < 0 || !intArg(u.Child, n, prec) {
+			return nil, false
+		}
+		if iv%2 == 0 {
+			return big.NewRat(1, 1), true
+		}
+		return big.NewRat(-1, 1), true
+
+	case OpFactorial, OpDoubleFactorial, OpFibonacci:
+		iv, ok := ratInt64(child)
+		if !ok || iv < 0 || iv > maxComputeInput || !intArg(u.Child, n, prec) {
+			return nil, false
+		}
+		switch u.Op {
+		case OpFactorial:
+			return new(big.Rat).SetInt(factorialInt(iv)), true
+		case OpDoubleFactorial:
+			return new(big.Rat).SetInt(doubleFactorialInt(iv)), true
+		default:
+			return new(big.Rat).SetInt(fibonacciInt(iv)), true
+		}
+
+	case OpHarmonic, OpBernoulli, OpCatalan, OpLucas:
+		iv, ok := ratInt64(child)
+		if !ok || iv < 0 || iv > maxComputeInput || !intArg(u.Child, n, prec) {
+			return nil, false
+		}
+		switch u.Op {
+		case OpHarmonic:
+			return new(big.Rat).Set(harmonicRat(iv, 1)), true
+		case OpBernoulli:
+			return new(big.Rat).Set(bernoulliRat(iv)), true
+		case OpCatalan:
+			return new(big.Rat).SetInt(catalanInt(iv)), true
+		default:
+			return new(big.Rat).SetInt(lucasInt(iv)), true
+		}
+
+	default: // sin, cos, ln, sqrt
+		return nil, false
+	}
+}
+
+func (b *BinaryNode) EvalRat(n *big.Int, prec uint) (*big.Rat, bool) {
+	left, ok := b.Left.EvalRat(n, prec)
+	if !ok {
+		return nil, false
+	}
+	right, ok := b.Right.EvalRat(n, prec)
+	if !ok {
+		return nil, false
+	}
+	switch b.Op {
+	case OpBinomial, OpHarmonicGen:
+		if !intArg(b.Left, n, prec) || !intArg(b.Right, n, prec) {
+			return nil, false
+		}
+	case OpPow, OpPochhammer:
+		if !intArg(b.Right, n, prec) {
+			return nil, false
+		}
+	}
+
+	switch b.Op {
+	case OpAdd:
+		return left.Add(left, right), true
+
+	case OpSub:
+		return left.Sub(left, right), true
+
+	case OpMul:
+		return left.Mul(left, right), true
+
+	case OpDiv:
+		if right.Sign() == 0 {
+			return nil, false
+		}
+		return left.Quo(left, right), true
+
+	case OpPow:
+		return ratPow(left, right)
+
+	case OpBinomial:
+		nv, ok := ratInt64(left)
+		if !ok {
+			return nil, false
+		}
+		kv, ok := ratInt64(right)
+		if !ok {
+			return nil, false
+		}
+		result, ok := binomialInt(nv, kv)
+		if !ok {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(result), true
+
+	case OpHarmonicGen:
+		nv, ok := ratInt64(left)
+		if !ok || nv < 0 || nv > maxComputeInput {
+			return nil, false
+		}
+		kv, ok := ratInt64(right)
+		if !ok || kv < 1 || kv > maxHarmonicOrder {
+			return nil, false
+		}
+		return new(big.Rat).Set(harmonicRat(nv, kv)), true
+
+	case OpPochhammer:
+		nv, ok := ratInt64(right)
+		if !ok {
+			return nil, false
+		}
+		return pochhammerRat(left, nv)
+
+	default:
+		return nil, false
+	}
+}
+
+// intArg reports whether Eval at prec computes node at n as an integer. Only
+// then does Eval take it as the argument of an operation defined at
+// integers; n and constants always pass.
+func intArg(node ExprNode, n *big.Int, prec uint) bool {
+	switch node.(type) {
+	case *VarNode, *ConstNode:
+		return true
+	}
+	v, ok := node.Eval(new(big.Float).SetPrec(prec).SetInt(n), prec)
+	if !ok {
+		return false
+	}
+	_, ok = toInt64(v)
+	return ok
+}
+
+// ratInt64 converts r to int64 if it is a whole number that fits.
+func ratInt64(r *big.Rat) (int64, bool) {
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, false
+	}
+	return r.Num().Int64(), true
+}
+
+// ratFloor returns the largest integer not above r.
+func ratFloor(r *big.Rat) *big.Int {
+	// Div is Euclidean, so with a positive divisor it rounds down.
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+// ratPow raises base to an integer exponent; other exponents fail, since
+// the result is then irrational in general.
+func ratPow(base, exp *big.Rat) (*big.Rat, bool) {
+	ei, ok := ratInt64(exp)
+	if !ok || ei > maxRatPow || ei < -maxRatPow {
+		return nil, false
+	}
+	if ei < 0 {
+		if base.Sign() == 0 {
+			return nil, false
+		}
+		base.Inv(base)
+		ei = -ei
+	}
+	num := new(big.Int).Exp(base.Num(), big.NewInt(ei), nil)
+	den := new(big.Int).Exp(base.Denom(), big.NewInt(ei), nil)
+	return new(big.Rat).SetFrac(num, den), true
+}
+
+// IsRational reports whether node has a rational value at every integer n
+// where it is defined, so that EvalRat can evaluate it: it avoids sin, cos,
+// ln and sqrt, and raises only to integer powers.
+func IsRational(node ExprNode) bool {
+	return isRationalD(node, 0)
+}
+
+func isRationalD(node ExprNode, depth int) bool {
+	if depth > maxRecurseDepth {
+		return false
+	}
+	switch n := node.(type) {
+	case *VarNode, *ConstNode, *RatNode:
+		return true
+	case *UnaryNode:
+		switch n.Op {
+		case OpSin, OpCos, OpLn, OpSqrt:
+			return false
+		}
+		return isRationalD(n.Child, depth+1)
+	case *BinaryNode:
+		if n.Op == OpPow && !isIntegerD(n.Right, depth+1) {
+			return false
+		}
+		return isRationalD(n.Left, depth+1) && isRationalD(n.Right, depth+1)
+	default:
+		return false
+	}
+}
+
+// isIntegerD reports whether node is an integer at every integer n where it
+// is defined.
+func isIntegerD(node ExprNode, depth int) bool {
+	if depth > maxRecurseDepth {
+		return false
+	}
+	switch n := node.(type) {
+	case *VarNode, *ConstNode:
+		return true
+	case *UnaryNode:
+		switch n.Op {
+		case OpFactorial, OpDoubleFactorial, OpFibonacci, OpAltSign, OpCatalan, OpLucas:
+			return true // defined only at integers, with integer values
+		case OpFloor, OpCeil:
+			return isRationalD(n.Child, depth+1)
+		case OpNeg, OpAbs:
+			return isIntegerD(n.Child, depth+1)
+		}
+		return false
+	case *BinaryNode:
+		switch n.Op {
+		case OpAdd, OpSub, OpMul:
+			return isIntegerD(n.Left, depth+1) && isIntegerD(n.Right, depth+1)
+		case OpBinomial:
+			return true
+		case OpPochhammer:
+			return isIntegerD(n.Left, depth+1)
+		case OpPow:
+			k, ok := n.Right.(*ConstNode)
+			return ok && k.Val >= 0 && isIntegerD(n.Left, depth+1)
+		}
+		return false
+	default:
+		return false
+	}
+}
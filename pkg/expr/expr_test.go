@@ -3,6 +3,7 @@ package expr
 import (
 	"math"
 	"math/big"
+	"math/rand"
 	"testing"
 )
 
@@ -51,6 +52,48 @@ func TestConstNode(t *testing.T) {
 	}
 }
 
+func TestRatNode(t *testing.T) {
+	r := &RatNode{Num: -3, Den: 4}
+	assertEval(t, r, 99, -0.75, 0)
+	if v, ok := r.EvalF64(99); !ok || v != -0.75 {
+		t.Errorf("RatNode.EvalF64() = %v, %v, want -0.75", v, ok)
+	}
+	if v, ok := r.EvalRat(big.NewInt(99), testPrec); !ok || v.RatString() != "-3/4" {
+		t.Errorf("RatNode.EvalRat() = %v, %v, want -3/4", v, ok)
+	}
+	if r.String() != "-3/4" || r.LaTeX() != `\frac{-3}{4}` {
+		t.Errorf("RatNode prints as %q and %q", r.String(), r.LaTeX())
+	}
+
+	// A fraction is cheaper than the division it replaces.
+	div := &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: -3}, Right: &ConstNode{Val: 4}}
+	if WeightedComplexity(r) >= WeightedComplexity(div) {
+		t.Errorf("3/4 weighs %v, not less than (3 / 4) at %v", WeightedComplexity(r), WeightedComplexity(div))
+	}
+
+	for _, tc := range []struct {
+		num, den int64
+		want     string
+	}{
+		{6, 8, "3/4"},
+		{3, -6, "-1/2"},
+		{-8, -4, "2"},
+		{0, 5, "0"},
+		{math.MinInt64, 2, "-4611686018427387904"},
+	} {
+		got, ok := NewRat(tc.num, tc.den)
+		if !ok || got.String() != tc.want {
+			t.Errorf("NewRat(%d, %d) = %v, %v, want %s", tc.num, tc.den, got, ok, tc.want)
+		}
+	}
+	if _, ok := NewRat(1, 0); ok {
+		t.Error("NewRat(1, 0) should fail")
+	}
+	if _, ok := NewRat(math.MinInt64, -1); ok {
+		t.Error("NewRat(MinInt64, -1) should fail: 2^63 leaves int64")
+	}
+}
+
 func TestFactorial(t *testing.T) {
 	// 5! = 120
 	node := &UnaryNode{Op: OpFactorial, Child: &ConstNode{Val: 5}}
@@ -356,6 +399,43 @@ func TestSimplify(t *testing.T) {
 			&UnaryNode{Op: OpDoubleFactorial, Child: &ConstNode{Val: 5}},
 			"15",
 		},
+		{
+			"const fold 1/3 + 1",
+			&BinaryNode{Op: OpAdd,
+				Left:  &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &ConstNode{Val: 3}},
+				Right: &ConstNode{Val: 1}},
+			"4/3",
+		},
+		{
+			"rational fold 1/2 * 2/3",
+			&BinaryNode{Op: OpMul, Left: &RatNode{Num: 1, Den: 2}, Right: &RatNode{Num: 2, Den: 3}},
+			"1/3",
+		},
+		{
+			"rational fold to integer",
+			&BinaryNode{Op: OpAdd, Left: &RatNode{Num: 1, Den: 2}, Right: &RatNode{Num: 1, Den: 2}},
+			"1",
+		},
+		{
+			"negative power folds",
+			&BinaryNode{Op: OpPow, Left: &RatNode{Num: 2, Den: 3}, Right: &ConstNode{Val: -2}},
+			"9/4",
+		},
+		{
+			"neg of rational",
+			&UnaryNode{Op: OpNeg, Child: &RatNode{Num: 1, Den: 2}},
+			"-1/2",
+		},
+		{
+			"floor of rational",
+			&UnaryNode{Op: OpFloor, Child: &RatNode{Num: -7, Den: 2}},
+			"-4",
+		},
+		{
+			"x + (-1/2) = x - 1/2",
+			&BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &RatNode{Num: -1, Den: 2}},
+			"(n - 1/2)",
+		},
 		{
 			"MinInt64 add no crash",
 			&BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: math.MinInt64}},
@@ -378,6 +458,241 @@ func TestSimplify(t *testing.T) {
 	}
 }
 
+func TestSimplifyBigFloat_FoldsRationals(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{`\frac{1}{-13} + 9`, "116/13"},
+		{`n + \frac{2}{4} \cdot 3`, "(3/2 + n)"},
+		{`\sqrt{2} + 9`, "10"}, // irrational: rounded
+	} {
+		node, err := ParseExprLatex(tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := SimplifyBigFloat(node, 128).String(); got != tc.want {
+			t.Errorf("SimplifyBigFloat(%s) = %s, want %s", node, got, tc.want)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"cancel n", `\frac{n}{n \cdot n!}`, "(1 / (n)!)"},
+		{"cancel square", `\frac{n + 1}{n^{2} + 2 \cdot n + 1}`, "(1 / (1 + n))"},
+		{"cancel to constant", `\frac{n \cdot (n + 1)}{n^{2} + n}`, "1"},
+		{"like terms", `n + n + 3 \cdot n`, "(5 * n)"},
+		{"expand product", `(n + 1) \cdot (n - 1)`, "((n)^(2) - 1)"},
+		{"common denominator", `\frac{1}{n} + \frac{1}{n + 1}`, "((1 + (2 * n)) / (n + (n)^(2)))"},
+		{"content", `\frac{2 \cdot n}{4 \cdot n + 2}`, "(n / (1 + (2 * n)))"},
+		{"commutative order", `n + 2^{n}`, "((2)^(n) + n)"},
+		{"canonical children", `(n + 1)! \cdot (1 + n)!`, "(((1 + n))!)^(2)"},
+		{"rational constant", `\frac{1}{2} + \frac{1}{3}`, "5/6"},
+		{"rational coefficient", `\frac{1}{2} n + \frac{n}{3}`, "((5 * n) / 6)"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := ParseExprLatex(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := Canonical(node)
+			if !ok || got.String() != tc.want {
+				t.Errorf("Canonical(%s) = %s, %v, want %s", node, got, ok, tc.want)
+			}
+			for _, n := range []int64{2, 3, 7} {
+				a, _ := node.EvalF64(float64(n))
+				b, _ := got.EvalF64(float64(n))
+				if math.Abs(a-b) > 1e-9*math.Abs(a) {
+					t.Errorf("at n=%d: %s = %v but canonical %s = %v", n, node, a, got, b)
+				}
+			}
+		})
+	}
+
+	if _, ok := Canonical(&BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &VarNode{}}}); ok {
+		t.Error("n/(n-n) should have no canonical form")
+	}
+	if a, b := CanonicalString(&BinaryNode{Op: OpMul, Left: &VarNode{}, Right: &ConstNode{Val: 3}}),
+		CanonicalString(&BinaryNode{Op: OpMul, Left: &ConstNode{Val: 3}, Right: &VarNode{}}); a != b {
+		t.Errorf("n*3 and 3*n canonicalize to %s and %s", a, b)
+	}
+}
+
+// TestCanonical_Overflow checks that a child whose coefficients leave int64
+// is kept as it is, so Simplify neither loses it nor crashes printing it,
+// and that constant folding does not wrap.
+func TestCanonical_Overflow(t *testing.T) {
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	pow := func(l, r ExprNode) ExprNode { return &BinaryNode{Op: OpPow, Left: l, Right: r} }
+	poly12 := pow(&BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpMul, Left: c(100), Right: &VarNode{}}, Right: c(1)}, c(12))
+	if got, ok := Canonical(poly12); ok || got != poly12 {
+		t.Errorf("Canonical(%s) = %v, %v, want the tree itself and false", poly12, got, ok)
+	}
+	for _, node := range []ExprNode{
+		&BinaryNode{Op: OpBinomial, Left: poly12, Right: &VarNode{}},
+		&BinaryNode{Op: OpBinomial, Left: pow(pow(c(4), c(8)), c(5)), Right: &VarNode{}},
+		&UnaryNode{Op: OpSqrt, Child: poly12},
+		pow(pow(c(4), c(8)), c(5)),
+	} {
+		got := Simplify(node)
+		for _, n := range []int64{1, 2} {
+			want, okA := node.Eval(bfInt(n), testPrec)
+			have, okB := got.Eval(bfInt(n), testPrec)
+			if okA != okB || (okA && want.Cmp(have) != 0) {
+				t.Errorf("at n=%d: Simplify(%s) = %s changes the value", n, node, got)
+			}
+		}
+	}
+}
+
+// TestCanonical_Random checks on random trees that the canonical form
+// evaluates to the same values as the tree it came from.
+func TestCanonical_Random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ops := []BinaryOp{OpAdd, OpSub, OpMul, OpDiv, OpPow}
+	var gen func(depth int) ExprNode
+	gen = func(depth int) ExprNode {
+		if depth == 0 || rng.Intn(3) == 0 {
+			if rng.Intn(2) == 0 {
+				return &VarNode{}
+			}
+			return &ConstNode{Val: int64(rng.Intn(9) - 2)}
+		}
+		switch rng.Intn(6) {
+		case 0:
+			return &UnaryNode{Op: OpFactorial, Child: gen(depth - 1)}
+		case 1:
+			return &UnaryNode{Op: OpNeg, Child: gen(depth - 1)}
+		}
+		return &BinaryNode{Op: ops[rng.Intn(len(ops))], Left: gen(depth - 1), Right: gen(depth - 1)}
+	}
+	checked := 0
+	for range 2000 {
+		node := gen(5)
+		canon, ok := Canonical(node)
+		if !ok {
+			continue
+		}
+		for _, n := range []int64{1, 2, 5} {
+			nf := bfInt(n)
+			want, okA := node.Eval(nf, testPrec)
+			got, okB := canon.Eval(nf, testPrec)
+			if !okA || !okB {
+				continue // canonical forms may cancel a pole the tree has
+			}
+			checked++
+			diff := new(big.Float).Sub(want, got)
+			tol := new(big.Float).Abs(want)
+			tol.Mul(tol, big.NewFloat(1e-30))
+			if diff.Abs(diff).Cmp(tol) > 0 && diff.Cmp(big.NewFloat(1e-30)) > 0 {
+				t.Errorf("at n=%d: %s = %s but canonical %s = %s", n, node, want.Text('g', 20), canon, got.Text('g', 20))
+			}
+		}
+	}
+	if checked < 1000 {
+		t.Errorf("only %d random comparisons ran", checked)
+	}
+}
+
+func TestEvalRat(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int64
+		want string
+	}{
+		{`\frac{1}{n!}`, 5, "1/120"},
+		{`\frac{(-1)^{n}}{2 \cdot n + 1}`, 3, "-1/7"},
+		{`\binom{n}{2} \cdot n^{-2}`, 4, "3/8"},
+		{`\lfloor \frac{n}{3} \rfloor - \lceil \frac{-n}{3} \rceil`, 7, "4"},
+	}
+	for _, tc := range tests {
+		node, err := ParseExprLatex(tc.in)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", tc.in, err)
+		}
+		if !IsRational(node) {
+			t.Errorf("IsRational(%s) = false", node)
+		}
+		got, ok := node.EvalRat(big.NewInt(tc.n), testPrec)
+		if !ok || got.RatString() != tc.want {
+			t.Errorf("%s at n=%d: EvalRat = %v, %v, want %s", node, tc.n, got, ok, tc.want)
+		}
+	}
+
+	for _, in := range []string{`\sqrt{n}`, `\ln(n)`, `2^{\frac{1}{n}}`} {
+		node, err := ParseExprLatex(in)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", in, err)
+		}
+		if IsRational(node) {
+			t.Errorf("IsRational(%s) = true", node)
+		}
+	}
+
+	div := &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &ConstNode{Val: 2}}}
+	if _, ok := div.EvalRat(big.NewInt(2), testPrec); ok {
+		t.Error("EvalRat should fail on division by zero")
+	}
+}
+
+func TestEvalRat_AgreesWithEval(t *testing.T) {
+	v := &VarNode{}
+	c := func(k int64) ExprNode { return &ConstNode{Val: k} }
+	div := func(a, b ExprNode) ExprNode { return &BinaryNode{Op: OpDiv, Left: a, Right: b} }
+	fixed := []ExprNode{
+		// H(27, (n/3)/(3/9)) and fib((7/n)*n): the integer arguments are
+		// exact rationals but need not be exact in big.Float.
+		&BinaryNode{Op: OpHarmonicGen, Left: c(27), Right: div(div(v, c(3)), div(c(3), c(9)))},
+		&UnaryNode{Op: OpFibonacci, Child: &BinaryNode{Op: OpMul, Left: div(c(7), v), Right: v}},
+	}
+
+	rng := rand.New(rand.NewSource(3))
+	bops := []BinaryOp{OpAdd, OpSub, OpMul, OpDiv, OpPow, OpBinomial, OpHarmonicGen, OpPochhammer}
+	uops := []UnaryOp{OpNeg, OpFactorial, OpDoubleFactorial, OpFibonacci, OpHarmonic, OpAltSign, OpCatalan, OpLucas, OpBernoulli, OpFloor}
+	var gen func(depth int) ExprNode
+	gen = func(depth int) ExprNode {
+		if depth == 0 || rng.Intn(3) == 0 {
+			switch rng.Intn(3) {
+			case 0:
+				return &VarNode{}
+			case 1:
+				return &RatNode{Num: int64(rng.Intn(9) + 1), Den: int64(rng.Intn(8) + 2)}
+			}
+			return &ConstNode{Val: int64(rng.Intn(10) - 2)}
+		}
+		if rng.Intn(3) == 0 {
+			return &UnaryNode{Op: uops[rng.Intn(len(uops))], Child: gen(depth - 1)}
+		}
+		return &BinaryNode{Op: bops[rng.Intn(len(bops))], Left: gen(depth - 1), Right: gen(depth - 1)}
+	}
+	nodes := fixed
+	for range 5000 {
+		if node := gen(4); IsRational(node) {
+			nodes = append(nodes, node)
+		}
+	}
+
+	valid := 0
+	for _, node := range nodes {
+		for n := int64(1); n <= 6; n++ {
+			_, okF := node.Eval(bfInt(n), testPrec)
+			_, okR := node.EvalRat(big.NewInt(n), testPrec)
+			if okF != okR {
+				t.Errorf("at n=%d: %s: Eval ok=%v, EvalRat ok=%v", n, node, okF, okR)
+			}
+			if okF {
+				valid++
+			}
+		}
+	}
+	if valid < 1000 {
+		t.Errorf("only %d valid evaluations compared", valid)
+	}
+}
+
 func TestFloorCeil(t *testing.T) {
 	// floor(3.7) = 3
 	node := &UnaryNode{Op: OpFloor, Child: &BinaryNode{
@@ -6,6 +6,7 @@ import "math/big"
 type ExprNode interface {
 	Eval(n *big.Float, prec uint) (*big.Float, bool)
 	EvalF64(n float64) (float64, bool)
+	EvalRat(n *big.Int, prec uint) (*big.Rat, bool)
 	String() string
 	LaTeX() string
 	Clone() ExprNode
@@ -29,6 +30,10 @@ const (
 	OpCeil
 	OpAbs
 	OpSqrt
+	OpHarmonic  // H_n
+	OpBernoulli // B_n
+	OpCatalan   // C_n
+	OpLucas     // L_n
 )
 
 // BinaryOp identifies a binary operation.
@@ -40,7 +45,9 @@ const (
 	OpMul
 	OpDiv
 	OpPow
-	OpBinomial // C(a, b)
+	OpBinomial    // C(a, b)
+	OpHarmonicGen // H_a^(b)
+	OpPochhammer  // (a)_b, the rising factorial
 )
 
 // VarNode represents the variable n.
@@ -51,6 +58,33 @@ type ConstNode struct {
 	Val int64
 }
 
+// RatNode represents a rational constant Num/Den that is not an integer:
+// Den > 1 and the fraction is in lowest terms. NewRat builds one.
+type RatNode struct {
+	Num, Den int64
+}
+
+// NewRat returns the constant num/den in lowest terms: a ConstNode when it
+// is an integer, a RatNode otherwise. ok is false if den is zero or the
+// reduced fraction leaves int64.
+func NewRat(num, den int64) (node ExprNode, ok bool) {
+	if den == 0 {
+		return nil, false
+	}
+	return ratConst(big.NewRat(num, den))
+}
+
+// ratConst returns r as a ConstNode or RatNode, if it fits in int64.
+func ratConst(r *big.Rat) (ExprNode, bool) {
+	if !r.Num().IsInt64() || !r.Denom().IsInt64() {
+		return nil, false
+	}
+	if r.IsInt() {
+		return &ConstNode{Val: r.Num().Int64()}, true
+	}
+	return &RatNode{Num: r.Num().Int64(), Den: r.Denom().Int64()}, true
+}
+
 // UnaryNode applies a unary operation to a child expression.
 type UnaryNode struct {
 	Op    UnaryOp
@@ -256,6 +256,14 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		if err := p.Consume("}"); err != nil {
 			return nil, err
 		}
+		// \frac{p}{q} of integers is a rational constant.
+		if pc, ok := num.(*ConstNode); ok {
+			if qc, ok := den.(*ConstNode); ok {
+				if c, ok := NewRat(pc.Val, qc.Val); ok {
+					return c, nil
+				}
+			}
+		}
 		return &BinaryNode{Op: OpDiv, Left: num, Right: den}, nil
 	}
 
@@ -374,8 +382,8 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		return &UnaryNode{Op: OpAltSign, Child: child}, nil
 	}
 
-	// F_{...} → OpFibonacci
-	if p.HasPrefix(`F_{`) {
+	// F_{...}, H_{...}, B_{...}, C_{...}, L_{...} → sequences
+	if op, ok := subscriptOps[p.peek()]; ok && p.HasPrefix(p.src[p.pos:p.pos+1]+"_{") {
 		p.pos += 3
 		child, err := p.ParseExpr()
 		if err != nil {
@@ -384,7 +392,19 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		if err := p.Consume("}"); err != nil {
 			return nil, err
 		}
-		return &UnaryNode{Op: OpFibonacci, Child: child}, nil
+		// H_{...}^{(...)} → OpHarmonicGen
+		if op == OpHarmonic && p.HasPrefix("^{(") {
+			p.pos += 3
+			order, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.Consume(")}"); err != nil {
+				return nil, err
+			}
+			return &BinaryNode{Op: OpHarmonicGen, Left: child, Right: order}, nil
+		}
+		return &UnaryNode{Op: op, Child: child}, nil
 	}
 
 	// {...} → brace grouping
@@ -410,6 +430,18 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		if err := p.Consume(")"); err != nil {
 			return nil, err
 		}
+		// (...)_{...} → OpPochhammer
+		if p.HasPrefix("_{") {
+			p.pos += 2
+			count, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.Consume("}"); err != nil {
+				return nil, err
+			}
+			return &BinaryNode{Op: OpPochhammer, Left: node, Right: count}, nil
+		}
 		return node, nil
 	}
 
@@ -435,6 +467,16 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 	return nil, fmt.Errorf("unexpected token at pos %d: %q", p.pos, got)
 }
 
+// subscriptOps maps the letter of a subscripted sequence, as in F_{n}, to
+// its operation.
+var subscriptOps = map[byte]UnaryOp{
+	'F': OpFibonacci,
+	'H': OpHarmonic,
+	'B': OpBernoulli,
+	'C': OpCatalan,
+	'L': OpLucas,
+}
+
 // parseFuncArg parses a function argument in {(expr)}, (expr), or {expr} form.
 func (p *LatexParser) parseFuncArg() (ExprNode, error) {
 	// Engine format: {(expr)}
@@ -486,7 +528,7 @@ func (p *LatexParser) canStartImplicitMul() bool {
 	if unicode.IsDigit(rune(c)) || c == 'n' || c == '(' || c == '{' {
 		return true
 	}
-	if c == 'F' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '_' {
+	if _, ok := subscriptOps[c]; ok && p.pos+1 < len(p.src) && p.src[p.pos+1] == '_' {
 		return true
 	}
 	if c == '\\' {
@@ -13,6 +13,8 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 		{"var", &VarNode{}},
 		{"const", &ConstNode{Val: 42}},
 		{"negative const", &ConstNode{Val: -7}},
+		{"rational", &RatNode{Num: 3, Den: 4}},
+		{"negative rational", &RatNode{Num: -1, Den: 2}},
 
 		// All unary ops
 		{"neg", &UnaryNode{Op: OpNeg, Child: &VarNode{}}},
@@ -27,6 +29,10 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 		{"ceil", &UnaryNode{Op: OpCeil, Child: &VarNode{}}},
 		{"abs", &UnaryNode{Op: OpAbs, Child: &VarNode{}}},
 		{"sqrt", &UnaryNode{Op: OpSqrt, Child: &VarNode{}}},
+		{"harmonic", &UnaryNode{Op: OpHarmonic, Child: &VarNode{}}},
+		{"bernoulli", &UnaryNode{Op: OpBernoulli, Child: &VarNode{}}},
+		{"catalan", &UnaryNode{Op: OpCatalan, Child: &VarNode{}}},
+		{"lucas", &UnaryNode{Op: OpLucas, Child: &VarNode{}}},
 
 		// All binary ops
 		{"add", &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
@@ -35,6 +41,12 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 		{"div", &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
 		{"pow", &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
 		{"binomial", &BinaryNode{Op: OpBinomial, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
+		{"generalized harmonic", &BinaryNode{Op: OpHarmonicGen, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
+		{"pochhammer", &BinaryNode{Op: OpPochhammer, Left: &ConstNode{Val: 3}, Right: &VarNode{}}},
+		{"rational pochhammer", &BinaryNode{Op: OpPochhammer, Left: &RatNode{Num: 1, Den: 3}, Right: &VarNode{}}},
+		{"pochhammer of sum", &BinaryNode{Op: OpPochhammer,
+			Left:  &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}},
+			Right: &VarNode{}}},
 
 		// Nested expressions (3+ levels)
 		{"nested add-mul", &BinaryNode{
@@ -15,15 +15,21 @@ var unaryOpNames = map[UnaryOp]string{
 	OpCeil:             "ceil",
 	OpAbs:              "abs",
 	OpSqrt:             "sqrt",
+	OpHarmonic:         "H",
+	OpBernoulli:        "B",
+	OpCatalan:          "catalan",
+	OpLucas:            "lucas",
 }
 
 var binaryOpSymbols = map[BinaryOp]string{
-	OpAdd:      "+",
-	OpSub:      "-",
-	OpMul:      "*",
-	OpDiv:      "/",
-	OpPow:      "^",
-	OpBinomial: "C",
+	OpAdd:         "+",
+	OpSub:         "-",
+	OpMul:         "*",
+	OpDiv:         "/",
+	OpPow:         "^",
+	OpBinomial:    "C",
+	OpHarmonicGen: "H",
+	OpPochhammer:  "poch",
 }
 
 // String methods
@@ -36,6 +42,10 @@ func (c *ConstNode) String() string {
 	return fmt.Sprintf("%d", c.Val)
 }
 
+func (r *RatNode) String() string {
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
+
 func (u *UnaryNode) String() string {
 	child := u.Child.String()
 	switch u.Op {
@@ -58,8 +68,8 @@ func (b *BinaryNode) String() string {
 	right := b.Right.String()
 	sym := binaryOpSymbols[b.Op]
 	switch b.Op {
-	case OpBinomial:
-		return fmt.Sprintf("C(%s, %s)", left, right)
+	case OpBinomial, OpHarmonicGen, OpPochhammer:
+		return fmt.Sprintf("%s(%s, %s)", sym, left, right)
 	case OpPow:
 		return fmt.Sprintf("(%s)^(%s)", left, right)
 	default:
@@ -77,6 +87,10 @@ func (c *ConstNode) LaTeX() string {
 	return fmt.Sprintf("%d", c.Val)
 }
 
+func (r *RatNode) LaTeX() string {
+	return fmt.Sprintf("\\frac{%d}{%d}", r.Num, r.Den)
+}
+
 func (u *UnaryNode) LaTeX() string {
 	child := u.Child.LaTeX()
 	switch u.Op {
@@ -104,6 +118,14 @@ func (u *UnaryNode) LaTeX() string {
 		return fmt.Sprintf("|%s|", child)
 	case OpSqrt:
 		return fmt.Sprintf("\\sqrt{%s}", child)
+	case OpHarmonic:
+		return fmt.Sprintf("H_{%s}", child)
+	case OpBernoulli:
+		return fmt.Sprintf("B_{%s}", child)
+	case OpCatalan:
+		return fmt.Sprintf("C_{%s}", child)
+	case OpLucas:
+		return fmt.Sprintf("L_{%s}", child)
 	default:
 		return child
 	}
@@ -125,6 +147,10 @@ func (b *BinaryNode) LaTeX() string {
 		return fmt.Sprintf("{%s}^{%s}", left, right)
 	case OpBinomial:
 		return fmt.Sprintf("\\binom{%s}{%s}", left, right)
+	case OpHarmonicGen:
+		return fmt.Sprintf("H_{%s}^{(%s)}", left, right)
+	case OpPochhammer:
+		return fmt.Sprintf("(%s)_{%s}", left, right)
 	default:
 		return ""
 	}
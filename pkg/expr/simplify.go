@@ -10,8 +10,18 @@ import (
 const maxRecurseDepth = 100
 
 // Simplify applies rewrite rules to reduce an expression tree.
-// It repeatedly applies rules until no further changes occur.
+// It repeatedly applies rules until no further changes occur, then takes the
+// canonical form (see Canonical) unless that is more complex, so like terms
+// collect and common polynomial factors cancel.
 func Simplify(node ExprNode) ExprNode {
+	node = simplifyLocal(node)
+	if c, ok := Canonical(node); ok && WeightedComplexity(c) <= WeightedComplexity(node) {
+		node = simplifyLocal(c)
+	}
+	return node
+}
+
+func simplifyLocal(node ExprNode) ExprNode {
 	for i := 0; i < 20; i++ { // cap iterations
 		next := simplifyD(node, 0)
 		if next.String() == node.String() {
@@ -28,7 +38,7 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 	}
 
 	switch n := node.(type) {
-	case *VarNode, *ConstNode:
+	case *VarNode, *ConstNode, *RatNode:
 		return node
 
 	case *UnaryNode:
@@ -46,6 +56,9 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 			if c, ok := child.(*ConstNode); ok && (c.Val > math.MinInt64) {
 				return &ConstNode{Val: -c.Val}
 			}
+			if r, ok := child.(*RatNode); ok && r.Num > math.MinInt64 {
+				return &RatNode{Num: -r.Num, Den: r.Den}
+			}
 		}
 
 		// Factorial of small constants: fold entirely
@@ -70,6 +83,33 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 			}
 		}
 
+		// Catalan and Lucas numbers of small constants
+		if n.Op == OpCatalan || n.Op == OpLucas {
+			if c, ok := child.(*ConstNode); ok && c.Val >= 0 && c.Val <= 20 {
+				if n.Op == OpCatalan {
+					return &ConstNode{Val: catalanInt(c.Val).Int64()}
+				}
+				return &ConstNode{Val: lucasInt(c.Val).Int64()}
+			}
+		}
+
+		// H_0 = 0, H_1 = 1
+		if n.Op == OpHarmonic {
+			if c, ok := child.(*ConstNode); ok && (c.Val == 0 || c.Val == 1) {
+				return c
+			}
+		}
+
+		// B_0 = 1, and odd Bernoulli numbers past B_1 vanish
+		if n.Op == OpBernoulli {
+			if c, ok := child.(*ConstNode); ok && c.Val == 0 {
+				return &ConstNode{Val: 1}
+			}
+			if c, ok := child.(*ConstNode); ok && c.Val >= 3 && c.Val%2 == 1 {
+				return &ConstNode{Val: 0}
+			}
+		}
+
 		// AltSign constant folding
 		if n.Op == OpAltSign {
 			if c, ok := child.(*ConstNode); ok && c.Val >= 0 {
@@ -88,6 +128,22 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 				}
 				return c
 			}
+			if r, ok := child.(*RatNode); ok && r.Num < 0 && r.Num > math.MinInt64 {
+				return &RatNode{Num: -r.Num, Den: r.Den}
+			}
+		}
+
+		// Floor and ceil of a rational constant
+		if n.Op == OpFloor || n.Op == OpCeil {
+			if r, ok := child.(*RatNode); ok {
+				v := r.Num / r.Den // truncates toward zero
+				if n.Op == OpFloor && r.Num < 0 {
+					v--
+				} else if n.Op == OpCeil && r.Num > 0 {
+					v++
+				}
+				return &ConstNode{Val: v}
+			}
 		}
 
 		// Sqrt of perfect square constant: sqrt(k²) = k
@@ -109,10 +165,13 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 		lc, lok := left.(*ConstNode)
 		rc, rok := right.(*ConstNode)
 
-		// Constant folding for basic ops
-		if lok && rok {
-			if result, ok := foldConstants(n.Op, lc.Val, rc.Val); ok {
-				return &ConstNode{Val: result}
+		// Constant folding, exact and only while the result fits a ConstNode
+		// or RatNode: 1/3 + 1 = 4/3, 2^(-1) = 1/2
+		if a, ok := ratValue(left); ok {
+			if b, ok := ratValue(right); ok {
+				if folded, ok := foldRats(n.Op, a, b); ok {
+					return folded
+				}
 			}
 		}
 
@@ -130,6 +189,10 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 			if rok && rc.Val < 0 && -rc.Val > 0 {
 				return simplifyD(&BinaryNode{Op: OpSub, Left: left, Right: &ConstNode{Val: -rc.Val}}, depth+1)
 			}
+			// x + (-p/q) = x - p/q
+			if rr, ok := right.(*RatNode); ok && rr.Num < 0 && -rr.Num > 0 {
+				return simplifyD(&BinaryNode{Op: OpSub, Left: left, Right: &RatNode{Num: -rr.Num, Den: rr.Den}}, depth+1)
+			}
 			// x + neg(y) = x - y
 			if ru, ok := right.(*UnaryNode); ok && ru.Op == OpNeg {
 				return simplifyD(&BinaryNode{Op: OpSub, Left: left, Right: ru.Child}, depth+1)
@@ -148,6 +211,10 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 			if rok && rc.Val < 0 && -rc.Val > 0 {
 				return simplifyD(&BinaryNode{Op: OpAdd, Left: left, Right: &ConstNode{Val: -rc.Val}}, depth+1)
 			}
+			// x - (-p/q) = x + p/q
+			if rr, ok := right.(*RatNode); ok && rr.Num < 0 && -rr.Num > 0 {
+				return simplifyD(&BinaryNode{Op: OpAdd, Left: left, Right: &RatNode{Num: -rr.Num, Den: rr.Den}}, depth+1)
+			}
 			// x - neg(y) = x + y
 			if ru, ok := right.(*UnaryNode); ok && ru.Op == OpNeg {
 				return simplifyD(&BinaryNode{Op: OpAdd, Left: left, Right: ru.Child}, depth+1)
@@ -213,6 +280,26 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 			if lok && lc.Val == 1 {
 				return &ConstNode{Val: 1}
 			}
+
+		case OpHarmonicGen:
+			// H_x^(1) = H_x
+			if rok && rc.Val == 1 {
+				return &UnaryNode{Op: OpHarmonic, Child: left}
+			}
+
+		case OpPochhammer:
+			// (x)_0 = 1
+			if rok && rc.Val == 0 {
+				return &ConstNode{Val: 1}
+			}
+			// (x)_1 = x
+			if rok && rc.Val == 1 {
+				return left
+			}
+			// (1)_x = x!
+			if lok && lc.Val == 1 {
+				return &UnaryNode{Op: OpFactorial, Child: right}
+			}
 		}
 
 		// Canonicalize commutative ops: sort children so equivalent
@@ -230,49 +317,52 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 	}
 }
 
-func foldConstants(op BinaryOp, a, b int64) (int64, bool) {
+// ratValue returns the value of a ConstNode or RatNode.
+func ratValue(node ExprNode) (*big.Rat, bool) {
+	switch c := node.(type) {
+	case *ConstNode:
+		return big.NewRat(c.Val, 1), true
+	case *RatNode:
+		return big.NewRat(c.Num, c.Den), true
+	}
+	return nil, false
+}
+
+// foldRats folds a op b exactly, for integer powers up to 20, if the result
+// fits a ConstNode or RatNode.
+func foldRats(op BinaryOp, a, b *big.Rat) (ExprNode, bool) {
+	r := new(big.Rat)
 	switch op {
 	case OpAdd:
-		return a + b, true
+		r.Add(a, b)
 	case OpSub:
-		return a - b, true
+		r.Sub(a, b)
 	case OpMul:
-		// Check for overflow
-		if a != 0 && b != 0 {
-			result := a * b
-			if result/a != b {
-				return 0, false
-			}
-			return result, true
-		}
-		return 0, true
+		r.Mul(a, b)
 	case OpDiv:
-		if b == 0 {
-			return 0, false
-		}
-		if a%b != 0 {
-			return 0, false // don't fold if not exact
+		if b.Sign() == 0 {
+			return nil, false
 		}
-		return a / b, true
+		r.Quo(a, b)
 	case OpPow:
-		if b < 0 {
-			return 0, false
+		k, ok := ratInt64(b)
+		if !ok || k < -20 || k > 20 || (k < 0 && a.Sign() == 0) {
+			return nil, false
 		}
-		if b > 20 {
-			return 0, false
+		num := new(big.Int).Exp(a.Num(), big.NewInt(max(k, -k)), nil)
+		den := new(big.Int).Exp(a.Denom(), big.NewInt(max(k, -k)), nil)
+		if k < 0 {
+			num, den = den, num
 		}
-		result := int64(1)
-		base := a
-		for i := int64(0); i < b; i++ {
-			result *= base
-		}
-		return result, true
+		r.SetFrac(num, den)
 	default:
-		return 0, false
+		return nil, false
 	}
+	return ratConst(r)
 }
 
-// SimplifyBigFloat evaluates constant subtrees and replaces them with ConstNodes.
+// SimplifyBigFloat evaluates constant subtrees and replaces them with ConstNodes
+// or, when they are rational, RatNodes.
 // This recursively finds subtrees with no VarNode and evaluates them.
 func SimplifyBigFloat(node ExprNode, prec uint) ExprNode {
 	node = Simplify(node)
@@ -291,15 +381,21 @@ func foldConstantSubtreesD(node ExprNode, prec uint, depth int) ExprNode {
 	}
 
 	if !containsVar(node) {
+		// Rational constant subtree (e.g. 1/(-13) + 9 = 116/13): fold exactly.
+		if IsRational(node) {
+			if r, ok := node.EvalRat(new(big.Int), prec); ok {
+				if c, ok := ratConst(r); ok {
+					return c
+				}
+			}
+		}
 		dummyN := new(big.Float).SetPrec(prec).SetInt64(0)
 		if val, ok := node.Eval(dummyN, prec); ok {
 			if iv, ok := toInt64Approx(val); ok {
 				return &ConstNode{Val: iv}
 			}
-			// Non-integer constant subtree (e.g. 1/(-13) + 9 ≈ 8.923):
+			// Irrational constant subtree (e.g. sqrt(2) + 9 ≈ 10.414):
 			// round to nearest integer so the GA can work with a clean constant.
-			// TODO: support rational constants (e.g. RatNode{Num, Den}) so we
-			// can fold 1/3 + 1 to 4/3 instead of rounding to 1.
 			if iv, ok := roundToInt64(val); ok {
 				return &ConstNode{Val: iv}
 			}
@@ -336,7 +432,7 @@ func containsVarD(node ExprNode, depth int) bool {
 	switch n := node.(type) {
 	case *VarNode:
 		return true
-	case *ConstNode:
+	case *ConstNode, *RatNode:
 		return false
 	case *UnaryNode:
 		return containsVarD(n.Child, depth+1)
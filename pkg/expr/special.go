@@ -0,0 +1,274 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// Special sequences: harmonic numbers H_n and H_n^(k) = sum_{i<=n} 1/i^k,
+// Bernoulli numbers B_n (with B_1 = -1/2), Catalan numbers C_n, Lucas
+// numbers L_n and the rising factorial (a)_n = a (a+1) ... (a+n-1). Like
+// factorial they are defined at integer n in [0, maxComputeInput] and
+// memoized exactly; all but (a)_n for non-integer a are rational.
+
+// maxHarmonicOrder caps k in H_n^(k). The cached denominators grow like
+// lcm(1..n)^k, and past the first few orders H_n^(k) is 1 + 2^-k to within
+// a few digits anyway.
+const maxHarmonicOrder = 8
+
+var (
+	catalanCache   = &mathCache[*big.Int]{}
+	lucasCache     = &mathCache[*big.Int]{}
+	bernoulliCache = &mathCache[*big.Rat]{}
+	harmonicCaches [maxHarmonicOrder + 1]mathCache[*big.Rat] // by order k
+)
+
+// catalanInt returns C_n = C(2n, n)/(n+1) for 0 <= n <= maxComputeInput.
+func catalanInt(n int64) *big.Int {
+	return catalanCache.extend(n, func(i int64, v []*big.Int) *big.Int {
+		if i == 0 {
+			return big.NewInt(1)
+		}
+		// C_i = C_{i-1} 2(2i-1)/(i+1)
+		c := new(big.Int).Mul(v[i-1], big.NewInt(2*(2*i-1)))
+		return c.Quo(c, big.NewInt(i+1))
+	})
+}
+
+// lucasInt returns L_n for 0 <= n <= maxComputeInput.
+func lucasInt(n int64) *big.Int {
+	return lucasCache.extend(n, func(i int64, v []*big.Int) *big.Int {
+		switch i {
+		case 0:
+			return big.NewInt(2)
+		case 1:
+			return big.NewInt(1)
+		}
+		return new(big.Int).Add(v[i-1], v[i-2])
+	})
+}
+
+// bernoulliRat returns B_n for 0 <= n <= maxComputeInput, from
+// B_m = -1/(m+1) sum_{k<m} C(m+1, k) B_k. Odd terms past B_1 vanish, so the
+// sum runs over even k.
+func bernoulliRat(n int64) *big.Rat {
+	return bernoulliCache.extend(n, func(i int64, v []*big.Rat) *big.Rat {
+		switch {
+		case i == 0:
+			return big.NewRat(1, 1)
+		case i == 1:
+			return big.NewRat(-1, 2)
+		case i%2 == 1:
+			return new(big.Rat)
+		}
+		// C(i+1, 0) B_0 + C(i+1, 1) B_1 = 1 - (i+1)/2
+		sum := big.NewRat(1-i, 2)
+		binom := big.NewInt(i + 1) // C(i+1, k), for k = 1 to start
+		term := new(big.Rat)
+		for k := int64(2); k < i; k += 2 {
+			// Step C(i+1, k-1) to C(i+1, k).
+			binom.Mul(binom, big.NewInt(i+2-k)).Quo(binom, big.NewInt(k))
+			term.SetInt(binom)
+			sum.Add(sum, term.Mul(term, v[k]))
+			binom.Mul(binom, big.NewInt(i+1-k)).Quo(binom, big.NewInt(k+1))
+		}
+		return sum.Quo(sum, big.NewRat(-(i+1), 1))
+	})
+}
+
+// harmonicRat returns H_n^(k) for 0 <= n <= maxComputeInput and
+// 1 <= k <= maxHarmonicOrder.
+func harmonicRat(n, k int64) *big.Rat {
+	return harmonicCaches[k].extend(n, func(i int64, v []*big.Rat) *big.Rat {
+		if i == 0 {
+			return new(big.Rat)
+		}
+		inv := new(big.Int).Exp(big.NewInt(i), big.NewInt(k), nil)
+		h := new(big.Rat).SetFrac(big.NewInt(1), inv)
+		return h.Add(h, v[i-1])
+	})
+}
+
+// risingInt returns b (b+step) ... (b+step(n-1)), taking the product from
+// the factorial or double factorial caches when its factors are positive.
+func risingInt(b, step, n int64) *big.Int {
+	if n == 0 {
+		return big.NewInt(1)
+	}
+	last := b + step*(n-1)
+	if b <= 0 {
+		p := big.NewInt(1)
+		for f := b; f <= last; f += step {
+			p.Mul(p, big.NewInt(f))
+		}
+		return p
+	}
+	if step == 1 {
+		p := new(big.Int).Set(factorialInt(last))
+		return p.Quo(p, factorialInt(b-1))
+	}
+	p := new(big.Int).Set(doubleFactorialInt(last))
+	if b > 2 {
+		p.Quo(p, doubleFactorialInt(b-2))
+	}
+	return p
+}
+
+// pochhammerRat returns (a)_n for rational a and 0 <= n <= maxComputeInput.
+// Integer and half-integer a come from the factorial caches; other a need a
+// product of n factors.
+func pochhammerRat(a *big.Rat, n int64) (*big.Rat, bool) {
+	if n < 0 || n > maxComputeInput {
+		return nil, false
+	}
+	twice := new(big.Rat).Mul(a, big.NewRat(2, 1))
+	if b, ok := ratInt64(twice); ok && b >= -2*maxComputeInput && b <= 2*maxComputeInput {
+		if b%2 == 0 {
+			return new(big.Rat).SetInt(risingInt(b/2, 1, n)), true
+		}
+		// (b/2)_n = b (b+2) ... (b+2n-2) / 2^n
+		den := new(big.Int).Lsh(big.NewInt(1), uint(n))
+		return new(big.Rat).SetFrac(risingInt(b, 2, n), den), true
+	}
+	p := big.NewRat(1, 1)
+	f := new(big.Rat).Set(a)
+	for range n {
+		p.Mul(p, f)
+		f.Add(f, big.NewRat(1, 1))
+	}
+	return p, true
+}
+
+func bigHarmonic(nf, kf *big.Float, prec uint) (*big.Float, bool) {
+	n, ok := toInt64(nf)
+	if !ok || n < 0 || n > maxComputeInput {
+		return nil, false
+	}
+	k, ok := toInt64(kf)
+	if !ok || k < 1 || k > maxHarmonicOrder {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).SetRat(harmonicRat(n, k)), true
+}
+
+func bigBernoulli(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok || iv < 0 || iv > maxComputeInput {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).SetRat(bernoulliRat(iv)), true
+}
+
+func bigCatalan(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok || iv < 0 || iv > maxComputeInput {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).SetInt(catalanInt(iv)), true
+}
+
+func bigLucas(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok || iv < 0 || iv > maxComputeInput {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).SetInt(lucasInt(iv)), true
+}
+
+// bigPochhammer returns (a)_n. A big.Float is a dyadic rational, so
+// integer and half-integer a are exact; other a are multiplied out at prec.
+func bigPochhammer(af, nf *big.Float, prec uint) (*big.Float, bool) {
+	n, ok := toInt64(nf)
+	if !ok || n < 0 || n > maxComputeInput || af.IsInf() {
+		return nil, false
+	}
+	if twice := new(big.Float).SetMantExp(af, 1); twice.IsInt() {
+		a, _ := af.Rat(nil)
+		p, ok := pochhammerRat(a, n)
+		if !ok {
+			return nil, false
+		}
+		return new(big.Float).SetPrec(prec).SetRat(p), true
+	}
+	wp := prec + 32
+	p := new(big.Float).SetPrec(wp).SetInt64(1)
+	f := new(big.Float).SetPrec(wp).Set(af)
+	for range n {
+		p.Mul(p, f)
+		f.Add(f, bigOne)
+	}
+	return p.SetPrec(prec), true
+}
+
+// Float64 versions: tables up to where the sequences overflow.
+var (
+	catalanF64  [520]float64  // C_519 is the last finite float64
+	lucasF64    [1475]float64 // L_1474 is the last finite float64
+	harmonicF64 [maxHarmonicOrder + 1][maxComputeInput + 1]float64
+
+	// bernoulliF64 is filled on first use: B_n needs O(n^2) rational work.
+	bernoulliF64 = sync.OnceValue(func() []float64 {
+		var b []float64
+		for n := int64(0); ; n++ {
+			f, _ := bernoulliRat(n).Float64()
+			if math.IsInf(f, 0) {
+				return b
+			}
+			b = append(b, f)
+		}
+	})
+)
+
+func init() {
+	// Rounded from the exact values: float64 recurrences accumulate rounding.
+	for i := range catalanF64 {
+		catalanF64[i], _ = new(big.Float).SetInt(catalanInt(int64(i))).Float64()
+	}
+	for i := range lucasF64 {
+		lucasF64[i], _ = new(big.Float).SetInt(lucasInt(int64(i))).Float64()
+	}
+
+	for k := 1; k <= maxHarmonicOrder; k++ {
+		for i := 1; i <= maxComputeInput; i++ {
+			harmonicF64[k][i] = harmonicF64[k][i-1] + math.Pow(float64(i), -float64(k))
+		}
+	}
+}
+
+// seqIndexF64 converts x to an index into a table of length n.
+func seqIndexF64(x float64, n int) (int, bool) {
+	iv := int(x)
+	if x != float64(iv) || iv < 0 || iv >= n {
+		return 0, false
+	}
+	return iv, true
+}
+
+func harmonicF64At(nf, kf float64) (float64, bool) {
+	n, ok := seqIndexF64(nf, maxComputeInput+1)
+	if !ok {
+		return 0, false
+	}
+	k, ok := seqIndexF64(kf, maxHarmonicOrder+1)
+	if !ok || k < 1 {
+		return 0, false
+	}
+	return harmonicF64[k][n], true
+}
+
+// pochhammerF64 multiplies out (a)_n in float64.
+func pochhammerF64(a, nf float64) (float64, bool) {
+	n, ok := seqIndexF64(nf, maxComputeInput+1)
+	if !ok || math.IsInf(a, 0) || math.IsNaN(a) {
+		return 0, false
+	}
+	p := 1.0
+	for i := range n {
+		p *= a + float64(i)
+		if math.IsInf(p, 0) {
+			return 0, false
+		}
+	}
+	return p, true
+}
@@ -0,0 +1,150 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestSpecialSequences_KnownValues(t *testing.T) {
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	half := &BinaryNode{Op: OpDiv, Left: c(1), Right: c(2)}
+	negHalf := &BinaryNode{Op: OpDiv, Left: c(-3), Right: c(2)}
+	third := &BinaryNode{Op: OpDiv, Left: c(1), Right: c(3)}
+
+	tests := []struct {
+		name string
+		node ExprNode
+		want string // exact rational
+	}{
+		{"H_4", &UnaryNode{Op: OpHarmonic, Child: c(4)}, "25/12"},
+		{"H_0", &UnaryNode{Op: OpHarmonic, Child: c(0)}, "0"},
+		{"H_3^(2)", &BinaryNode{Op: OpHarmonicGen, Left: c(3), Right: c(2)}, "49/36"},
+		{"B_0", &UnaryNode{Op: OpBernoulli, Child: c(0)}, "1"},
+		{"B_1", &UnaryNode{Op: OpBernoulli, Child: c(1)}, "-1/2"},
+		{"B_2", &UnaryNode{Op: OpBernoulli, Child: c(2)}, "1/6"},
+		{"B_7", &UnaryNode{Op: OpBernoulli, Child: c(7)}, "0"},
+		{"B_12", &UnaryNode{Op: OpBernoulli, Child: c(12)}, "-691/2730"},
+		{"B_20", &UnaryNode{Op: OpBernoulli, Child: c(20)}, "-174611/330"},
+		{"C_10", &UnaryNode{Op: OpCatalan, Child: c(10)}, "16796"},
+		{"L_0", &UnaryNode{Op: OpLucas, Child: c(0)}, "2"},
+		{"L_10", &UnaryNode{Op: OpLucas, Child: c(10)}, "123"},
+		{"(3)_4", &BinaryNode{Op: OpPochhammer, Left: c(3), Right: c(4)}, "360"},
+		{"(-2)_3", &BinaryNode{Op: OpPochhammer, Left: c(-2), Right: c(3)}, "0"},
+		{"(-3)_2", &BinaryNode{Op: OpPochhammer, Left: c(-3), Right: c(2)}, "6"},
+		{"(1/2)_3", &BinaryNode{Op: OpPochhammer, Left: half, Right: c(3)}, "15/8"},
+		{"(-3/2)_3", &BinaryNode{Op: OpPochhammer, Left: negHalf, Right: c(3)}, "3/8"},
+		{"(1/3)_2", &BinaryNode{Op: OpPochhammer, Left: third, Right: c(2)}, "4/9"},
+		{"(x)_0", &BinaryNode{Op: OpPochhammer, Left: third, Right: c(0)}, "1"},
+	}
+	const prec = 256
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, _ := new(big.Rat).SetString(tt.want)
+
+			r, ok := tt.node.EvalRat(big.NewInt(0), prec)
+			if !ok || r.Cmp(want) != 0 {
+				t.Errorf("EvalRat = %v (ok=%v), want %s", r, ok, tt.want)
+			}
+
+			f, ok := tt.node.Eval(new(big.Float).SetPrec(prec), prec)
+			wantF := new(big.Float).SetPrec(prec).SetRat(want)
+			if !ok {
+				t.Fatalf("Eval failed")
+			}
+			diff := new(big.Float).Sub(f, wantF)
+			if diff.Sign() != 0 && diff.Quo(diff, wantF).Abs(diff).Cmp(big.NewFloat(math.Ldexp(1, -prec+8))) > 0 {
+				t.Errorf("Eval = %s, want %s", f.Text('g', 30), tt.want)
+			}
+
+			w, _ := want.Float64()
+			if g, ok := tt.node.EvalF64(0); !ok || math.Abs(g-w) > 1e-14*math.Max(1, math.Abs(w)) {
+				t.Errorf("EvalF64 = %v (ok=%v), want %v", g, ok, w)
+			}
+		})
+	}
+}
+
+func TestSpecialSequences_Domain(t *testing.T) {
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	bad := []ExprNode{
+		&UnaryNode{Op: OpHarmonic, Child: c(-1)},
+		&UnaryNode{Op: OpCatalan, Child: c(maxComputeInput + 1)},
+		&UnaryNode{Op: OpLucas, Child: &BinaryNode{Op: OpDiv, Left: c(1), Right: c(2)}},
+		&BinaryNode{Op: OpHarmonicGen, Left: c(5), Right: c(0)},
+		&BinaryNode{Op: OpHarmonicGen, Left: c(5), Right: c(maxHarmonicOrder + 1)},
+		&BinaryNode{Op: OpPochhammer, Left: c(2), Right: c(-1)},
+	}
+	for _, node := range bad {
+		if _, ok := node.Eval(new(big.Float).SetPrec(64), 64); ok {
+			t.Errorf("Eval(%s) succeeded, want failure", node)
+		}
+		if _, ok := node.EvalF64(0); ok {
+			t.Errorf("EvalF64(%s) succeeded, want failure", node)
+		}
+		if _, ok := node.EvalRat(big.NewInt(0), 64); ok {
+			t.Errorf("EvalRat(%s) succeeded, want failure", node)
+		}
+	}
+}
+
+// TestSpecialSequences_F64Tables checks the float64 tables against the exact
+// caches out to their ends, where the sequences overflow.
+func TestSpecialSequences_F64Tables(t *testing.T) {
+	last := func(name string, table []float64, exact func(int64) *big.Float) {
+		t.Helper()
+		n := int64(len(table) - 1)
+		want, _ := exact(n).Float64()
+		if table[n] != want || math.IsInf(want, 0) {
+			t.Errorf("%s[%d] = %v, want finite %v", name, n, table[n], want)
+		}
+		if next, _ := exact(n + 1).Float64(); !math.IsInf(next, 0) {
+			t.Errorf("%s[%d] = %v is finite; the table stops short", name, n+1, next)
+		}
+	}
+	last("catalan", catalanF64[:], func(n int64) *big.Float { return new(big.Float).SetInt(catalanInt(n)) })
+	last("lucas", lucasF64[:], func(n int64) *big.Float { return new(big.Float).SetInt(lucasInt(n)) })
+	b := bernoulliF64()
+	last("bernoulli", b, func(n int64) *big.Float { return new(big.Float).SetRat(bernoulliRat(n)) })
+}
+
+func TestSpecialSequences_Parse(t *testing.T) {
+	tests := []struct{ latex, want string }{
+		{`H_{n}^{(2)}`, "H(n, 2)"},
+		{`H_{n}^{2}`, "(H(n))^(2)"},
+		{`2C_{n}`, "(2 * catalan(n))"},
+		{`\frac{B_{2n}}{(2n)!}`, "(B((2 * n)) / ((2 * n))!)"},
+		{`(\frac{1}{2})_{n}`, "poch(1/2, n)"},
+	}
+	for _, tt := range tests {
+		node, err := ParseExprLatex(tt.latex)
+		if err != nil {
+			t.Errorf("ParseExprLatex(%q): %v", tt.latex, err)
+			continue
+		}
+		if got := node.String(); got != tt.want {
+			t.Errorf("ParseExprLatex(%q) = %s, want %s", tt.latex, got, tt.want)
+		}
+	}
+}
+
+func TestSimplify_SpecialSequences(t *testing.T) {
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	tests := []struct {
+		name string
+		node ExprNode
+		want string
+	}{
+		{"catalan const", &UnaryNode{Op: OpCatalan, Child: c(5)}, "42"},
+		{"lucas const", &UnaryNode{Op: OpLucas, Child: c(5)}, "11"},
+		{"odd bernoulli", &UnaryNode{Op: OpBernoulli, Child: c(9)}, "0"},
+		{"H order 1", &BinaryNode{Op: OpHarmonicGen, Left: &VarNode{}, Right: c(1)}, "H(n)"},
+		{"(1)_n", &BinaryNode{Op: OpPochhammer, Left: c(1), Right: &VarNode{}}, "(n)!"},
+		{"(n)_1", &BinaryNode{Op: OpPochhammer, Left: &VarNode{}, Right: c(1)}, "n"},
+	}
+	for _, tt := range tests {
+		if got := Simplify(tt.node).String(); got != tt.want {
+			t.Errorf("%s: Simplify(%s) = %s, want %s", tt.name, tt.node, got, tt.want)
+		}
+	}
+}
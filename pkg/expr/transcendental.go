@@ -0,0 +1,226 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// Transcendental functions at full precision. Each reduces its argument to a
+// small interval where a power series converges fast, sums it at a working
+// precision of prec plus guard bits, and undoes the reduction:
+//
+//   - exp x = 2^k e^r with r = x - k ln 2, e^r from e^(r/2^s) squared s times;
+//   - ln x by constants.Ln, which the constants need as well;
+//   - sin and cos of r = x - q pi/2, from v = 1 - cos(r/2^s) doubled s times
+//     by 1 - cos 2a = 2v(2 - v);
+//   - atan x from the series for |x| <= 1 after halving the angle s times;
+//   - x^y = exp(y ln x).
+
+// transGuard is the extra working precision: enough for the rounding of the
+// series and reductions, not counting bits the argument's size eats.
+const transGuard = 32
+
+// maxTransArg bounds |x| for exp, sin and cos. Past it exp leaves any useful
+// range, and reducing sin and cos needs pi to more bits than x carries.
+const maxTransArg = 1 << 20
+
+// halvings returns how many times to halve an argument before summing: about
+// sqrt(prec)/2, balancing series terms against the doublings that undo it.
+func halvings(prec uint) int {
+	return int(math.Sqrt(float64(prec))) / 2
+}
+
+// argBits returns the bits |x| >= 1 has before its binary point, which
+// reducing x modulo a constant loses.
+func argBits(x *big.Float) uint {
+	return uint(max(x.MantExp(nil), 0))
+}
+
+// bigExp returns e^x, or false when |x| > maxTransArg.
+func bigExp(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() || new(big.Float).Abs(x).Cmp(big.NewFloat(maxTransArg)) > 0 {
+		return nil, false
+	}
+	if x.Sign() == 0 {
+		return new(big.Float).SetPrec(prec).SetInt64(1), true
+	}
+	s := halvings(prec)
+	wp := prec + transGuard + argBits(x) + uint(s)
+
+	// r = x - k ln 2 with |r| <= ln 2 / 2.
+	ln2 := constants.Get("ln2", wp).Value
+	xf, _ := x.Float64()
+	k := int64(math.Round(xf / math.Ln2))
+	r := new(big.Float).SetPrec(wp).Mul(big.NewFloat(float64(k)), ln2)
+	r.Sub(x, r)
+	r.SetMantExp(r, -s)
+
+	// e^r - 1 by Taylor, kept small so squaring loses nothing.
+	sum := new(big.Float).SetPrec(wp)
+	term := new(big.Float).SetPrec(wp).SetInt64(1)
+	for j := int64(1); ; j++ {
+		term.Mul(term, r).Quo(term, new(big.Float).SetInt64(j))
+		if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(wp) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	// (1 + u)^2 - 1 = u(2 + u)
+	two := big.NewFloat(2)
+	for range s {
+		u := new(big.Float).SetPrec(wp).Add(sum, two)
+		sum.Mul(sum, u)
+	}
+	sum.Add(sum, bigOne)
+	sum.SetMantExp(sum, int(k))
+	return new(big.Float).SetPrec(prec).Set(sum), true
+}
+
+// bigSinCos returns sin x and cos x, or false when |x| > maxTransArg.
+func bigSinCos(x *big.Float, prec uint) (sin, cos *big.Float, ok bool) {
+	if x.IsInf() || new(big.Float).Abs(x).Cmp(big.NewFloat(maxTransArg)) > 0 {
+		return nil, nil, false
+	}
+	s := halvings(prec)
+	wp := prec + transGuard + argBits(x) + uint(2*s)
+
+	// r = x - q pi/2 with |r| <= pi/4.
+	halfPi := new(big.Float).SetPrec(wp).Set(constants.Get("pi", wp).Value)
+	halfPi.SetMantExp(halfPi, -1)
+	xf, _ := x.Float64()
+	q := int64(math.Round(xf / (math.Pi / 2)))
+	r := new(big.Float).SetPrec(wp).Mul(big.NewFloat(float64(q)), halfPi)
+	r.Sub(x, r)
+	a := new(big.Float).SetPrec(wp).SetMantExp(r, -s)
+
+	// v = 1 - cos a = a^2/2! - a^4/4! + ...
+	a2 := new(big.Float).SetPrec(wp).Mul(a, a)
+	v := new(big.Float).SetPrec(wp)
+	term := new(big.Float).SetPrec(wp).SetInt64(-1)
+	for j := int64(2); ; j += 2 {
+		term.Mul(term, a2).Quo(term, new(big.Float).SetInt64(-(j-1)*j))
+		if term.Sign() == 0 || v.Sign() != 0 && term.MantExp(nil) < v.MantExp(nil)-int(wp) {
+			break
+		}
+		v.Add(v, term)
+	}
+	two := big.NewFloat(2)
+	for range s {
+		w := new(big.Float).SetPrec(wp).Sub(two, v)
+		v.Mul(v, w).SetMantExp(v, 1)
+	}
+
+	// cos r = 1 - v, |sin r| = sqrt(v (2 - v)).
+	c := new(big.Float).SetPrec(wp).Sub(bigOne, v)
+	sn := new(big.Float).SetPrec(wp).Sub(two, v)
+	sn.Mul(sn, v)
+	if sn.Sign() > 0 {
+		sn.Sqrt(sn)
+	}
+	if r.Sign() < 0 {
+		sn.Neg(sn)
+	}
+
+	// Rotate back by q quarter turns.
+	switch q & 3 {
+	case 1:
+		sn, c = c, sn.Neg(sn)
+	case 2:
+		sn.Neg(sn)
+		c.Neg(c)
+	case 3:
+		sn, c = c.Neg(c), sn
+	}
+	return new(big.Float).SetPrec(prec).Set(sn), new(big.Float).SetPrec(prec).Set(c), true
+}
+
+// bigAtan returns atan x.
+func bigAtan(x *big.Float, prec uint) *big.Float {
+	if x.IsInf() {
+		halfPi := new(big.Float).SetPrec(prec).Set(constants.Get("pi", prec).Value)
+		halfPi.SetMantExp(halfPi, -1)
+		if x.Sign() < 0 {
+			halfPi.Neg(halfPi)
+		}
+		return halfPi
+	}
+	s := halvings(prec)
+	wp := prec + transGuard + uint(s)
+
+	// For |x| > 1, atan x = sign(x) pi/2 - atan(1/x).
+	t := new(big.Float).SetPrec(wp).Set(x)
+	invert := new(big.Float).Abs(t).Cmp(bigOne) > 0
+	if invert {
+		t.Quo(bigOne, t)
+	}
+
+	// atan t = 2 atan(t / (1 + sqrt(1 + t^2))), s times.
+	for range s {
+		d := new(big.Float).SetPrec(wp).Mul(t, t)
+		d.Add(d, bigOne).Sqrt(d).Add(d, bigOne)
+		t.Quo(t, d)
+	}
+
+	// atan t = t - t^3/3 + t^5/5 - ...
+	t2 := new(big.Float).SetPrec(wp).Mul(t, t)
+	t2.Neg(t2)
+	sum := new(big.Float).SetPrec(wp).Set(t)
+	power := new(big.Float).SetPrec(wp).Set(t)
+	for j := int64(3); sum.Sign() != 0; j += 2 {
+		power.Mul(power, t2)
+		term := new(big.Float).SetPrec(wp).Quo(power, new(big.Float).SetInt64(j))
+		if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(wp) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	sum.SetMantExp(sum, s)
+
+	if invert {
+		halfPi := new(big.Float).SetPrec(wp).Set(constants.Get("pi", wp).Value)
+		halfPi.SetMantExp(halfPi, -1)
+		if x.Sign() < 0 {
+			halfPi.Neg(halfPi)
+		}
+		sum.Sub(halfPi, sum)
+	}
+	return new(big.Float).SetPrec(prec).Set(sum)
+}
+
+// bigRealPow returns base^exp for a non-integer exp: e^(exp ln base) for
+// base > 0, and 0 for base 0 and exp > 0. Half-integer powers take a square
+// root instead.
+func bigRealPow(base, exp *big.Float, prec uint) (*big.Float, bool) {
+	switch base.Sign() {
+	case -1:
+		return nil, false
+	case 0:
+		if exp.Sign() <= 0 {
+			return nil, false
+		}
+		return new(big.Float).SetPrec(prec), true
+	}
+	twice := new(big.Float).SetMantExp(exp, 1)
+	if k, ok := toInt64(twice); ok {
+		p, ok := bigPow(base, big.NewFloat(float64((k-1)/2)), prec+transGuard)
+		if !ok {
+			return nil, false
+		}
+		root := new(big.Float).SetPrec(prec + transGuard).Sqrt(base)
+		return new(big.Float).SetPrec(prec).Mul(p, root), true
+	}
+
+	// e^y to prec bits needs ln base to the bits of y before its binary point
+	// on top, which its float64 estimate gives.
+	ef, _ := exp.Float64()
+	yBits := math.Abs(ef) * (math.Abs(float64(base.MantExp(nil))) + 1)
+	if yBits > maxTransArg {
+		return nil, false
+	}
+	wp := prec + transGuard + uint(bits.Len(uint(yBits)))
+	lnBase, _ := constants.Ln(base, wp)
+	return bigExp(lnBase.Mul(lnBase, exp), prec)
+}
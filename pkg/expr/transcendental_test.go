@@ -0,0 +1,148 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// transPrec holds the 100-digit references below with room to spare.
+const transPrec = 400
+
+func bigFromString(t *testing.T, s string, prec uint) *big.Float {
+	t.Helper()
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		t.Fatalf("ParseFloat(%q): %v", s, err)
+	}
+	return f
+}
+
+// assertDigits checks got against a reference to at least digits digits.
+func assertDigits(t *testing.T, name string, got *big.Float, want string, digits float64) {
+	t.Helper()
+	w := bigFromString(t, want, got.Prec())
+	diff := new(big.Float).Sub(got, w)
+	if diff.Sign() == 0 {
+		return
+	}
+	mant := new(big.Float)
+	exp := diff.Quo(diff, w).MantExp(mant)
+	m, _ := mant.Float64()
+	if agreed := -(math.Log10(math.Abs(m)) + float64(exp)*math.Log10(2)); agreed < digits {
+		t.Errorf("%s = %s, want %s (%.1f digits)", name, got.Text('g', 40), want[:min(len(want), 40)], agreed)
+	}
+}
+
+func TestTranscendental_KnownDigits(t *testing.T) {
+	x := func(s string) *big.Float { return bigFromString(t, s, transPrec) }
+	sin := func(s string) *big.Float { v, _, _ := bigSinCos(x(s), transPrec); return v }
+	cos := func(s string) *big.Float { _, v, _ := bigSinCos(x(s), transPrec); return v }
+	ln := func(s string) *big.Float { v, _ := constants.Ln(x(s), transPrec); return v }
+	exp := func(s string) *big.Float { v, _ := bigExp(x(s), transPrec); return v }
+	pow := func(b, e string) *big.Float { v, _ := bigPow(x(b), x(e), transPrec); return v }
+
+	cases := []struct {
+		name string
+		got  *big.Float
+		want string
+	}{
+		{"sin 1", sin("1"), "0.8414709848078965066525023216302989996225630607983710656727517099919104043912396689486397435430526959"},
+		{"cos 1", cos("1"), "0.5403023058681397174009366074429766037323104206179222276700972553811003947744717645179518560871830893"},
+		{"sin 100", sin("100"), "-0.5063656411097587936565576104597854320650327212906573234433924735943579134194766964992366645129273922"},
+		{"cos -7", cos("-7"), "0.7539022543433046381411975217191820122183133914601268395436138808138760267207174056254283910893024825"},
+		{"ln 10", ln("10"), "2.302585092994045684017991454684364207601101488628772976033327900967572609677352480235997205089598298"},
+		{"ln 0.001", ln("0.001"), "-6.907755278982137052053974364053092622803304465886318928099983702902717829032057440707991615268794895"},
+		{"exp -50", exp("-50"), "1.928749847963917783017342816527012574752832651230262910897809103820511624979646591652373378777735137e-22"},
+		{"exp 10.5", exp("10.5"), "36315.50267424663773891202690131661796893155796712758576074801905508428566280991877497644277581748663"},
+		{"atan 0.5", bigAtan(x("0.5"), transPrec), "0.4636476090008061162142562314612144020285370542861202638109330887201978641657417053006002839848878926"},
+		{"atan -3", bigAtan(x("-3"), transPrec), "-1.249045772398254425829917077281090123077829404129896719054669236797151965737293954957608990320417160"},
+		{"7^2.5", pow("7", "2.5"), "129.6418142421649389345791719283237608598026999710400588380483885008523723382838977602592514372526369"},
+		{"2^0.3", pow("2", "0.3"), "1.231144413344916284499393069167743109876137761100817794337065538246100719719358458404022749650894142"},
+		{"0.5^-3.7", pow("0.5", "-3.7"), "12.99603834169976836175535012440450642056327959091455108051989940886753189496023816999577807705646124"},
+	}
+	for _, tc := range cases {
+		if tc.got == nil {
+			t.Errorf("%s failed", tc.name)
+			continue
+		}
+		assertDigits(t, tc.name, tc.got, tc.want, 99)
+	}
+}
+
+// TestTranscendental_HighPrecision checks identities at 2048 bits, far past
+// float64: exp(1) = e, 4 atan(1) = pi, ln 2 and sin^2 + cos^2 = 1.
+func TestTranscendental_HighPrecision(t *testing.T) {
+	const prec = 2048
+	one := big.NewFloat(1)
+	digits := float64(prec)*math.Log10(2) - 3
+
+	e, _ := bigExp(one, prec)
+	assertDigits(t, "exp 1", e, constants.Get("e", prec).Value.Text('g', 700), digits)
+
+	pi := bigAtan(one, prec)
+	pi.SetMantExp(pi, 2)
+	assertDigits(t, "4 atan 1", pi, constants.Get("pi", prec).Value.Text('g', 700), digits)
+
+	ln2, _ := constants.Ln(big.NewFloat(2), prec)
+	assertDigits(t, "ln 2", ln2, constants.Get("ln2", prec).Value.Text('g', 700), digits)
+
+	// e^(ln 3) round-trips.
+	three := new(big.Float).SetPrec(prec).SetInt64(3)
+	ln3, _ := constants.Ln(three, prec)
+	back, _ := bigExp(ln3, prec)
+	assertDigits(t, "exp ln 3", back, "3", digits)
+
+	s, c, ok := bigSinCos(new(big.Float).SetPrec(prec).SetInt64(12345), prec)
+	if !ok {
+		t.Fatal("bigSinCos(12345) failed")
+	}
+	s.Mul(s, s)
+	c.Mul(c, c)
+	assertDigits(t, "sin^2 + cos^2", s.Add(s, c), "1", digits)
+}
+
+// TestEval_TranscendentalPrecision checks that sin, cos, ln and real powers in
+// a tree carry the requested precision instead of float64's 16 digits.
+func TestEval_TranscendentalPrecision(t *testing.T) {
+	const prec = 1024
+	n := new(big.Float).SetPrec(prec).SetInt64(2)
+	half := &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &ConstNode{Val: 2}}
+
+	// sin^2 n + cos^2 n = 1
+	sq := func(op UnaryOp) ExprNode {
+		return &BinaryNode{Op: OpPow, Left: &UnaryNode{Op: op, Child: &VarNode{}}, Right: &ConstNode{Val: 2}}
+	}
+	trig := &BinaryNode{Op: OpAdd, Left: sq(OpSin), Right: sq(OpCos)}
+	// n^(1/2) = sqrt n
+	root := &BinaryNode{Op: OpSub,
+		Left:  &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: half},
+		Right: &UnaryNode{Op: OpSqrt, Child: &VarNode{}}}
+	// ln(n^3) - 3 ln n = 0
+	logs := &BinaryNode{Op: OpSub,
+		Left:  &UnaryNode{Op: OpLn, Child: &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
+		Right: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 3}, Right: &UnaryNode{Op: OpLn, Child: &VarNode{}}}}
+
+	want := []struct {
+		name string
+		node ExprNode
+		val  float64
+	}{
+		{"sin^2+cos^2", trig, 1},
+		{"n^(1/2)-sqrt n", root, 0},
+		{"ln n^3 - 3 ln n", logs, 0},
+	}
+	tol := new(big.Float).SetMantExp(big.NewFloat(1), -prec+16)
+	for _, w := range want {
+		got, ok := w.node.Eval(n, prec)
+		if !ok {
+			t.Fatalf("%s: Eval failed", w.name)
+		}
+		diff := new(big.Float).Sub(got, big.NewFloat(w.val))
+		if diff.Abs(diff).Cmp(tol) > 0 {
+			t.Errorf("%s = %s, want %v to %d bits", w.name, got.Text('g', 30), w.val, prec-16)
+		}
+	}
+}
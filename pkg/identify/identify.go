@@ -0,0 +1,216 @@
+package identify
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// Constant is one element of the basis relations are expressed in.
+type Constant struct {
+	Name  string
+	LaTeX string
+	Value *big.Float
+}
+
+// Relation records that a value equals a rational combination of basis
+// constants: Coeffs[0]*value + Σ Coeffs[i]*Basis[i-1] = 0.
+type Relation struct {
+	Coeffs []int64  `json:"coeffs"`
+	Basis  []string `json:"basis"`
+	Expr   string   `json:"expr"`
+	LaTeX  string   `json:"latex"`
+	Digits float64  `json:"digits"` // digits of the value the relation was checked against
+}
+
+// maxIterPerDim caps PSLQ iterations per vector entry.
+const maxIterPerDim = 200
+
+// maxDigits caps the digits a search works to: relations whose coefficients
+// fit in half of them are all Identify accepts anyway.
+const maxDigits = 50
+
+// maxRelationNorm bounds the relations searched for. Known identities have
+// small coefficients; chasing larger ones mostly finds coincidences.
+const maxRelationNorm = 1e6
+
+// guardBits is the working precision kept beyond the digits being matched.
+const guardBits = 64
+
+// Basis returns the default identification basis built from the constants
+// registry: 1, pi, pi^2, 1/pi, e, ln2, zeta(3), Catalan's G and gamma.
+func Basis(prec uint) []Constant {
+	get := func(name string) *big.Float {
+		return new(big.Float).SetPrec(prec).Set(constants.Get(name, prec).Value)
+	}
+	pi := get("pi")
+	return []Constant{
+		{"1", "1", new(big.Float).SetPrec(prec).SetInt64(1)},
+		{"pi", `\pi`, pi},
+		{"pi^2", `\pi^2`, new(big.Float).SetPrec(prec).Mul(pi, pi)},
+		{"1/pi", `\pi^{-1}`, get("one_over_pi")},
+		{"e", "e", get("e")},
+		{"ln2", `\ln 2`, get("ln2")},
+		{"zeta(3)", `\zeta(3)`, get("apery")},
+		{"catalan", "G", get("catalan")},
+		{"gamma", `\gamma`, get("euler_gamma")},
+	}
+}
+
+// Identify looks for a small-coefficient relation between v, known to the
+// given number of decimal digits, and the basis. Pairs {1, c} are tried first
+// since they need the least precision; the full basis is tried last. A
+// relation is only accepted if its coefficients carry at most half the
+// digits it was matched to, which screens out PSLQ's chance fits.
+func Identify(v *big.Float, digits float64, basis []Constant) (Relation, bool) {
+	if v.Sign() == 0 || digits <= 0 || len(basis) == 0 {
+		return Relation{}, false
+	}
+	// PSLQ loses a few digits to rounding, so never trust the last 10% of
+	// v's precision. Past maxDigits, extra digits only slow the search down.
+	if limit := 0.9 * float64(v.Prec()) * math.Log10(2); digits > limit {
+		digits = limit
+	}
+	if digits > maxDigits {
+		digits = maxDigits
+	}
+	prec := uint(digits*math.Log2(10)) + guardBits
+
+	var subsets [][]Constant
+	for _, c := range basis[1:] {
+		subsets = append(subsets, []Constant{basis[0], c})
+	}
+	subsets = append(subsets, basis)
+
+	for _, sub := range subsets {
+		x := make([]*big.Float, 0, len(sub)+1)
+		x = append(x, v)
+		for _, c := range sub {
+			x = append(x, c.Value)
+		}
+		eps := math.Pow(10, -(digits - 3))
+		maxNorm := math.Min(maxRelationNorm, math.Pow(10, digits/2))
+		coeffs, ok := PSLQ(x, prec, eps, maxNorm, maxIterPerDim*len(x))
+		if !ok {
+			continue
+		}
+		if rel, ok := accept(v, digits, coeffs, sub); ok {
+			return rel, true
+		}
+	}
+	return Relation{}, false
+}
+
+// accept validates a PSLQ relation and turns it into a Relation.
+func accept(v *big.Float, digits float64, coeffs []int64, sub []Constant) (Relation, bool) {
+	if coeffs[0] == 0 {
+		return Relation{}, false
+	}
+	// Orient so the value's coefficient is positive, then reduce by the gcd.
+	if coeffs[0] < 0 {
+		for i := range coeffs {
+			coeffs[i] = -coeffs[i]
+		}
+	}
+	g := int64(0)
+	for _, c := range coeffs {
+		g = gcd(g, abs64(c))
+	}
+	info := 0.0
+	nonzero := false
+	for i := range coeffs {
+		coeffs[i] /= g
+		info += math.Log10(float64(abs64(coeffs[i])) + 1)
+		if i > 0 && coeffs[i] != 0 && sub[i-1].Name != "1" {
+			nonzero = true
+		}
+	}
+	// A limit that is just a rational says nothing about the constants.
+	if !nonzero || 2*info > digits {
+		return Relation{}, false
+	}
+
+	// The relation must reproduce v to the digits it was found at.
+	prec := v.Prec()
+	sum := new(big.Float).SetPrec(prec)
+	for i, c := range sub {
+		term := new(big.Float).SetPrec(prec).SetInt64(coeffs[i+1])
+		sum.Add(sum, term.Mul(term, c.Value))
+	}
+	sum.Quo(sum, new(big.Float).SetPrec(prec).SetInt64(-coeffs[0]))
+	diff := new(big.Float).SetPrec(prec).Sub(sum, v)
+	if bigAbsF64(diff) > math.Pow(10, -(digits-1)) {
+		return Relation{}, false
+	}
+
+	rel := Relation{Coeffs: coeffs, Digits: digits}
+	for _, c := range sub {
+		rel.Basis = append(rel.Basis, c.Name)
+	}
+	rel.Expr, rel.LaTeX = format(coeffs, sub)
+	return rel, true
+}
+
+// format renders value = -(Σ a_i c_i) / a_0 as plain text and LaTeX.
+func format(coeffs []int64, sub []Constant) (string, string) {
+	den := coeffs[0]
+	var txt, tex []string
+	terms := 0
+	for i, c := range sub {
+		a := -coeffs[i+1]
+		if a == 0 {
+			continue
+		}
+		sign := "+"
+		if a < 0 {
+			sign, a = "-", -a
+		}
+		var t, l string
+		switch {
+		case c.Name == "1":
+			t, l = fmt.Sprintf("%d", a), fmt.Sprintf("%d", a)
+		case a == 1:
+			t, l = c.Name, c.LaTeX
+		default:
+			t, l = fmt.Sprintf("%d*%s", a, c.Name), fmt.Sprintf("%d%s", a, c.LaTeX)
+		}
+		if terms == 0 {
+			if sign == "-" {
+				t, l = "-"+t, "-"+l
+			}
+		} else {
+			t, l = sign+" "+t, sign+" "+l
+		}
+		txt = append(txt, t)
+		tex = append(tex, l)
+		terms++
+	}
+	num, numTex := strings.Join(txt, " "), strings.Join(tex, " ")
+	if den == 1 {
+		return num, numTex
+	}
+	if terms > 1 {
+		return fmt.Sprintf("(%s)/%d", num, den), fmt.Sprintf(`\frac{%s}{%d}`, numTex, den)
+	}
+	if strings.HasPrefix(numTex, "-") {
+		return fmt.Sprintf("%s/%d", num, den), fmt.Sprintf(`-\frac{%s}{%d}`, numTex[1:], den)
+	}
+	return fmt.Sprintf("%s/%d", num, den), fmt.Sprintf(`\frac{%s}{%d}`, numTex, den)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func abs64(a int64) int64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
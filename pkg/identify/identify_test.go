@@ -0,0 +1,111 @@
+package identify
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+const testPrec = 512
+
+// noisy returns v perturbed in its (digits+1)-th decimal place, the way a
+// partial sum stable to that many digits would be.
+func noisy(v *big.Float, digits int) *big.Float {
+	eps := new(big.Float).SetPrec(testPrec).SetFloat64(3)
+	for i := 0; i <= digits; i++ {
+		eps.Quo(eps, big.NewFloat(10))
+	}
+	return new(big.Float).SetPrec(testPrec).Add(v, eps)
+}
+
+func TestPSLQ_FindsRelation(t *testing.T) {
+	pi := constants.Get("pi", testPrec).Value
+	ln2 := constants.Get("ln2", testPrec).Value
+	// x = 2*pi - 5*ln2 + 7
+	x := new(big.Float).SetPrec(testPrec).Mul(big.NewFloat(2), pi)
+	x.Sub(x, new(big.Float).SetPrec(testPrec).Mul(big.NewFloat(5), ln2))
+	x.Add(x, big.NewFloat(7))
+	one := new(big.Float).SetPrec(testPrec).SetInt64(1)
+
+	rel, ok := PSLQ([]*big.Float{x, one, pi, ln2}, testPrec, 1e-100, 1e20, 1000)
+	if !ok {
+		t.Fatal("PSLQ found no relation")
+	}
+	if rel[0] < 0 {
+		for i := range rel {
+			rel[i] = -rel[i]
+		}
+	}
+	want := []int64{1, -7, -2, 5}
+	for i := range want {
+		if rel[i] != want[i] {
+			t.Fatalf("relation = %v, want %v", rel, want)
+		}
+	}
+}
+
+// TestAddMul checks the basis update near the coefficient bound, where the
+// product alone would wrap around int64.
+func TestAddMul(t *testing.T) {
+	tests := []struct {
+		a, t, b int64
+		want    int64
+		ok      bool
+	}{
+		{3, 4, 5, 23, true},
+		{maxCoeff, -1, maxCoeff, 0, true},
+		{0, maxCoeff, maxCoeff, 0, false}, // 2^106 would wrap to 0
+		{0, 1 << 40, 1 << 30, 0, false},   // 2^70 would wrap to 0
+		{1, maxCoeff, 1, 0, false},
+		{-maxCoeff, -1, 1, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := addMul(tt.a, tt.t, tt.b)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("addMul(%d, %d, %d) = %d, %v, want %d, %v", tt.a, tt.t, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIdentify_KnownValues(t *testing.T) {
+	basis := Basis(testPrec)
+	pi := constants.Get("pi", testPrec).Value
+	ln2 := constants.Get("ln2", testPrec).Value
+
+	threePiSqOver8 := new(big.Float).SetPrec(testPrec).Mul(pi, pi)
+	threePiSqOver8.Mul(threePiSqOver8, big.NewFloat(3))
+	threePiSqOver8.Quo(threePiSqOver8, big.NewFloat(8))
+
+	onePlus2Ln2Over3 := new(big.Float).SetPrec(testPrec).Mul(ln2, big.NewFloat(2))
+	onePlus2Ln2Over3.Add(onePlus2Ln2Over3, big.NewFloat(1))
+	onePlus2Ln2Over3.Quo(onePlus2Ln2Over3, big.NewFloat(3))
+
+	cases := []struct {
+		name      string
+		v         *big.Float
+		wantExpr  string
+		wantLaTeX string
+	}{
+		{"3pi^2/8", threePiSqOver8, "3*pi^2/8", `\frac{3\pi^2}{8}`},
+		{"(1+2ln2)/3", onePlus2Ln2Over3, "(1 + 2*ln2)/3", `\frac{1 + 2\ln 2}{3}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rel, ok := Identify(noisy(tc.v, 18), 18, basis)
+			if !ok {
+				t.Fatal("no relation found")
+			}
+			if rel.Expr != tc.wantExpr || rel.LaTeX != tc.wantLaTeX {
+				t.Errorf("got %q / %q, want %q / %q", rel.Expr, rel.LaTeX, tc.wantExpr, tc.wantLaTeX)
+			}
+		})
+	}
+}
+
+func TestIdentify_RejectsUnrelated(t *testing.T) {
+	sqrt2 := new(big.Float).SetPrec(testPrec).Sqrt(big.NewFloat(2))
+	if rel, ok := Identify(noisy(sqrt2, 16), 16, Basis(testPrec)); ok {
+		t.Errorf("sqrt(2) identified as %s", rel.Expr)
+	}
+}
@@ -0,0 +1,230 @@
+package identify
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxCoeff bounds the entries of the PSLQ basis matrix. Anything larger is far
+// outside the relations worth reporting and would overflow int64 soon after.
+const maxCoeff = 1 << 53
+
+// PSLQ searches for integers a, not all zero, with a·x ≈ 0 using the
+// Ferguson–Bailey PSLQ algorithm at prec bits. A relation is accepted once
+// |a·x| / ‖x‖ drops below eps. The search gives up when every remaining
+// relation must have a norm above maxNorm, or after maxIter iterations.
+func PSLQ(x []*big.Float, prec uint, eps, maxNorm float64, maxIter int) ([]int64, bool) {
+	n := len(x)
+	if n < 2 {
+		return nil, false
+	}
+	nf := func() *big.Float { return new(big.Float).SetPrec(prec) }
+
+	// A zero entry is a relation on its own.
+	for k, xk := range x {
+		if xk.Sign() == 0 {
+			rel := make([]int64, n)
+			rel[k] = 1
+			return rel, true
+		}
+	}
+
+	// s[k] = sqrt(x_k^2 + ... + x_{n-1}^2), then normalize so s[0] = 1.
+	s := make([]*big.Float, n)
+	acc := nf()
+	for k := n - 1; k >= 0; k-- {
+		acc.Add(acc, nf().Mul(x[k], x[k]))
+		s[k] = nf().Sqrt(acc)
+	}
+	norm := nf().Copy(s[0])
+	y := make([]*big.Float, n)
+	for k := range x {
+		y[k] = nf().Quo(x[k], norm)
+		s[k].Quo(s[k], norm)
+	}
+
+	// H is n×(n-1) lower trapezoidal with H·Hᵀ = I - y·yᵀ.
+	h := make([][]*big.Float, n)
+	for i := range h {
+		h[i] = make([]*big.Float, n-1)
+		for j := range h[i] {
+			switch {
+			case j > i:
+				h[i][j] = nf()
+			case j == i:
+				h[i][j] = nf().Quo(s[i+1], s[i])
+			default:
+				v := nf().Mul(y[i], y[j])
+				v.Quo(v, nf().Mul(s[j], s[j+1]))
+				h[i][j] = v.Neg(v)
+			}
+		}
+	}
+
+	b := make([][]int64, n)
+	for i := range b {
+		b[i] = make([]int64, n)
+		b[i][i] = 1
+	}
+
+	// reduce applies Hermite reduction to row i against rows jmax..0,
+	// folding the same integer operations into y and B.
+	reduce := func(i, jmax int) bool {
+		for j := jmax; j >= 0; j-- {
+			if h[j][j].Sign() == 0 {
+				continue
+			}
+			t, ok := nearestInt(nf().Quo(h[i][j], h[j][j]))
+			if !ok {
+				return false
+			}
+			if t == 0 {
+				continue
+			}
+			tf := nf().SetInt64(t)
+			y[j].Add(y[j], nf().Mul(tf, y[i]))
+			for k := 0; k <= j; k++ {
+				h[i][k].Sub(h[i][k], nf().Mul(tf, h[j][k]))
+			}
+			for k := 0; k < n; k++ {
+				v, ok := addMul(b[k][j], t, b[k][i])
+				if !ok {
+					return false
+				}
+				b[k][j] = v
+			}
+		}
+		return true
+	}
+
+	for i := 1; i < n; i++ {
+		if !reduce(i, i-1) {
+			return nil, false
+		}
+	}
+
+	logGamma := 0.5 * math.Log2(4.0/3.0)
+	for iter := 0; iter < maxIter; iter++ {
+		// Exchange the rows where γ^i·|H_ii| is largest.
+		m, bestScore := 0, math.Inf(-1)
+		for i := 0; i < n-1; i++ {
+			score := log2Abs(h[i][i]) + float64(i+1)*logGamma
+			if score > bestScore {
+				m, bestScore = i, score
+			}
+		}
+		y[m], y[m+1] = y[m+1], y[m]
+		h[m], h[m+1] = h[m+1], h[m]
+		for k := 0; k < n; k++ {
+			b[k][m], b[k][m+1] = b[k][m+1], b[k][m]
+		}
+
+		// Restore the lower-trapezoidal shape with a Givens rotation.
+		if m < n-2 {
+			t0 := nf().Mul(h[m][m], h[m][m])
+			t0.Add(t0, nf().Mul(h[m][m+1], h[m][m+1]))
+			t0.Sqrt(t0)
+			if t0.Sign() != 0 {
+				t1 := nf().Quo(h[m][m], t0)
+				t2 := nf().Quo(h[m][m+1], t0)
+				for i := m; i < n; i++ {
+					t3, t4 := h[i][m], h[i][m+1]
+					a := nf().Mul(t1, t3)
+					a.Add(a, nf().Mul(t2, t4))
+					c := nf().Mul(t1, t4)
+					c.Sub(c, nf().Mul(t2, t3))
+					h[i][m], h[i][m+1] = a, c
+				}
+			}
+		}
+
+		for i := m + 1; i < n; i++ {
+			jmax := i - 1
+			if m+1 < jmax {
+				jmax = m + 1
+			}
+			if !reduce(i, jmax) {
+				return nil, false
+			}
+		}
+
+		for j := range y {
+			if bigAbsF64(y[j]) < eps {
+				rel := make([]int64, n)
+				for k := range rel {
+					rel[k] = b[k][j]
+				}
+				return rel, true
+			}
+		}
+
+		// Any relation not yet found has norm at least 1/max|H_jj|.
+		maxDiag := math.Inf(-1)
+		for j := 0; j < n-1; j++ {
+			if l := log2Abs(h[j][j]); l > maxDiag {
+				maxDiag = l
+			}
+		}
+		if -maxDiag > math.Log2(maxNorm) {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// addMul returns a + t*b, failing when it leaves the coefficient range. The
+// product is bounded before it is formed, so it cannot wrap around int64.
+func addMul(a, t, b int64) (int64, bool) {
+	if b != 0 && (t > 2*maxCoeff/absInt(b) || t < -2*maxCoeff/absInt(b)) {
+		return 0, false
+	}
+	v := a + t*b
+	if v > maxCoeff || v < -maxCoeff {
+		return 0, false
+	}
+	return v, true
+}
+
+func absInt(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// nearestInt rounds x to the nearest int64, failing when it would not fit in
+// the coefficient range.
+func nearestInt(x *big.Float) (int64, bool) {
+	if x.IsInf() {
+		return 0, false
+	}
+	f, _ := x.Float64()
+	if math.Abs(f) > maxCoeff {
+		return 0, false
+	}
+	r := new(big.Float).SetPrec(x.Prec())
+	if x.Sign() < 0 {
+		r.Sub(x, big.NewFloat(0.5))
+	} else {
+		r.Add(x, big.NewFloat(0.5))
+	}
+	v, _ := r.Int64()
+	return v, true
+}
+
+// log2Abs returns log2|x| without underflowing for tiny big.Float values.
+func log2Abs(x *big.Float) float64 {
+	if x.Sign() == 0 {
+		return math.Inf(-1)
+	}
+	mant := new(big.Float)
+	exp := x.MantExp(mant)
+	m, _ := mant.Float64()
+	return math.Log2(math.Abs(m)) + float64(exp)
+}
+
+// bigAbsF64 returns |x| as a float64 (0 on underflow).
+func bigAbsF64(x *big.Float) float64 {
+	f, _ := new(big.Float).Abs(x).Float64()
+	return f
+}
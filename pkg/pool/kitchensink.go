@@ -10,7 +10,9 @@ func init() {
 	Register("kitchensink", func() Pool { return &KitchenSinkPool{} })
 }
 
-// KitchenSinkPool extends moderate with trig, ln, floor, ceil.
+// KitchenSinkPool extends moderate with trig, ln, floor, ceil and the
+// special sequences: harmonic, Bernoulli, Catalan and Lucas numbers and
+// rising factorials.
 type KitchenSinkPool struct{}
 
 func (p *KitchenSinkPool) Name() string { return "kitchensink" }
@@ -44,6 +46,10 @@ var kitchenSinkUnary = []expr.UnaryOp{
 	expr.OpLn,
 	expr.OpFloor,
 	expr.OpCeil,
+	expr.OpHarmonic,
+	expr.OpBernoulli,
+	expr.OpCatalan,
+	expr.OpLucas,
 }
 
 func (p *KitchenSinkPool) RandomUnary(rng *rand.Rand) expr.UnaryOp {
@@ -57,6 +63,8 @@ var kitchenSinkBinary = []expr.BinaryOp{
 	expr.OpDiv,
 	expr.OpPow,
 	expr.OpBinomial,
+	expr.OpHarmonicGen,
+	expr.OpPochhammer,
 }
 
 func (p *KitchenSinkPool) RandomBinary(rng *rand.Rand) expr.BinaryOp {
@@ -0,0 +1,484 @@
+package series
+
+import (
+	"math"
+	"math/big"
+)
+
+// Acceleration identifies the sequence-acceleration method that produced an
+// estimate of a series' limit.
+type Acceleration int
+
+const (
+	AccelNone       Acceleration = iota // raw partial sum
+	AccelRichardson                     // Richardson table over power-of-2 checkpoints
+	AccelAitken                         // iterated Aitken delta-squared over checkpoints
+	AccelLevin                          // Levin u-transform over the trailing terms
+	AccelEuler                          // Euler transform of an alternating tail
+)
+
+var accelNames = map[Acceleration]string{
+	AccelNone:       "none",
+	AccelRichardson: "richardson",
+	AccelAitken:     "aitken",
+	AccelLevin:      "levin",
+	AccelEuler:      "euler",
+}
+
+func (a Acceleration) String() string { return accelNames[a] }
+
+const (
+	// accelWindow is how many trailing terms/partial sums are kept for the
+	// Levin and Euler transforms.
+	accelWindow = 12
+	// richardsonLevels caps the depth of the Richardson table; deeper levels
+	// amplify rounding noise faster than they cancel error terms.
+	richardsonLevels = 3
+	// accelMaxJump rejects estimates that move further from the raw partial
+	// sum than this multiple of the last checkpoint difference.
+	accelMaxJump = 10.0
+)
+
+// termWindow is a ring buffer of the last accelWindow terms and partial sums.
+type termWindow struct {
+	terms [accelWindow]*big.Float
+	sums  [accelWindow]*big.Float
+	count int
+}
+
+func newTermWindow(prec uint) *termWindow {
+	w := &termWindow{}
+	for i := range w.sums {
+		w.sums[i] = new(big.Float).SetPrec(prec)
+	}
+	return w
+}
+
+// push records a term and the partial sum that includes it. The term is
+// retained, so callers must not mutate it afterwards.
+func (w *termWindow) push(term, sum *big.Float) {
+	i := w.count % accelWindow
+	w.terms[i] = term
+	w.sums[i].Set(sum)
+	w.count++
+}
+
+// ordered returns the buffered terms and sums, oldest first.
+func (w *termWindow) ordered() (terms, sums []*big.Float) {
+	n := w.count
+	if n > accelWindow {
+		n = accelWindow
+	}
+	for k := w.count - n; k < w.count; k++ {
+		terms = append(terms, w.terms[k%accelWindow])
+		sums = append(sums, w.sums[k%accelWindow])
+	}
+	return terms, sums
+}
+
+// accelerate picks the acceleration method whose self-consistency error is
+// smallest, provided it beats the raw partial sum. It returns the raw sum and
+// AccelNone when no method helps. The third result is the error estimate of
+// the returned value (0 when the checkpoints agree exactly, +Inf when there
+// are too few to tell).
+func accelerate(cps []checkpoint, win *termWindow, raw *big.Float, prec uint) (*big.Float, Acceleration, float64) {
+	if len(cps) < 3 {
+		return raw, AccelNone, math.Inf(1)
+	}
+	last := new(big.Float).SetPrec(prec).Sub(cps[len(cps)-1].sum, cps[len(cps)-2].sum)
+	rawErr := bigAbsF64(last)
+	if rawErr == 0 || math.IsInf(rawErr, 0) || math.IsNaN(rawErr) {
+		return raw, AccelNone, rawErr
+	}
+
+	best, bestMethod, bestErr := raw, AccelNone, rawErr
+	consider := func(est *big.Float, errEst float64, m Acceleration) {
+		if est == nil || math.IsNaN(errEst) || errEst >= bestErr {
+			return
+		}
+		jump := new(big.Float).SetPrec(prec).Sub(est, raw)
+		if bigAbsF64(jump) > accelMaxJump*rawErr {
+			return
+		}
+		best, bestMethod, bestErr = est, m, errEst
+	}
+
+	sums := make([]*big.Float, len(cps))
+	for i, cp := range cps {
+		sums[i] = cp.sum
+	}
+	est, errEst := richardson(sums, prec)
+	consider(est, errEst, AccelRichardson)
+	est, errEst = aitken(sums, prec)
+	consider(est, errEst, AccelAitken)
+
+	terms, wsums := win.ordered()
+	offset := int64(win.count - len(terms))
+	est, errEst = levinU(terms, wsums, offset, prec)
+	consider(est, errEst, AccelLevin)
+	est, errEst = eulerTail(terms, wsums, prec)
+	consider(est, errEst, AccelEuler)
+
+	return best, bestMethod, bestErr
+}
+
+// richardson extrapolates partial sums taken at N, 2N, 4N, ... assuming an
+// error expansion c_p/N^p + c_{p+1}/N^{p+1} + ..., with p estimated from the
+// last three checkpoints. Returns nil for geometric or non-converging data.
+func richardson(sums []*big.Float, prec uint) (*big.Float, float64) {
+	n := len(sums)
+	if n < 3 {
+		return nil, 0
+	}
+	d1 := bigAbsF64(new(big.Float).SetPrec(prec).Sub(sums[n-1], sums[n-2]))
+	d0 := bigAbsF64(new(big.Float).SetPrec(prec).Sub(sums[n-2], sums[n-3]))
+	if d0 == 0 {
+		return nil, 0
+	}
+	ratio := d1 / d0
+	if ratio <= 0.01 || ratio >= 1 {
+		return nil, 0
+	}
+	p := math.Round(-math.Log2(ratio))
+	if p < 1 {
+		p = 1
+	}
+
+	levels := richardsonLevels
+	if levels > n-1 {
+		levels = n - 1
+	}
+	row := make([]*big.Float, levels+1)
+	for i := range row {
+		row[i] = new(big.Float).SetPrec(prec).Copy(sums[n-1-levels+i])
+	}
+	var prevBest *big.Float
+	for j := 1; j <= levels; j++ {
+		factor := math.Exp2(p + float64(j-1))
+		f := new(big.Float).SetPrec(prec).SetFloat64(factor)
+		den := new(big.Float).SetPrec(prec).SetFloat64(factor - 1)
+		prevBest = row[len(row)-1]
+		next := make([]*big.Float, len(row)-1)
+		for i := 1; i < len(row); i++ {
+			v := new(big.Float).SetPrec(prec).Mul(f, row[i])
+			v.Sub(v, row[i-1])
+			next[i-1] = v.Quo(v, den)
+		}
+		row = next
+	}
+	est := row[len(row)-1]
+	return est, bigAbsF64(new(big.Float).SetPrec(prec).Sub(est, prevBest))
+}
+
+// aitken applies Aitken's delta-squared process to the checkpoint sums,
+// iterating while enough points remain.
+func aitken(sums []*big.Float, prec uint) (*big.Float, float64) {
+	seq := sums
+	if len(seq) > 7 {
+		seq = seq[len(seq)-7:]
+	}
+	var prev *big.Float
+	for len(seq) >= 3 {
+		next := make([]*big.Float, 0, len(seq)-2)
+		for i := 2; i < len(seq); i++ {
+			d1 := new(big.Float).SetPrec(prec).Sub(seq[i], seq[i-1])
+			d0 := new(big.Float).SetPrec(prec).Sub(seq[i-1], seq[i-2])
+			den := new(big.Float).SetPrec(prec).Sub(d1, d0)
+			if den.Sign() == 0 {
+				return nil, 0
+			}
+			v := new(big.Float).SetPrec(prec).Mul(d1, d1)
+			v.Quo(v, den)
+			next = append(next, v.Sub(seq[i], v))
+		}
+		prev = seq[len(seq)-1]
+		seq = next
+	}
+	if prev == nil {
+		return nil, 0
+	}
+	est := seq[len(seq)-1]
+	return est, bigAbsF64(new(big.Float).SetPrec(prec).Sub(est, prev))
+}
+
+// levinU applies the Levin u-transform to consecutive partial sums S_m with
+// remainder estimates w_m = (m+1)*a_m. offset is the index m of the first
+// element. The error estimate compares against the transform of one fewer term.
+func levinU(terms, sums []*big.Float, offset int64, prec uint) (*big.Float, float64) {
+	if len(terms) < 4 {
+		return nil, 0
+	}
+	est := levinUOrder(terms, sums, offset, prec)
+	prev := levinUOrder(terms[:len(terms)-1], sums[:len(sums)-1], offset, prec)
+	if est == nil || prev == nil {
+		return nil, 0
+	}
+	return est, bigAbsF64(new(big.Float).SetPrec(prec).Sub(est, prev))
+}
+
+func levinUOrder(terms, sums []*big.Float, offset int64, prec uint) *big.Float {
+	k := len(terms) - 1
+	num := new(big.Float).SetPrec(prec)
+	den := new(big.Float).SetPrec(prec)
+	last := float64(offset + int64(k) + 1)
+	binom := 1.0
+	for j := 0; j <= k; j++ {
+		if terms[j].Sign() == 0 {
+			return nil
+		}
+		// c_j = (-1)^j C(k,j) ((m+j+1)/(m+k+1))^(k-1) / w_{m+j}
+		c := binom * math.Pow(float64(offset+int64(j)+1)/last, float64(k-1))
+		if j%2 == 1 {
+			c = -c
+		}
+		w := new(big.Float).SetPrec(prec).SetFloat64(float64(offset + int64(j) + 1))
+		w.Mul(w, terms[j])
+		cj := new(big.Float).SetPrec(prec).SetFloat64(c)
+		cj.Quo(cj, w)
+		den.Add(den, cj)
+		num.Add(num, new(big.Float).SetPrec(prec).Mul(cj, sums[j]))
+		binom = binom * float64(k-j) / float64(j+1)
+	}
+	if den.Sign() == 0 {
+		return nil
+	}
+	return num.Quo(num, den)
+}
+
+// eulerTail replaces the buffered tail of a strictly alternating series with
+// its Euler transform: sum (-1)^k b_k = sum_k (-1)^k Delta^k b_0 / 2^(k+1).
+func eulerTail(terms, sums []*big.Float, prec uint) (*big.Float, float64) {
+	if len(terms) < 4 {
+		return nil, 0
+	}
+	for i := 1; i < len(terms); i++ {
+		if terms[i].Sign() == 0 || terms[i].Sign() == terms[i-1].Sign() {
+			return nil, 0
+		}
+	}
+	// b_j = |a_j|; the tail starting at terms[0] is sign(a_0) * sum (-1)^j b_j.
+	diffs := make([]*big.Float, len(terms))
+	for i, t := range terms {
+		diffs[i] = new(big.Float).SetPrec(prec).Abs(t)
+	}
+	tail := new(big.Float).SetPrec(prec)
+	scale := new(big.Float).SetPrec(prec).SetFloat64(0.5)
+	half := new(big.Float).SetPrec(prec).SetFloat64(0.5)
+	var lastTerm float64
+	for k := 0; k < len(terms); k++ {
+		// Forward difference of order k is diffs[0] after k passes.
+		v := new(big.Float).SetPrec(prec).Mul(diffs[0], scale)
+		if k%2 == 1 {
+			v.Neg(v)
+		}
+		tail.Add(tail, v)
+		lastTerm = bigAbsF64(v)
+		for i := 0; i < len(diffs)-1-k; i++ {
+			diffs[i].Sub(diffs[i+1], diffs[i])
+		}
+		scale.Mul(scale, half)
+	}
+	if terms[0].Sign() < 0 {
+		tail.Neg(tail)
+	}
+	// sums[0] includes terms[0], so the head is sums[0] - terms[0].
+	est := new(big.Float).SetPrec(prec).Sub(sums[0], terms[0])
+	return est.Add(est, tail), lastTerm
+}
+
+func bigAbsF64(x *big.Float) float64 {
+	f, _ := new(big.Float).Abs(x).Float64()
+	return f
+}
+
+// f64TermWindow is the float64 counterpart of termWindow.
+type f64TermWindow struct {
+	terms [accelWindow]float64
+	sums  [accelWindow]float64
+	count int
+}
+
+func (w *f64TermWindow) push(term, sum float64) {
+	i := w.count % accelWindow
+	w.terms[i] = term
+	w.sums[i] = sum
+	w.count++
+}
+
+func (w *f64TermWindow) ordered() (terms, sums []float64) {
+	n := w.count
+	if n > accelWindow {
+		n = accelWindow
+	}
+	for k := w.count - n; k < w.count; k++ {
+		terms = append(terms, w.terms[k%accelWindow])
+		sums = append(sums, w.sums[k%accelWindow])
+	}
+	return terms, sums
+}
+
+// accelerateF64 mirrors accelerate for the float64 fast path.
+func accelerateF64(cps []float64, win *f64TermWindow, raw float64) (float64, Acceleration) {
+	if len(cps) < 3 {
+		return raw, AccelNone
+	}
+	rawErr := math.Abs(cps[len(cps)-1] - cps[len(cps)-2])
+	if rawErr == 0 || math.IsInf(rawErr, 0) || math.IsNaN(rawErr) {
+		return raw, AccelNone
+	}
+
+	best, bestMethod, bestErr := raw, AccelNone, rawErr
+	consider := func(est, errEst float64, ok bool, m Acceleration) {
+		if !ok || math.IsNaN(est) || math.IsInf(est, 0) || math.IsNaN(errEst) || errEst >= bestErr {
+			return
+		}
+		if math.Abs(est-raw) > accelMaxJump*rawErr {
+			return
+		}
+		best, bestMethod, bestErr = est, m, errEst
+	}
+
+	est, errEst, ok := richardsonF64(cps)
+	consider(est, errEst, ok, AccelRichardson)
+	est, errEst, ok = aitkenF64(cps)
+	consider(est, errEst, ok, AccelAitken)
+
+	terms, sums := win.ordered()
+	offset := int64(win.count - len(terms))
+	est, errEst, ok = levinUF64(terms, sums, offset)
+	consider(est, errEst, ok, AccelLevin)
+	est, errEst, ok = eulerTailF64(terms, sums)
+	consider(est, errEst, ok, AccelEuler)
+
+	return best, bestMethod
+}
+
+func richardsonF64(sums []float64) (float64, float64, bool) {
+	n := len(sums)
+	if n < 3 {
+		return 0, 0, false
+	}
+	d1 := math.Abs(sums[n-1] - sums[n-2])
+	d0 := math.Abs(sums[n-2] - sums[n-3])
+	if d0 == 0 {
+		return 0, 0, false
+	}
+	ratio := d1 / d0
+	if ratio <= 0.01 || ratio >= 1 {
+		return 0, 0, false
+	}
+	p := math.Max(1, math.Round(-math.Log2(ratio)))
+
+	levels := richardsonLevels
+	if levels > n-1 {
+		levels = n - 1
+	}
+	row := append([]float64(nil), sums[n-1-levels:]...)
+	var prevBest float64
+	for j := 1; j <= levels; j++ {
+		factor := math.Exp2(p + float64(j-1))
+		prevBest = row[len(row)-1]
+		for i := 0; i < len(row)-1; i++ {
+			row[i] = (factor*row[i+1] - row[i]) / (factor - 1)
+		}
+		row = row[:len(row)-1]
+	}
+	est := row[len(row)-1]
+	return est, math.Abs(est - prevBest), true
+}
+
+func aitkenF64(sums []float64) (float64, float64, bool) {
+	seq := sums
+	if len(seq) > 7 {
+		seq = seq[len(seq)-7:]
+	}
+	seq = append([]float64(nil), seq...)
+	prev, havePrev := 0.0, false
+	for len(seq) >= 3 {
+		next := make([]float64, 0, len(seq)-2)
+		for i := 2; i < len(seq); i++ {
+			d1 := seq[i] - seq[i-1]
+			d0 := seq[i-1] - seq[i-2]
+			if d1 == d0 {
+				return 0, 0, false
+			}
+			next = append(next, seq[i]-d1*d1/(d1-d0))
+		}
+		prev, havePrev = seq[len(seq)-1], true
+		seq = next
+	}
+	if !havePrev {
+		return 0, 0, false
+	}
+	est := seq[len(seq)-1]
+	return est, math.Abs(est - prev), true
+}
+
+func levinUF64(terms, sums []float64, offset int64) (float64, float64, bool) {
+	if len(terms) < 4 {
+		return 0, 0, false
+	}
+	est, ok1 := levinUOrderF64(terms, sums, offset)
+	prev, ok2 := levinUOrderF64(terms[:len(terms)-1], sums[:len(sums)-1], offset)
+	if !ok1 || !ok2 {
+		return 0, 0, false
+	}
+	return est, math.Abs(est - prev), true
+}
+
+func levinUOrderF64(terms, sums []float64, offset int64) (float64, bool) {
+	k := len(terms) - 1
+	var num, den float64
+	last := float64(offset + int64(k) + 1)
+	binom := 1.0
+	for j := 0; j <= k; j++ {
+		if terms[j] == 0 {
+			return 0, false
+		}
+		c := binom * math.Pow(float64(offset+int64(j)+1)/last, float64(k-1))
+		if j%2 == 1 {
+			c = -c
+		}
+		cj := c / (float64(offset+int64(j)+1) * terms[j])
+		den += cj
+		num += cj * sums[j]
+		binom = binom * float64(k-j) / float64(j+1)
+	}
+	if den == 0 {
+		return 0, false
+	}
+	return num / den, true
+}
+
+func eulerTailF64(terms, sums []float64) (float64, float64, bool) {
+	if len(terms) < 4 {
+		return 0, 0, false
+	}
+	for i := 1; i < len(terms); i++ {
+		if terms[i] == 0 || (terms[i] > 0) == (terms[i-1] > 0) {
+			return 0, 0, false
+		}
+	}
+	diffs := make([]float64, len(terms))
+	for i, t := range terms {
+		diffs[i] = math.Abs(t)
+	}
+	var tail, lastTerm float64
+	scale := 0.5
+	for k := 0; k < len(terms); k++ {
+		v := diffs[0] * scale
+		if k%2 == 1 {
+			v = -v
+		}
+		tail += v
+		lastTerm = math.Abs(v)
+		for i := 0; i < len(diffs)-1-k; i++ {
+			diffs[i] = diffs[i+1] - diffs[i]
+		}
+		scale *= 0.5
+	}
+	if terms[0] < 0 {
+		tail = -tail
+	}
+	return sums[0] - terms[0] + tail, lastTerm, true
+}
@@ -0,0 +1,119 @@
+package series
+
+import (
+	"hash/fnv"
+	"math"
+	"strconv"
+)
+
+// Descriptor describes what a candidate computes rather than how it is
+// written: its first k partial sums, squashed with asinh so that a sum of
+// 1e6 and one of 1e7 are a few units apart rather than millions. Formulas
+// that print differently but sum alike get nearby descriptors. A partial sum
+// that stops early is held at its last value, as evaluation does; nil means
+// the first term already fails.
+func Descriptor(c *Candidate, k int) []float64 {
+	sums := partialSumsF64(c, k)
+	if sums == nil {
+		return nil
+	}
+	for i, s := range sums {
+		sums[i] = math.Asinh(s)
+	}
+	return sums
+}
+
+// partialSumsF64 returns the first k partial sums of c, or nil.
+func partialSumsF64(c *Candidate, k int) []float64 {
+	terms := leadingTermsF64(c, k)
+	if terms == nil {
+		return nil
+	}
+	sum := 0.0
+	for i, t := range terms {
+		sum += t
+		terms[i] = sum
+	}
+	return terms
+}
+
+// leadingTermsF64 returns the first k terms of c, zero from the first term
+// that fails or overflows the sum on, or nil if the first one does. A
+// composite's terms are the weighted sums of its sub-series' terms at the
+// same position.
+func leadingTermsF64(c *Candidate, k int) []float64 {
+	if cc := c.Composite; cc != nil {
+		if len(cc.Terms) == 0 || cc.PrefDen == 0 {
+			return nil
+		}
+		out := make([]float64, k)
+		for i, t := range cc.Terms {
+			sub := leadingTermsF64(t.Series, k)
+			if sub == nil {
+				return nil
+			}
+			for j := range out {
+				out[j] += cc.scale(i) * sub[j]
+			}
+		}
+		return out
+	}
+
+	next := c.termsF64()
+	out := make([]float64, k)
+	sum := 0.0
+	for j := range out {
+		term, ok := next(c.Start + int64(j))
+		if !ok || math.IsInf(sum+term, 0) || math.IsNaN(sum+term) {
+			if j == 0 {
+				return nil
+			}
+			break
+		}
+		sum += term
+		out[j] = term
+	}
+	return out
+}
+
+// fingerprintDigits is the precision, in significant digits, that terms and
+// sums are compared at by Fingerprint.
+const fingerprintDigits = 12
+
+// Fingerprint hashes the first k terms of c and their sum, each rounded to
+// fingerprintDigits significant digits. Candidates that compute the same
+// series share a fingerprint however they are written, as 1/n! and
+// n/(n*n!) do. ok is false when the first term already fails.
+func Fingerprint(c *Candidate, k int) (fp uint64, ok bool) {
+	terms := leadingTermsF64(c, k)
+	if terms == nil {
+		return 0, false
+	}
+	h := fnv.New64a()
+	var buf []byte
+	write := func(x float64) {
+		if x == 0 {
+			x = 0 // fold -0 into 0
+		}
+		buf = strconv.AppendFloat(buf[:0], x, 'g', fingerprintDigits, 64)
+		buf = append(buf, ';')
+		h.Write(buf)
+	}
+	sum := 0.0
+	for _, t := range terms {
+		write(t)
+		sum += t
+	}
+	write(sum)
+	return h.Sum64(), true
+}
+
+// DescriptorDistance is the root-mean-square distance between two
+// descriptors of the same length.
+func DescriptorDistance(a, b []float64) float64 {
+	var d float64
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return math.Sqrt(d / float64(len(a)))
+}
@@ -0,0 +1,72 @@
+package series
+
+import (
+	"math"
+	"math/big"
+)
+
+// Term budgets. A fixed number of terms fits few series: 1/n! has settled to
+// working precision by n = 100, while 1/n^2 is still 3 digits short of its
+// limit at n = 1000. Evaluation stops as soon as a window of trailing terms
+// has dropped below half an ulp of the sum, since no further term can move
+// it, and a series that converges but has not settled to the digit cap, raw
+// or accelerated, keeps going: its budget doubles up to Ceiling.
+
+// Budget bounds how many terms an evaluation sums.
+type Budget struct {
+	Terms   int64 // terms summed before deciding whether to go on
+	Ceiling int64 // most terms a converging series is extended to (<= Terms = never)
+}
+
+// extend returns the term limit to continue to after limit terms, or false
+// when the sum has what it needs or the ceiling is reached.
+func (b Budget) extend(limit int64, r EvalResult) (int64, bool) {
+	if limit >= b.Ceiling || !r.Converged {
+		return limit, false
+	}
+	digitCap := DigitCap(r.PartialSum.Prec(), b.Ceiling)
+	sum, _ := r.PartialSum.Float64()
+	if r.StableDigits >= digitCap || certifiedDigits(r.TailBound, sum, digitCap) >= digitCap {
+		return limit, false
+	}
+	return min(2*limit, b.Ceiling), true
+}
+
+// extendF64 is extend for float64 evaluations, which settle at
+// maxDigitsF64.
+func (b Budget) extendF64(limit int64, r EvalResultF64) (int64, bool) {
+	if limit >= b.Ceiling || !r.Converged || certifiedDigits(r.TailBound, r.PartialSum, maxDigitsF64) >= maxDigitsF64 {
+		return limit, false
+	}
+	return min(2*limit, b.Ceiling), true
+}
+
+// vanishing counts the trailing terms in a row that were below half an ulp
+// of the sum they were added to.
+type vanishing int
+
+// push records a term added to sum, held to prec bits, and reports whether
+// the last accelWindow terms have all vanished. A zero sum never counts as
+// settled, so leading zero terms do not stop a series before it starts.
+func (v *vanishing) push(term, sum *big.Float, prec uint) bool {
+	// |term| < 2^et <= 2^(es-1) 2^-(prec+1) <= |sum| 2^-(prec+1)
+	if sum.Sign() != 0 && (term.Sign() == 0 || term.MantExp(nil) <= sum.MantExp(nil)-int(prec)-2) {
+		*v++
+	} else {
+		*v = 0
+	}
+	return v.settled()
+}
+
+// pushF64 is push for float64 sums.
+func (v *vanishing) pushF64(term, sum float64) bool {
+	if sum != 0 && math.Abs(term) < math.Abs(sum)*0x1p-54 {
+		*v++
+	} else {
+		*v = 0
+	}
+	return v.settled()
+}
+
+// settled reports whether the last accelWindow terms have all vanished.
+func (v vanishing) settled() bool { return v >= accelWindow }
@@ -6,38 +6,169 @@ import (
 	"github.com/wildfunctions/genetic_series/pkg/expr"
 )
 
-// Candidate represents a candidate series: Sum_{n=Start}^{inf} Numerator(n) / Denominator(n)
+// Candidate represents a candidate series: Sum_{n=Start}^{inf} Numerator(n) / Denominator(n),
+// or, when Composite is set, a weighted sum of such series (the trees are then nil).
+// Form selects a product or continued fraction over the same two trees instead.
+// When Correction is set the candidate is the limit of the partial sums minus
+// Correction(N); see difference.go.
 type Candidate struct {
 	Numerator   expr.ExprNode
 	Denominator expr.ExprNode
 	Start       int64 // starting index (0 or 1 typically)
+	Form        Form
+
+	// Hyper, when set, is the term-ratio genome the trees were built from.
+	// Evaluation then runs the ratio recurrence instead of the trees.
+	Hyper *HyperGenome
+
+	Correction expr.ExprNode // g(N) subtracted from the N-th partial sum, or nil
+
+	Composite *CompositeCandidate
 }
 
 // Clone returns a deep copy of the candidate.
 func (c *Candidate) Clone() *Candidate {
-	return &Candidate{
+	if c.Composite != nil {
+		return &Candidate{Composite: c.Composite.Clone()}
+	}
+	out := &Candidate{
 		Numerator:   c.Numerator.Clone(),
 		Denominator: c.Denominator.Clone(),
 		Start:       c.Start,
+		Form:        c.Form,
+		Hyper:       c.Hyper.Clone(),
 	}
+	if c.Correction != nil {
+		out.Correction = c.Correction.Clone()
+	}
+	return out
 }
 
 // String returns a human-readable representation.
 func (c *Candidate) String() string {
+	if c.Composite != nil {
+		return c.Composite.String()
+	}
+	if c.Correction != nil {
+		return c.differenceString()
+	}
+	if c.Form != FormSum {
+		return c.formString()
+	}
 	return fmt.Sprintf("Sum_{n=%d}^{inf} (%s) / (%s)", c.Start, c.Numerator.String(), c.Denominator.String())
 }
 
 // LaTeX returns a LaTeX representation.
 func (c *Candidate) LaTeX() string {
+	if c.Composite != nil {
+		return c.Composite.LaTeX()
+	}
+	if c.Correction != nil {
+		return c.differenceLaTeX()
+	}
+	if c.Form != FormSum {
+		return c.formLaTeX()
+	}
 	return fmt.Sprintf("\\sum_{n=%d}^{\\infty} \\frac{%s}{%s}", c.Start, c.Numerator.LaTeX(), c.Denominator.LaTeX())
 }
 
-// Complexity returns combined complexity of both trees.
+// Complexity returns combined complexity of both trees and the correction.
 func (c *Candidate) Complexity() float64 {
-	return expr.WeightedComplexity(c.Numerator) + expr.WeightedComplexity(c.Denominator)
+	if c.Composite != nil {
+		return c.Composite.Complexity()
+	}
+	total := expr.WeightedComplexity(c.Numerator) + expr.WeightedComplexity(c.Denominator)
+	if c.Correction != nil {
+		total += expr.WeightedComplexity(c.Correction)
+	}
+	return total
 }
 
-// NodeCount returns the total node count of both trees.
+// NodeCount returns the total node count of both trees and the correction.
 func (c *Candidate) NodeCount() int {
-	return c.Numerator.NodeCount() + c.Denominator.NodeCount()
+	if c.Composite != nil {
+		return c.Composite.NodeCount()
+	}
+	total := c.Numerator.NodeCount() + c.Denominator.NodeCount()
+	if c.Correction != nil {
+		total += c.Correction.NodeCount()
+	}
+	return total
+}
+
+// CancelCommon cancels the factors the numerator and denominator of each of
+// c's series share, as in n/(n*n!) = 1/n!, where that leaves the series no
+// more complex. Continued fractions, whose trees are not a ratio, and
+// hypergeometric candidates, whose trees mirror their genome, are left as
+// they are.
+func (c *Candidate) CancelCommon() {
+	for _, s := range c.plainSeries() {
+		if s.Form == FormCF || s.Hyper != nil {
+			continue
+		}
+		num, den, ok := expr.CancelRatio(s.Numerator, s.Denominator)
+		if ok && expr.WeightedComplexity(num)+expr.WeightedComplexity(den) <=
+			expr.WeightedComplexity(s.Numerator)+expr.WeightedComplexity(s.Denominator) {
+			s.Numerator, s.Denominator = num, den
+		}
+	}
+}
+
+// CanonicalString is String with every tree in canonical form and common
+// factors cancelled, however complex that makes them. Candidates whose terms
+// are the same rational function of n, or differ only in how non-rational
+// parts are multiplied and added, share it.
+func (c *Candidate) CanonicalString() string {
+	out := c.Clone()
+	for _, s := range out.plainSeries() {
+		if s.Form == FormCF || s.Hyper != nil {
+			s.Numerator, _ = expr.Canonical(s.Numerator)
+			s.Denominator, _ = expr.Canonical(s.Denominator)
+		} else {
+			s.Numerator, s.Denominator, _ = expr.CancelRatio(s.Numerator, s.Denominator)
+		}
+		if s.Correction != nil {
+			s.Correction, _ = expr.Canonical(s.Correction)
+		}
+	}
+	return out.String()
+}
+
+// plainSeries returns the plain series making up c: its composite terms, or c
+// itself.
+func (c *Candidate) plainSeries() []*Candidate {
+	if c.Composite == nil {
+		return []*Candidate{c}
+	}
+	out := make([]*Candidate, len(c.Composite.Terms))
+	for i, t := range c.Composite.Terms {
+		out[i] = t.Series
+	}
+	return out
+}
+
+// degenerate reports whether the candidate has a series whose denominator
+// does not depend on n. Its terms then don't shrink to zero and it diverges.
+// A correction that does not depend on N can't cancel a divergence either.
+// A product needs its factors to depend on n to tend to 1; a continued
+// fraction with constant parts is periodic and fine.
+func (c *Candidate) degenerate() bool {
+	if c.Composite != nil {
+		for _, t := range c.Composite.Terms {
+			if t.Series.degenerate() {
+				return true
+			}
+		}
+		return false
+	}
+	if c.Correction != nil && !expr.ContainsVar(c.Correction) {
+		return true
+	}
+	switch c.Form {
+	case FormProduct:
+		return !expr.ContainsVar(c.Numerator) && !expr.ContainsVar(c.Denominator)
+	case FormCF:
+		return false
+	}
+	return !expr.ContainsVar(c.Denominator)
 }
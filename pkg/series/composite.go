@@ -0,0 +1,213 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// WeightedSeries is one integer-weighted sub-series of a composite.
+type WeightedSeries struct {
+	Weight int64
+	Series *Candidate
+}
+
+// CompositeCandidate is a rational prefactor times a weighted sum of series:
+// (PrefNum/PrefDen) * Σ_k Terms[k].Weight * Terms[k].Series.
+// Identities like pi = 4*Sum(...) - Sum(...) need more than one series.
+type CompositeCandidate struct {
+	PrefNum, PrefDen int64
+	Terms            []WeightedSeries
+}
+
+// NewComposite wraps sub-series into a candidate. Weights default to 1 and
+// the prefactor to 1/1.
+func NewComposite(terms ...*Candidate) *Candidate {
+	cc := &CompositeCandidate{PrefNum: 1, PrefDen: 1}
+	for _, t := range terms {
+		cc.Terms = append(cc.Terms, WeightedSeries{Weight: 1, Series: t})
+	}
+	return &Candidate{Composite: cc}
+}
+
+// Clone returns a deep copy of the composite.
+func (cc *CompositeCandidate) Clone() *CompositeCandidate {
+	if cc == nil {
+		return nil
+	}
+	out := &CompositeCandidate{PrefNum: cc.PrefNum, PrefDen: cc.PrefDen}
+	for _, t := range cc.Terms {
+		out.Terms = append(out.Terms, WeightedSeries{Weight: t.Weight, Series: t.Series.Clone()})
+	}
+	return out
+}
+
+// String returns a human-readable representation.
+func (cc *CompositeCandidate) String() string {
+	var b strings.Builder
+	for i, t := range cc.Terms {
+		writeWeight(&b, i, t.Weight, " * ")
+		b.WriteString(t.Series.String())
+	}
+	switch {
+	case cc.PrefNum == 1 && cc.PrefDen == 1:
+		return b.String()
+	case cc.PrefDen == 1:
+		return fmt.Sprintf("%d * (%s)", cc.PrefNum, b.String())
+	default:
+		return fmt.Sprintf("%d/%d * (%s)", cc.PrefNum, cc.PrefDen, b.String())
+	}
+}
+
+// LaTeX returns a LaTeX representation that ParseCandidateLatex reads back.
+func (cc *CompositeCandidate) LaTeX() string {
+	var b strings.Builder
+	for i, t := range cc.Terms {
+		writeWeight(&b, i, t.Weight, " ")
+		b.WriteString(t.Series.LaTeX())
+	}
+	pref := ""
+	switch {
+	case cc.PrefNum == 1 && cc.PrefDen == 1:
+		return b.String()
+	case cc.PrefDen == 1:
+		pref = fmt.Sprintf("%d", cc.PrefNum)
+	case cc.PrefNum < 0:
+		pref = fmt.Sprintf(`-\frac{%d}{%d}`, -cc.PrefNum, cc.PrefDen)
+	default:
+		pref = fmt.Sprintf(`\frac{%d}{%d}`, cc.PrefNum, cc.PrefDen)
+	}
+	return pref + ` \left(` + b.String() + `\right)`
+}
+
+// writeWeight writes the sign and weight leading term i.
+func writeWeight(b *strings.Builder, i int, w int64, mul string) {
+	switch {
+	case i > 0 && w < 0:
+		b.WriteString(" - ")
+		w = -w
+	case i > 0:
+		b.WriteString(" + ")
+	case w < 0:
+		b.WriteString("-")
+		w = -w
+	}
+	if w != 1 {
+		fmt.Fprintf(b, "%d%s", w, mul)
+	}
+}
+
+// Complexity sums the sub-series complexities plus the cost of the weights,
+// the prefactor and the additions joining them.
+func (cc *CompositeCandidate) Complexity() float64 {
+	total := float64(len(cc.Terms) - 1)
+	for _, t := range cc.Terms {
+		total += t.Series.Complexity() + coefComplexity(t.Weight)
+	}
+	return total + coefComplexity(cc.PrefNum) + coefComplexity(cc.PrefDen)
+}
+
+// coefComplexity is the cost of multiplying by an integer; 1 and -1 are free.
+func coefComplexity(v int64) float64 {
+	if v == 1 || v == -1 {
+		return 0
+	}
+	return 1 + expr.WeightedComplexity(&expr.ConstNode{Val: v})
+}
+
+// NodeCount returns the total node count of all sub-series.
+func (cc *CompositeCandidate) NodeCount() int {
+	total := 0
+	for _, t := range cc.Terms {
+		total += t.Series.NodeCount()
+	}
+	return total
+}
+
+// scale returns the factor sub-series i enters the sum with.
+func (cc *CompositeCandidate) scale(i int) float64 {
+	return float64(cc.Terms[i].Weight) * float64(cc.PrefNum) / float64(cc.PrefDen)
+}
+
+// evaluate evaluates every sub-series and combines them. The result
+// converges only if every sub-series does, and as slowly as the slowest; its
+// error estimate and tail bound are the weighted sums of theirs.
+func (cc *CompositeCandidate) evaluate(b Budget, prec uint) EvalResult {
+	if len(cc.Terms) == 0 || cc.PrefDen == 0 {
+		return EvalResult{OK: false}
+	}
+	out := EvalResult{
+		PartialSum:     new(big.Float).SetPrec(prec),
+		AcceleratedSum: new(big.Float).SetPrec(prec),
+		Converged:      true,
+		OK:             true,
+	}
+	errEst := 0.0
+	for i, t := range cc.Terms {
+		r := evaluateBudget(t.Series, b, prec)
+		if !r.OK {
+			return EvalResult{OK: false}
+		}
+		w := new(big.Float).SetPrec(prec).SetInt64(t.Weight)
+		out.PartialSum.Add(out.PartialSum, new(big.Float).SetPrec(prec).Mul(w, r.PartialSum))
+		out.AcceleratedSum.Add(out.AcceleratedSum, new(big.Float).SetPrec(prec).Mul(w, r.AcceleratedSum))
+		if out.Acceleration == AccelNone {
+			out.Acceleration = r.Acceleration
+		}
+		out.TermsComputed = max(out.TermsComputed, r.TermsComputed)
+		out.ConvergenceRate = math.Max(out.ConvergenceRate, r.ConvergenceRate)
+		out.Converged = out.Converged && r.Converged
+		if i == 0 || r.Convergence < out.Convergence {
+			out.Convergence, out.DecayRate = r.Convergence, r.DecayRate
+		}
+		errEst += math.Abs(cc.scale(i)) * math.Pow(10, -r.StableDigits)
+		out.TailBound += math.Abs(cc.scale(i)) * r.TailBound
+		if i == 0 || r.TailMethod == TailNone {
+			out.TailMethod = r.TailMethod
+		}
+	}
+	for _, s := range []*big.Float{out.PartialSum, out.AcceleratedSum} {
+		s.Mul(s, new(big.Float).SetPrec(prec).SetInt64(cc.PrefNum))
+		s.Quo(s, new(big.Float).SetPrec(prec).SetInt64(cc.PrefDen))
+	}
+	if out.Converged {
+		out.StableDigits = stableDigits(errEst, prec)
+	}
+	return out
+}
+
+// evaluateF64 is evaluate in float64.
+func (cc *CompositeCandidate) evaluateF64(b Budget) EvalResultF64 {
+	if len(cc.Terms) == 0 || cc.PrefDen == 0 {
+		return EvalResultF64{OK: false}
+	}
+	out := EvalResultF64{Converged: true, OK: true}
+	for i, t := range cc.Terms {
+		r := evaluateBudgetF64(t.Series, b)
+		if !r.OK {
+			return EvalResultF64{OK: false}
+		}
+		out.PartialSum += cc.scale(i) * r.PartialSum
+		out.AcceleratedSum += cc.scale(i) * r.AcceleratedSum
+		if out.Acceleration == AccelNone {
+			out.Acceleration = r.Acceleration
+		}
+		out.TermsComputed = max(out.TermsComputed, r.TermsComputed)
+		out.ConvergenceRate = math.Max(out.ConvergenceRate, r.ConvergenceRate)
+		out.Converged = out.Converged && r.Converged
+		if i == 0 || r.Convergence < out.Convergence {
+			out.Convergence, out.DecayRate = r.Convergence, r.DecayRate
+		}
+		out.TailBound += math.Abs(cc.scale(i)) * r.TailBound
+		if i == 0 || r.TailMethod == TailNone {
+			out.TailMethod = r.TailMethod
+		}
+	}
+	if math.IsInf(out.PartialSum, 0) || math.IsNaN(out.PartialSum) {
+		return EvalResultF64{OK: false}
+	}
+	return out
+}
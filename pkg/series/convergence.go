@@ -0,0 +1,232 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Convergence classification. The checkpoint differences |S_2N - S_N| alone
+// cannot tell a slowly converging series from a log-divergent one, nor an
+// oscillating one from a converging one with a bumpy start. The term
+// magnitudes at the checkpoints can: log|a_N| falls like -p ln N for
+// algebraic decay, like N ln r for geometric decay, and faster still for
+// factorial-like decay. Comparing the drops over the last two checkpoint
+// intervals tells these apart; the signs of the trailing terms and the
+// checkpoint differences settle the rest.
+
+// ConvergenceClass is the asymptotic behaviour of a series. The convergent
+// classes come last, slowest first.
+type ConvergenceClass int
+
+const (
+	ConvUnknown        ConvergenceClass = iota // too few checkpoints, or no asymptotic regime yet
+	ConvDivergent                              // terms do not shrink fast enough
+	ConvOscillating                            // partial sums keep moving without settling
+	ConvAlgebraic                              // terms of one sign decaying like n^-p, p > 1
+	ConvAlternating                            // alternating terms decaying like n^-p
+	ConvGeometric                              // terms decaying like r^n
+	ConvSuperGeometric                         // faster than any r^n, e.g. 1/n!
+)
+
+var convNames = map[ConvergenceClass]string{
+	ConvUnknown:        "unknown",
+	ConvDivergent:      "divergent",
+	ConvOscillating:    "oscillating",
+	ConvAlgebraic:      "algebraic",
+	ConvAlternating:    "alternating",
+	ConvGeometric:      "geometric",
+	ConvSuperGeometric: "super-geometric",
+}
+
+func (c ConvergenceClass) String() string { return convNames[c] }
+
+// Converges reports whether c is a convergent class.
+func (c ConvergenceClass) Converges() bool { return c >= ConvAlgebraic }
+
+func (c ConvergenceClass) MarshalText() ([]byte, error) { return []byte(c.String()), nil }
+
+func (c *ConvergenceClass) UnmarshalText(b []byte) error {
+	for k, name := range convNames {
+		if name == string(b) {
+			*c = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown convergence class %q", b)
+}
+
+const (
+	// algebraicMinP is the decay exponent one-sign algebraic terms must
+	// exceed: the margin above 1 keeps harmonic-like series out.
+	algebraicMinP = 1.05
+	// logDivergentP caps the exponents checked for drifting down towards 1,
+	// as the local exponent of 1/(n ln n) does, by more than pDriftTol per
+	// doubling.
+	logDivergentP = 1.5
+	pDriftTol     = 1e-3
+	// geometricMinGrowth is the growth of the log-log slope over one
+	// doubling that marks geometric decay: it doubles for r^n and stays
+	// put for n^-p.
+	geometricMinGrowth = 1.5
+	// superGeometricDrop is how much the per-term log ratio must fall over
+	// a doubling for decay to count as faster than geometric; it falls by
+	// ln 2 for 1/n!.
+	superGeometricDrop = 0.25
+)
+
+// trend is what classification looks at.
+type trend struct {
+	n     []float64 // term counts at the checkpoints
+	logs  []float64 // log|a_N| at the checkpoints, NaN where lost in rounding
+	diffs []float64 // |S_2N - S_N| between consecutive checkpoints
+	signs []int     // signs of the trailing terms
+}
+
+// classify assigns a convergence class. The rate is the per-term ratio r for
+// geometric and super-geometric decay, the exponent p for algebraic and
+// alternating decay, and 0 otherwise. settles is the verdict of the
+// checkpoint differences (analyzeConvergence), used where the signs of the
+// terms are irregular.
+func (t trend) classify(settles bool) (ConvergenceClass, float64) {
+	if len(t.diffs) < 2 {
+		return ConvUnknown, 0
+	}
+	alternating, oneSign := len(t.signs) >= 4, len(t.signs) >= 4
+	for j := 1; j < len(t.signs); j++ {
+		alternating = alternating && t.signs[j] != 0 && t.signs[j] == -t.signs[j-1]
+		oneSign = oneSign && t.signs[j] != 0 && t.signs[j] == t.signs[j-1]
+	}
+
+	// The last three checkpoints whose terms survived rounding.
+	var pts []int
+	for i := len(t.logs) - 1; i >= 0 && len(pts) < 3; i-- {
+		if !math.IsNaN(t.logs[i]) && !math.IsInf(t.logs[i], 0) {
+			pts = append([]int{i}, pts...)
+		}
+	}
+	if len(pts) < 3 {
+		// The terms vanished within a few checkpoints.
+		if t.diffs[len(t.diffs)-1] == 0 {
+			return ConvSuperGeometric, 0
+		}
+		return ConvUnknown, 0
+	}
+	n0, n1, n2 := t.n[pts[0]], t.n[pts[1]], t.n[pts[2]]
+	l0, l1, l2 := t.logs[pts[0]], t.logs[pts[1]], t.logs[pts[2]]
+	p1 := -(l1 - l0) / math.Log(n1/n0)
+	p2 := -(l2 - l1) / math.Log(n2/n1)
+	g1 := (l1 - l0) / (n1 - n0)
+	g2 := (l2 - l1) / (n2 - n1)
+
+	if p2 <= 0 {
+		if oneSign {
+			return ConvDivergent, 0
+		}
+		return ConvOscillating, 0
+	}
+
+	class, rate := ConvAlgebraic, p2
+	if p1 <= 0 || p2/p1 >= geometricMinGrowth {
+		class, rate = ConvGeometric, math.Exp(g2)
+		if g2 < g1-superGeometricDrop {
+			class = ConvSuperGeometric
+		}
+	}
+
+	logDivergent := p2 <= algebraicMinP || p2 < logDivergentP && p2 < p1-pDriftTol
+	switch {
+	case class != ConvAlgebraic:
+	case alternating:
+		class = ConvAlternating
+	case oneSign && logDivergent:
+		return ConvDivergent, 0
+	case oneSign, settles, p2 > algebraicMinP:
+		// Irregular signs: absolutely convergent, or settling anyway.
+	default:
+		return ConvOscillating, 0
+	}
+
+	// Decay that looks convergent while the partial sums still move further
+	// each doubling has not reached its asymptotic regime.
+	if d := t.diffs; d[len(d)-2] > 0 && d[len(d)-1] >= d[len(d)-2] {
+		return ConvUnknown, 0
+	}
+	return class, rate
+}
+
+// termLog returns log|term| for a checkpoint, or NaN when the term is lost
+// in the rounding of a sum held to prec bits (prec 0 for exact sums).
+func termLog(term, sum *big.Float, prec uint) float64 {
+	l := bigLogAbs(term)
+	if prec > 0 && l <= bigLogAbs(sum)-float64(prec-floorUlps)*math.Ln2 {
+		return math.NaN()
+	}
+	return l
+}
+
+// termLogF64 is termLog for float64 sums.
+func termLogF64(term, sum float64) float64 {
+	l := math.Log(math.Abs(term))
+	if l <= math.Log(math.Abs(sum))-(53-floorUlps)*math.Ln2 {
+		return math.NaN()
+	}
+	return l
+}
+
+// checkpointTrend collects the trend of a big.Float evaluation.
+func checkpointTrend(cps []checkpoint, win *termWindow, prec uint) trend {
+	var t trend
+	for i, cp := range cps {
+		t.n = append(t.n, float64(cp.terms))
+		t.logs = append(t.logs, cp.termLog)
+		if i > 0 {
+			d := new(big.Float).SetPrec(prec).Sub(cp.sum, cps[i-1].sum)
+			t.diffs = append(t.diffs, bigAbsF64(d))
+		}
+	}
+	terms, _ := win.ordered()
+	for _, term := range terms {
+		t.signs = append(t.signs, term.Sign())
+	}
+	return t
+}
+
+// checkpointTrendF64 collects the trend of a float64 evaluation.
+func checkpointTrendF64(sums, logs []float64, win *f64TermWindow) trend {
+	t := trend{logs: logs}
+	for i := range sums {
+		t.n = append(t.n, math.Exp2(float64(i)))
+		if i > 0 {
+			t.diffs = append(t.diffs, math.Abs(sums[i]-sums[i-1]))
+		}
+	}
+	terms, _ := win.ordered()
+	for _, term := range terms {
+		switch {
+		case term > 0:
+			t.signs = append(t.signs, 1)
+		case term < 0:
+			t.signs = append(t.signs, -1)
+		default:
+			t.signs = append(t.signs, 0)
+		}
+	}
+	return t
+}
+
+// convergenceScore is the fitness credit for converging fast, in digits
+// gained per term and at most 1: -log10 r for geometric decay, full credit
+// beyond it, none for algebraic decay.
+func convergenceScore(class ConvergenceClass, rate float64) float64 {
+	switch class {
+	case ConvSuperGeometric:
+		return 1
+	case ConvGeometric:
+		if rate <= 0 {
+			return 1
+		}
+		return math.Max(0, math.Min(1, -math.Log10(rate)))
+	}
+	return 0
+}
@@ -0,0 +1,167 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// A difference candidate is lim_{N->inf} [Sum_{n=Start}^{N} Numerator(n)/Denominator(n) - Correction(N)].
+// The sum diverges on its own; the correction cancels the divergence, as ln N
+// does for the harmonic numbers in Euler's gamma = lim (H_N - ln N).
+//
+// Evaluation feeds the differences D_N - D_{N-1} = a_N - (g(N) - g(N-1)) to
+// the ordinary summation loop, so the checkpoints, the convergence test and
+// the acceleration all see the sequence D_N.
+
+// differenceMaxRatio bounds how much the last checkpoint difference may shrink
+// by for a difference to count as converged. A correction that is off by
+// c*ln N leaves differences tending to c*ln 2, a ratio tending to 1 that the
+// average-ratio test for sums can let through.
+const differenceMaxRatio = 0.9
+
+// differenceSettles checks the last three checkpoints of a difference.
+func differenceSettles(cps []checkpoint, prec uint) bool {
+	if len(cps) < 3 {
+		return false
+	}
+	k := len(cps) - 1
+	d0 := new(big.Float).SetPrec(prec).Sub(cps[k-1].sum, cps[k-2].sum)
+	d1 := new(big.Float).SetPrec(prec).Sub(cps[k].sum, cps[k-1].sum)
+	f0, _ := d0.Abs(d0).Float64()
+	f1, _ := d1.Abs(d1).Float64()
+	return f0 == 0 || f1/f0 < differenceMaxRatio
+}
+
+// differenceSettlesF64 is differenceSettles in float64.
+func differenceSettlesF64(cps []float64) bool {
+	if len(cps) < 3 {
+		return false
+	}
+	k := len(cps) - 1
+	d0, d1 := math.Abs(cps[k-1]-cps[k-2]), math.Abs(cps[k]-cps[k-1])
+	return d0 == 0 || d1/d0 < differenceMaxRatio
+}
+
+// differenceTerms wraps the term source of a difference candidate. The first
+// term is a_s - g(s); each later term is a_i - g(i) + g(i-1).
+func differenceTerms(c *Candidate, next func(int64) (*big.Float, bool), prec uint) func(int64) (*big.Float, bool) {
+	n := new(big.Float).SetPrec(prec)
+	var prev *big.Float
+	return func(i int64) (*big.Float, bool) {
+		term, ok := next(i)
+		if !ok {
+			return nil, false
+		}
+		g, ok := c.Correction.Eval(n.SetInt64(i), prec)
+		if !ok || g.IsInf() {
+			return nil, false
+		}
+		term.Sub(term, g)
+		if prev != nil {
+			term.Add(term, prev)
+		}
+		prev = g
+		return term, true
+	}
+}
+
+// differenceTermsF64 is differenceTerms in float64.
+func differenceTermsF64(c *Candidate, next func(int64) (float64, bool)) func(int64) (float64, bool) {
+	var prev float64
+	first := true
+	return func(i int64) (float64, bool) {
+		term, ok := next(i)
+		if !ok {
+			return 0, false
+		}
+		g, ok := c.Correction.EvalF64(float64(i))
+		if !ok {
+			return 0, false
+		}
+		term -= g
+		if !first {
+			term += prev
+		}
+		prev, first = g, false
+		return term, true
+	}
+}
+
+// limitVar prints the correction's variable as N, the sum's upper limit.
+type limitVar struct{ expr.VarNode }
+
+func (*limitVar) String() string { return "N" }
+func (*limitVar) LaTeX() string  { return "N" }
+
+// inN returns a copy of node with its variable printed as N.
+func inN(node expr.ExprNode) expr.ExprNode {
+	switch n := node.(type) {
+	case *expr.VarNode:
+		return &limitVar{}
+	case *expr.UnaryNode:
+		return &expr.UnaryNode{Op: n.Op, Child: inN(n.Child)}
+	case *expr.BinaryNode:
+		return &expr.BinaryNode{Op: n.Op, Left: inN(n.Left), Right: inN(n.Right)}
+	default:
+		return node
+	}
+}
+
+func (c *Candidate) differenceString() string {
+	return fmt.Sprintf("lim_{N->inf} (Sum_{n=%d}^{N} (%s) / (%s) - %s)",
+		c.Start, c.Numerator.String(), c.Denominator.String(), inN(c.Correction).String())
+}
+
+func (c *Candidate) differenceLaTeX() string {
+	g := inN(c.Correction).LaTeX()
+	if _, signs := topLevelSums(g); len(signs) > 0 {
+		g = "{" + g + "}" // so the minus before it is the last top-level sign
+	}
+	return fmt.Sprintf(`\lim_{N \to \infty} \left(\sum_{n=%d}^{N} \frac{%s}{%s} - %s\right)`,
+		c.Start, c.Numerator.LaTeX(), c.Denominator.LaTeX(), g)
+}
+
+// parseDifferenceLatex parses
+//
+//	\lim_{N \to \infty} \left(\sum_{n=0}^{N} BODY - CORRECTION\right)
+//
+// where the limit variable may be any single letter other than the
+// summation variable.
+func parseDifferenceLatex(s string) (*Candidate, error) {
+	rest := strings.TrimPrefix(s, `\lim_{`)
+	end := strings.Index(rest, "}")
+	if end < 1 || strings.ReplaceAll(rest[1:end], " ", "") != `\to\infty` {
+		return nil, fmt.Errorf(`expected \lim_{N \to \infty}`)
+	}
+	limVar := rest[:1]
+	rest = strings.TrimSpace(rest[end+1:])
+	if !strings.HasPrefix(rest, `\left(`) || !strings.HasSuffix(rest, `\right)`) ||
+		matchingClose(rest, len(`\left`)) != len(rest)-1 {
+		return nil, fmt.Errorf(`expected \left( ... \right) after \lim`)
+	}
+	inner := strings.TrimSpace(rest[len(`\left(`) : len(rest)-len(`\right)`)])
+
+	_, signs := topLevelSums(inner)
+	if len(signs) == 0 || inner[signs[len(signs)-1]] != '-' {
+		return nil, fmt.Errorf("expected SUM - CORRECTION inside the limit")
+	}
+	sep := signs[len(signs)-1]
+	sum, corr := strings.TrimSpace(inner[:sep]), strings.TrimSpace(inner[sep+1:])
+
+	upper := "^{" + limVar + "}"
+	if !strings.Contains(sum, upper) {
+		return nil, fmt.Errorf("expected the sum to run up to %s", limVar)
+	}
+	c, err := parseSeriesLatex(strings.Replace(sum, upper, `^{\infty}`, 1))
+	if err != nil {
+		return nil, err
+	}
+	if c.Correction, err = expr.ParseExprLatex(strings.ReplaceAll(corr, limVar, "n")); err != nil {
+		return nil, fmt.Errorf("parsing correction: %w", err)
+	}
+	return c, nil
+}
@@ -4,12 +4,23 @@ import (
 	"math"
 	"math/big"
 	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 )
 
 // EvalResult holds the result of evaluating a candidate's partial sum.
 type EvalResult struct {
 	PartialSum      *big.Float
-	TermsComputed   int64
+	ExactSum        *big.Rat         // PartialSum without rounding, when evaluated exactly (see exact.go)
+	AcceleratedSum  *big.Float       // limit estimate after acceleration (== PartialSum when none helps)
+	Acceleration    Acceleration     // method that produced AcceleratedSum
+	StableDigits    float64          // decimal digits to which AcceleratedSum has settled
+	Convergence     ConvergenceClass // asymptotic behaviour (see convergence.go)
+	DecayRate       float64          // r per term for geometric classes, exponent p for algebraic ones
+	TailBound       float64          // bound on |limit - PartialSum| from the trailing terms (see tail.go)
+	TailMethod      TailMethod       // test behind TailBound
+	TermsComputed   int64            // terms actually summed
+	Elapsed         time.Duration    // time the evaluation took
 	Converged       bool
 	ConvergenceRate float64 // average ratio of |S_{2N} - S_N| decrease per doubling
 	OK              bool
@@ -19,77 +30,199 @@ type EvalResult struct {
 const evalTimeout = 100 * time.Millisecond
 
 // EvaluateCandidate computes the partial sum of a candidate series up to maxTerms,
-// using checkpoints at powers of 2 for convergence detection.
+// using checkpoints at powers of 2 for convergence detection. Convergent series
+// also get an accelerated limit estimate built from the checkpoints and the
+// trailing terms. For products and continued fractions PartialSum is the
+// partial product or the convergent (see form.go); for a difference candidate
+// it is the partial sum minus the correction. Candidates with rational terms
+// are summed exactly while their sums stay small enough.
 func EvaluateCandidate(c *Candidate, maxTerms int64, prec uint) EvalResult {
+	return EvaluateCandidateBudget(c, Budget{Terms: maxTerms}, prec)
+}
+
+// EvaluateCandidateBudget is EvaluateCandidate under a term budget (see
+// budget.go): summing stops once the terms vanish below the precision, and a
+// converging series that has not settled is summed on up to b.Ceiling.
+func EvaluateCandidateBudget(c *Candidate, b Budget, prec uint) EvalResult {
+	start := time.Now()
+	r := evaluateBudget(c, b, prec)
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+func evaluateBudget(c *Candidate, b Budget, prec uint) EvalResult {
+	if c.Composite != nil {
+		return c.Composite.evaluate(b, prec)
+	}
+	if r, ok := evaluateExact(c, b, prec); ok {
+		return r
+	}
+	next := c.terms(prec)
+
 	sum := new(big.Float).SetPrec(prec)
-	n := new(big.Float).SetPrec(prec)
 
 	// Track partial sums at checkpoints (powers of 2)
 	var checkpoints []checkpoint
 	nextCheckpoint := int64(1)
+	window := newTermWindow(prec)
+	var vanish vanishing
 
 	var termsComputed int64
 	deadline := time.Now().Add(evalTimeout)
 
-	for i := c.Start; i < c.Start+maxTerms; i++ {
-		if time.Now().After(deadline) {
-			return EvalResult{OK: false}
-		}
-
-		n.SetInt64(i)
-
-		num, ok := c.Numerator.Eval(n, prec)
-		if !ok {
-			break // term failed — use partial sum so far
+	i, limit := c.Start, b.Terms
+	for {
+		stopped := false
+		for ; i < c.Start+limit && !stopped; i++ {
+			if time.Now().After(deadline) {
+				if limit == b.Terms {
+					return EvalResult{OK: false}
+				}
+				break // out of time extending: keep what we have
+			}
+
+			term, ok := next(i)
+			if !ok {
+				stopped = true // term failed — use partial sum so far
+				break
+			}
+
+			sum.Add(sum, term)
+			termsComputed++
+			window.push(term, sum)
+
+			// Record checkpoint at powers of 2 (relative to start)
+			offset := i - c.Start + 1
+			if offset == nextCheckpoint {
+				checkpoints = append(checkpoints, checkpoint{
+					terms:   offset,
+					sum:     new(big.Float).SetPrec(prec).Copy(sum),
+					termLog: termLog(term, sum, prec),
+				})
+				nextCheckpoint *= 2
+			}
+			stopped = vanish.push(term, sum, prec)
 		}
 
-		den, ok := c.Denominator.Eval(n, prec)
-		if !ok {
-			break
+		// Need at least a few terms for a meaningful result
+		if termsComputed < 4 {
+			return EvalResult{OK: false}
 		}
 
-		if den.Sign() == 0 {
-			break
+		r := analyze(c, checkpoints, window, sum, termsComputed, vanish.settled(), prec, prec)
+		if stopped || time.Now().After(deadline) {
+			return r
 		}
-
-		term := new(big.Float).SetPrec(prec).Quo(num, den)
-		sum.Add(sum, term)
-		termsComputed++
-
-		// Record checkpoint at powers of 2 (relative to start)
-		offset := i - c.Start + 1
-		if offset == nextCheckpoint {
-			checkpoints = append(checkpoints, checkpoint{
-				terms: offset,
-				sum:   new(big.Float).SetPrec(prec).Copy(sum),
-			})
-			nextCheckpoint *= 2
+		var more bool
+		if limit, more = b.extend(limit, r); !more {
+			return r
 		}
 	}
+}
 
-	// Need at least a few terms for a meaningful result
-	if termsComputed < 4 {
-		return EvalResult{OK: false}
+// analyze classifies and accelerates a sum of terms terms held to prec bits.
+// vanished is whether the trailing terms dropped below the precision, which
+// leaves nothing to accelerate. tailPrec is the precision the tail bound's
+// rounding floor is taken at, 0 for a sum without rounding.
+func analyze(c *Candidate, checkpoints []checkpoint, window *termWindow, sum *big.Float, terms int64, vanished bool, prec, tailPrec uint) EvalResult {
+	// Classify convergence from the checkpoints and the trailing terms
+	settles, rate := analyzeConvergence(checkpoints, prec)
+	class, decay := checkpointTrend(checkpoints, window, prec).classify(settles)
+	converged := class.Converges()
+	if c.Correction != nil {
+		converged = converged && differenceSettles(checkpoints, prec)
 	}
 
-	// Compute convergence rate from checkpoints
-	converged, rate := analyzeConvergence(checkpoints, prec)
+	tail, tailMethod := window.tailBound(c.Start+terms-1, tailPrec)
+	accel, method, stable := sum, AccelNone, 0.0
+	switch {
+	case converged && vanished:
+		stable = stableDigits(tail, prec)
+	case converged:
+		var errEst float64
+		accel, method, errEst = accelerate(checkpoints, window, sum, prec)
+		stable = stableDigits(errEst, prec)
+	}
 
 	return EvalResult{
 		PartialSum:      sum,
-		TermsComputed:   termsComputed,
+		AcceleratedSum:  accel,
+		Acceleration:    method,
+		StableDigits:    stable,
+		Convergence:     class,
+		DecayRate:       decay,
+		TailBound:       tail,
+		TailMethod:      tailMethod,
+		TermsComputed:   terms,
 		Converged:       converged,
 		ConvergenceRate: rate,
 		OK:              true,
 	}
 }
 
+// terms returns the term source of a non-composite candidate: its terms, or
+// for other forms and differences the differences of its partial values.
+func (c *Candidate) terms(prec uint) func(int64) (*big.Float, bool) {
+	next := treeTerms(c, prec)
+	switch {
+	case c.Form == FormCF:
+		next = cfTerms(c, prec)
+	case c.Hyper != nil:
+		next = c.Hyper.terms(prec)
+	}
+	if c.Form == FormProduct {
+		next = productTerms(next, prec)
+	}
+	if c.Correction != nil {
+		next = differenceTerms(c, next, prec)
+	}
+	return next
+}
+
+// treeTerms returns the term function of a candidate's expression trees,
+// run as compiled programs. Each call returns a freshly allocated term.
+func treeTerms(c *Candidate, prec uint) func(i int64) (*big.Float, bool) {
+	numProg, denProg := expr.Compile(c.Numerator), expr.Compile(c.Denominator)
+	return func(i int64) (*big.Float, bool) {
+		num, ok := numProg.Eval(i, prec)
+		if !ok {
+			return nil, false
+		}
+
+		den, ok := denProg.Eval(i, prec)
+		if !ok {
+			return nil, false
+		}
+
+		if den.Sign() == 0 {
+			return nil, false
+		}
+
+		return new(big.Float).SetPrec(prec).Quo(num, den), true
+	}
+}
+
+// stableDigits converts an absolute error estimate into decimal digits,
+// capped at what prec bits can hold.
+func stableDigits(errEst float64, prec uint) float64 {
+	maxDigits := float64(prec) * math.Log10(2)
+	if math.IsNaN(errEst) || math.IsInf(errEst, 0) {
+		return 0
+	}
+	if errEst == 0 {
+		return maxDigits
+	}
+	return math.Max(0, math.Min(-math.Log10(errEst), maxDigits))
+}
+
 type checkpoint struct {
-	terms int64
-	sum   *big.Float
+	terms   int64
+	sum     *big.Float
+	termLog float64 // log|a_N| of the last term, NaN when lost in rounding
 }
 
-// analyzeConvergence checks if |S_{2N} - S_N| is decreasing by a consistent factor.
+// analyzeConvergence checks if |S_{2N} - S_N| is decreasing by a consistent
+// factor. Classification (see convergence.go) has the final word.
 func analyzeConvergence(cps []checkpoint, prec uint) (bool, float64) {
 	if len(cps) < 3 {
 		return false, 0
@@ -126,7 +259,7 @@ func analyzeConvergence(cps []checkpoint, prec uint) (bool, float64) {
 	}
 
 	if validRatios == 0 {
-		return true, 1.0 // converged exactly
+		return true, 0 // converged exactly
 	}
 
 	avgRatio := totalRatio / float64(validRatios)
@@ -135,91 +268,177 @@ func analyzeConvergence(cps []checkpoint, prec uint) (bool, float64) {
 
 // EvalResultF64 holds the result of a float64 candidate evaluation.
 type EvalResultF64 struct {
-	PartialSum    float64
-	TermsComputed int64
-	Converged     bool
-	OK            bool
+	PartialSum      float64
+	AcceleratedSum  float64
+	Acceleration    Acceleration
+	Convergence     ConvergenceClass
+	DecayRate       float64
+	TailBound       float64 // bound on |limit - PartialSum| (see tail.go)
+	TailMethod      TailMethod
+	TermsComputed   int64
+	Elapsed         time.Duration
+	Converged       bool
+	ConvergenceRate float64 // ratio of the last two checkpoint differences
+	OK              bool
 }
 
 // EvaluateCandidateF64 evaluates a candidate series entirely in float64.
 // No timeout — float64 on 1024 terms runs in microseconds.
 func EvaluateCandidateF64(c *Candidate, maxTerms int64) EvalResultF64 {
+	return EvaluateCandidateBudgetF64(c, Budget{Terms: maxTerms})
+}
+
+// EvaluateCandidateBudgetF64 is EvaluateCandidateBudget in float64.
+func EvaluateCandidateBudgetF64(c *Candidate, b Budget) EvalResultF64 {
+	start := time.Now()
+	r := evaluateBudgetF64(c, b)
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+func evaluateBudgetF64(c *Candidate, b Budget) EvalResultF64 {
+	if c.Composite != nil {
+		return c.Composite.evaluateF64(b)
+	}
+	next := c.termsF64()
+
 	var sum float64
 	var termsComputed int64
 
-	// Ring buffer of 3 checkpoint sums for convergence detection.
-	var cpSums [3]float64
-	cpIdx := 0
-	cpCount := 0
+	// Checkpoint sums at powers of 2 (at most 63 of them).
+	var cpSums, cpLogs []float64
 	nextCheckpoint := int64(1)
-
-	for i := c.Start; i < c.Start+maxTerms; i++ {
-		n := float64(i)
-
-		num, ok := c.Numerator.EvalF64(n)
-		if !ok {
-			break
+	var window f64TermWindow
+	var vanish vanishing
+
+	i, limit := c.Start, b.Terms
+	for {
+		stopped := false
+		for ; i < c.Start+limit && !stopped; i++ {
+			term, ok := next(i)
+			if !ok {
+				stopped = true
+				break
+			}
+
+			sum += term
+			termsComputed++
+			window.push(term, sum)
+
+			if math.IsInf(sum, 0) || math.IsNaN(sum) {
+				return EvalResultF64{OK: false}
+			}
+
+			offset := i - c.Start + 1
+			if offset == nextCheckpoint {
+				cpSums = append(cpSums, sum)
+				cpLogs = append(cpLogs, termLogF64(term, sum))
+				nextCheckpoint *= 2
+			}
+			stopped = vanish.pushF64(term, sum)
 		}
 
-		den, ok := c.Denominator.EvalF64(n)
-		if !ok {
-			break
+		if termsComputed < 4 {
+			return EvalResultF64{OK: false}
 		}
 
-		if den == 0 {
-			break
+		r := analyzeF64(c, cpSums, cpLogs, &window, sum, termsComputed, vanish.settled())
+		if stopped {
+			return r
 		}
+		var more bool
+		if limit, more = b.extendF64(limit, r); !more {
+			return r
+		}
+	}
+}
 
-		term := num / den
-		sum += term
-		termsComputed++
+// analyzeF64 is analyze for float64 sums.
+func analyzeF64(c *Candidate, cpSums, cpLogs []float64, window *f64TermWindow, sum float64, terms int64, vanished bool) EvalResultF64 {
+	settles, rate := analyzeConvergenceF64(cpSums)
+	class, decay := checkpointTrendF64(cpSums, cpLogs, window).classify(settles)
+	converged := class.Converges()
+	if c.Correction != nil {
+		converged = converged && differenceSettlesF64(cpSums)
+	}
 
-		if math.IsInf(sum, 0) || math.IsNaN(sum) {
-			return EvalResultF64{OK: false}
-		}
+	accel, method := sum, AccelNone
+	if converged && !vanished {
+		accel, method = accelerateF64(cpSums, window, sum)
+	}
+	tail, tailMethod := window.tailBound(c.Start + terms - 1)
 
-		offset := i - c.Start + 1
-		if offset == nextCheckpoint {
-			cpSums[cpIdx%3] = sum
-			cpIdx++
-			cpCount++
-			nextCheckpoint *= 2
-		}
+	return EvalResultF64{
+		PartialSum:      sum,
+		AcceleratedSum:  accel,
+		Acceleration:    method,
+		Convergence:     class,
+		DecayRate:       decay,
+		TailBound:       tail,
+		TailMethod:      tailMethod,
+		TermsComputed:   terms,
+		Converged:       converged,
+		ConvergenceRate: rate,
+		OK:              true,
 	}
+}
 
-	if termsComputed < 4 {
-		return EvalResultF64{OK: false}
+// termsF64 is terms in float64.
+func (c *Candidate) termsF64() func(int64) (float64, bool) {
+	next := treeTermsF64(c)
+	switch {
+	case c.Form == FormCF:
+		next = cfTermsF64(c)
+	case c.Hyper != nil:
+		next = c.Hyper.termsF64()
+	}
+	if c.Form == FormProduct {
+		next = productTermsF64(next)
+	}
+	if c.Correction != nil {
+		next = differenceTermsF64(c, next)
 	}
+	return next
+}
 
-	converged := analyzeConvergenceF64(cpSums[:], cpCount)
+// treeTermsF64 is treeTerms in float64.
+func treeTermsF64(c *Candidate) func(i int64) (float64, bool) {
+	numProg, denProg := expr.Compile(c.Numerator), expr.Compile(c.Denominator)
+	return func(i int64) (float64, bool) {
+		num, ok := numProg.EvalF64(i)
+		if !ok {
+			return 0, false
+		}
 
-	return EvalResultF64{
-		PartialSum:    sum,
-		TermsComputed: termsComputed,
-		Converged:     converged,
-		OK:            true,
+		den, ok := denProg.EvalF64(i)
+		if !ok {
+			return 0, false
+		}
+
+		if den == 0 {
+			return 0, false
+		}
+
+		return num / den, true
 	}
 }
 
-// analyzeConvergenceF64 checks convergence from a ring buffer of checkpoint sums.
-func analyzeConvergenceF64(ring []float64, count int) bool {
-	if count < 3 {
-		return false
+// analyzeConvergenceF64 checks convergence from the last three checkpoint sums
+// and returns the ratio by which their differences shrank.
+func analyzeConvergenceF64(cps []float64) (bool, float64) {
+	if len(cps) < 3 {
+		return false, 0
 	}
-	// We have the last 3 checkpoints in the ring buffer.
-	// Extract them in order.
-	n := len(ring) // always 3
-	oldest := (count - 3) % n
-	s0 := ring[(oldest)%n]
-	s1 := ring[(oldest+1)%n]
-	s2 := ring[(oldest+2)%n]
+	s0 := cps[len(cps)-3]
+	s1 := cps[len(cps)-2]
+	s2 := cps[len(cps)-1]
 
 	d0 := math.Abs(s1 - s0)
 	d1 := math.Abs(s2 - s1)
 
 	if d0 == 0 {
-		return true
+		return true, 0 // converged exactly
 	}
 	ratio := d1 / d0
-	return ratio < 0.99
+	return ratio < 0.99, ratio
 }
@@ -0,0 +1,195 @@
+package series
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Exact evaluation. When every term of a candidate is rational (its trees
+// pass expr.IsRational) its partial sums are kept as exact fractions: they
+// carry no rounding error however many terms are added, so the digits counted
+// against them are limited only by the target and the truncation. Only the
+// values handed to convergence analysis and acceleration are rounded, once
+// each.
+
+const (
+	// maxExactBits caps the size of an exact partial sum's numerator and
+	// denominator. Sums like 1/n^n outgrow it fast; they are evaluated in
+	// big.Float instead.
+	maxExactBits = 1 << 15
+
+	// exactTimeout bounds an exact evaluation, leaving the big.Float
+	// fallback its own evalTimeout.
+	exactTimeout = evalTimeout / 2
+)
+
+// exactTerms returns the term source of c in exact arithmetic, or nil when c
+// has no exact mode: composites, continued fractions, hypergeometric and
+// difference candidates, and trees with irrational operations. Terms fail
+// where the big.Float evaluation at prec fails.
+func (c *Candidate) exactTerms(prec uint) func(int64) (*big.Rat, bool) {
+	if c.Composite != nil || c.Form == FormCF || c.Hyper != nil || c.Correction != nil ||
+		!expr.IsRational(c.Numerator) || !expr.IsRational(c.Denominator) {
+		return nil
+	}
+	n := new(big.Int)
+	next := func(i int64) (*big.Rat, bool) {
+		n.SetInt64(i)
+		num, ok := c.Numerator.EvalRat(n, prec)
+		if !ok {
+			return nil, false
+		}
+		den, ok := c.Denominator.EvalRat(n, prec)
+		if !ok || den.Sign() == 0 {
+			return nil, false
+		}
+		return num.Quo(num, den), true
+	}
+	if c.Form == FormProduct {
+		next = ratProductTerms(next)
+	}
+	return next
+}
+
+// ratProductTerms is productTerms in exact arithmetic.
+func ratProductTerms(factors func(int64) (*big.Rat, bool)) func(int64) (*big.Rat, bool) {
+	prod, prev := big.NewRat(1, 1), new(big.Rat)
+	return func(i int64) (*big.Rat, bool) {
+		f, ok := factors(i)
+		if !ok {
+			return nil, false
+		}
+		prod.Mul(prod, f)
+		term := new(big.Rat).Sub(prod, prev)
+		prev.Set(prod)
+		return term, true
+	}
+}
+
+// evaluateExact is evaluateBudget in exact arithmetic. ok is false when c
+// has no exact mode, or its sums outgrow maxExactBits or exactTimeout; the
+// caller then evaluates in big.Float.
+func evaluateExact(c *Candidate, b Budget, prec uint) (EvalResult, bool) {
+	next := c.exactTerms(prec)
+	if next == nil {
+		return EvalResult{}, false
+	}
+
+	var sum ratSum
+	rounded := func(x *big.Rat) *big.Float { return new(big.Float).SetPrec(prec).SetRat(x) }
+
+	var checkpoints []checkpoint
+	nextCheckpoint := int64(1)
+	window := newTermWindow(prec)
+	var vanish vanishing
+
+	var termsComputed int64
+	deadline := time.Now().Add(exactTimeout)
+
+	// done is the result of the last budget segment, kept in case an
+	// extension runs out of time or bits.
+	var done EvalResult
+	i, limit := c.Start, b.Terms
+	for {
+		stopped := false
+		for ; i < c.Start+limit && !stopped; i++ {
+			if time.Now().After(deadline) {
+				return done, done.OK
+			}
+
+			term, ok := next(i)
+			if !ok {
+				stopped = true // term failed — use partial sum so far
+				break
+			}
+
+			sum.add(term)
+			if sum.num.BitLen() > maxExactBits || sum.den.BitLen() > maxExactBits {
+				return done, done.OK
+			}
+			termsComputed++
+			roundedSum, roundedTerm := sum.float(prec), rounded(term)
+			window.push(roundedTerm, roundedSum)
+
+			offset := i - c.Start + 1
+			if offset == nextCheckpoint {
+				checkpoints = append(checkpoints, checkpoint{terms: offset, sum: roundedSum, termLog: termLog(roundedTerm, roundedSum, 0)})
+				nextCheckpoint *= 2
+			}
+			// Terms below the rounded sum's precision still move the exact
+			// sum, but no longer any digit the target can check.
+			stopped = vanish.push(roundedTerm, roundedSum, prec)
+		}
+
+		if termsComputed < 4 {
+			return EvalResult{OK: false}, true
+		}
+
+		done = analyze(c, checkpoints, window, sum.float(prec), termsComputed, vanish.settled(), prec, 0)
+		done.ExactSum = new(big.Rat).SetFrac(sum.num, sum.den)
+		if stopped {
+			return done, true
+		}
+		var more bool
+		if limit, more = b.extend(limit, done); !more {
+			return done, true
+		}
+	}
+}
+
+// ratSum is an exact running sum num/den. den is kept at the lcm of the
+// terms' denominators and the fraction is not reduced, which saves a gcd of
+// two large numbers on every addition.
+type ratSum struct {
+	num, den *big.Int
+}
+
+func (s *ratSum) add(x *big.Rat) {
+	if s.den == nil {
+		s.num, s.den = new(big.Int).Set(x.Num()), new(big.Int).Set(x.Denom())
+		return
+	}
+	// num/den + a/b = (num*(b/g) + a*(den/g)) / (den*(b/g)), g = gcd(den, b).
+	g := new(big.Int).GCD(nil, nil, s.den, x.Denom())
+	other := new(big.Int).Quo(s.den, g)
+	other.Mul(other, x.Num())
+	scale := new(big.Int).Quo(x.Denom(), g)
+	s.num.Mul(s.num, scale).Add(s.num, other)
+	s.den.Mul(s.den, scale)
+}
+
+// float rounds the sum to prec bits, once.
+func (s *ratSum) float(prec uint) *big.Float {
+	num := new(big.Float).SetInt(s.num)
+	den := new(big.Float).SetInt(s.den)
+	return new(big.Float).SetPrec(prec).Quo(num, den)
+}
+
+// countCorrectDigitsExact is CorrectDigits for an exact sum. The only
+// rounding is in the target, so a sum equal to it is credited with every
+// digit the target's precision holds.
+func countCorrectDigitsExact(sum *big.Rat, target *big.Float) float64 {
+	if target.IsInf() {
+		return 0
+	}
+	tr, _ := target.Rat(nil)
+	diff := new(big.Rat).Sub(sum, tr)
+	if diff.Sign() == 0 {
+		return float64(target.Prec()) * math.Log10(2)
+	}
+	if tr.Sign() != 0 {
+		diff.Quo(diff, tr)
+	}
+	return math.Max(0, -ratLog10(diff.Abs(diff)))
+}
+
+// ratLog10 returns log10(x) for x > 0, without underflowing on tiny x.
+func ratLog10(x *big.Rat) float64 {
+	mant := new(big.Float)
+	exp := new(big.Float).SetRat(x).MantExp(mant)
+	m, _ := mant.Float64()
+	return math.Log10(m) + float64(exp)*math.Log10(2)
+}
@@ -4,14 +4,16 @@ import (
 	"math"
 	"math/big"
 
-	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/constants"
 )
 
 // FitnessWeights controls the relative importance of fitness components.
 type FitnessWeights struct {
 	Accuracy    float64
 	Complexity  float64 // penalty weight (subtracted)
-	Convergence float64
+	Convergence float64 // credit per digit gained per term by geometric and faster decay
+	Accelerated bool    // score the accelerated limit estimate, as far as the tail bound certifies it, instead of the raw partial sum
+	Transform   float64 // digits charged per unit of transform complexity
 }
 
 // DefaultWeights returns the default fitness weights.
@@ -20,15 +22,42 @@ func DefaultWeights() FitnessWeights {
 		Accuracy:    10.0,
 		Complexity:  2.0,
 		Convergence: 1.0,
+		Accelerated: true,
+		Transform:   1.0,
 	}
 }
 
 // Fitness holds the multi-objective fitness score for a candidate.
 type Fitness struct {
-	Combined        float64
-	CorrectDigits   float64
-	Simplicity      float64
-	ConvergenceRate float64
+	Combined          float64
+	CorrectDigits     float64 // digits the score is based on (raw or accelerated, per weights), at most CertifiedDigits
+	RawDigits         float64 // digits of the raw partial sum: min(AgreedDigits, CertifiedDigits), at most DigitCap
+	AgreedDigits      float64 // digits on which the raw partial sum agrees with the target
+	CertifiedDigits   float64 // digits the tail bound guarantees the partial sum
+	AcceleratedDigits float64 // uncertified: digits of the accelerated limit estimate, not capped by the tail bound
+	Simplicity        float64
+	ConvergenceRate   float64
+	Convergence       ConvergenceClass
+	DecayRate         float64 // r or p of the convergence class (see EvalResult)
+	Transform         string  // matched transform of the target ("" = the target itself)
+}
+
+// Valid reports whether f scores a usable candidate rather than a failed one.
+func (f Fitness) Valid() bool {
+	return f.Combined > WorstFitness().Combined
+}
+
+// Dominates reports whether f is Pareto-better than g: no worse in correct
+// digits, simplicity and convergence rate (lower is faster), and strictly
+// better in at least one. Every valid fitness dominates an invalid one.
+func (f Fitness) Dominates(g Fitness) bool {
+	if !f.Valid() || !g.Valid() {
+		return f.Valid() && !g.Valid()
+	}
+	if f.CorrectDigits < g.CorrectDigits || f.Simplicity < g.Simplicity || f.ConvergenceRate > g.ConvergenceRate {
+		return false
+	}
+	return f.CorrectDigits > g.CorrectDigits || f.Simplicity > g.Simplicity || f.ConvergenceRate < g.ConvergenceRate
 }
 
 // WorstFitness returns a fitness score for invalid/failed candidates.
@@ -47,13 +76,9 @@ func ComputeFitness(c *Candidate, result EvalResult, target *big.Float, weights
 		return WorstFitness()
 	}
 
-	// A series whose terms don't depend on n is just a constant times infinity — reject it.
-	if !expr.ContainsVar(c.Numerator) && !expr.ContainsVar(c.Denominator) {
-		return WorstFitness()
-	}
-
 	// Denominator must depend on n — otherwise terms don't shrink to zero and the series diverges.
-	if !expr.ContainsVar(c.Denominator) {
+	// This also rejects series whose terms don't depend on n at all.
+	if c.degenerate() {
 		return WorstFitness()
 	}
 
@@ -80,7 +105,30 @@ func ComputeFitness(c *Candidate, result EvalResult, target *big.Float, weights
 		}
 	}
 
-	correctDigits := countCorrectDigits(result.PartialSum, target)
+	agreedDigits := CorrectDigits(result.PartialSum, target)
+	if result.ExactSum != nil {
+		agreedDigits = countCorrectDigitsExact(result.ExactSum, target)
+	}
+	// Agreement is only credited as far as the tail bound backs it.
+	targetF64, _ := target.Float64()
+	certified := certifiedDigits(result.TailBound, targetF64, float64(target.Prec())*math.Log10(2))
+	rawDigits := math.Min(agreedDigits, certified)
+	// Without acceleration the estimate is the partial sum, certified as far
+	// as the tail bound goes.
+	accelDigits := rawDigits
+	if result.AcceleratedSum != nil && (result.PartialSum == nil || result.AcceleratedSum.Cmp(result.PartialSum) != 0) {
+		accelDigits = CorrectDigits(result.AcceleratedSum, target)
+	}
+	// Digits past the cap are rounding noise and must not rank candidates.
+	digitCap := DigitCap(target.Prec(), result.TermsComputed)
+	rawDigits = math.Min(rawDigits, digitCap)
+	accelDigits = math.Min(accelDigits, digitCap)
+	// Acceleration may only pick the better estimate; the digits credited
+	// stay within what the tail bound certifies.
+	correctDigits := rawDigits
+	if weights.Accelerated {
+		correctDigits = math.Min(accelDigits, certified)
+	}
 	complexity := c.Complexity()
 	simplicity := 1.0 / math.Max(complexity, 1.0)
 
@@ -89,51 +137,94 @@ func ComputeFitness(c *Candidate, result EvalResult, target *big.Float, weights
 	penaltyScale := math.Min(correctDigits, 5.0) / 5.0
 
 	combined := weights.Accuracy*correctDigits -
-		weights.Complexity*complexity*penaltyScale
+		weights.Complexity*complexity*penaltyScale +
+		weights.Convergence*convergenceScore(result.Convergence, result.DecayRate)
 
 	return Fitness{
-		Combined:        combined,
-		CorrectDigits:   correctDigits,
-		Simplicity:      simplicity,
-		ConvergenceRate: result.ConvergenceRate,
+		Combined:          combined,
+		CorrectDigits:     correctDigits,
+		RawDigits:         rawDigits,
+		AgreedDigits:      agreedDigits,
+		CertifiedDigits:   certified,
+		AcceleratedDigits: accelDigits,
+		Simplicity:        simplicity,
+		ConvergenceRate:   result.ConvergenceRate,
+		Convergence:       result.Convergence,
+		DecayRate:         result.DecayRate,
 	}
 }
 
-// MaxDigits is the cap on correct digits (limited by precision).
-const MaxDigits = 50
-
-// countCorrectDigits returns the number of matching decimal digits between two values.
-func countCorrectDigits(computed, target *big.Float) float64 {
-	if computed == nil || target == nil {
-		return 0
+// ComputeFitnessTransforms scores a candidate against every transform of a
+// target and keeps the best. Each match is charged its transform's
+// complexity, so pi^2/6 must beat pi by more than the extra chances it had.
+func ComputeFitnessTransforms(c *Candidate, result EvalResult, transforms []constants.Transform, weights FitnessWeights) Fitness {
+	if !result.OK || !result.Converged {
+		return WorstFitness()
 	}
+	best := WorstFitness()
+	for _, t := range transforms {
+		f := ComputeFitness(c, result, t.Value, weights)
+		chargeTransform(&f, t, weights)
+		if f.Combined > best.Combined {
+			best = f
+		}
+	}
+	return best
+}
 
-	diff := new(big.Float).Sub(computed, target)
-	diff.Abs(diff)
+// ComputeFitnessTransformsF64 is ComputeFitnessTransforms for float64 results.
+func ComputeFitnessTransformsF64(c *Candidate, result EvalResultF64, transforms []constants.Transform, weights FitnessWeights) Fitness {
+	if !result.OK || !result.Converged {
+		return WorstFitness()
+	}
+	best := WorstFitness()
+	for _, t := range transforms {
+		f := ComputeFitnessF64(c, result, t.Float64Value, weights)
+		chargeTransform(&f, t, weights)
+		if f.Combined > best.Combined {
+			best = f
+		}
+	}
+	return best
+}
 
-	// If exact match
-	if diff.Sign() == 0 {
-		return MaxDigits // cap at 50 digits
+func chargeTransform(f *Fitness, t constants.Transform, weights FitnessWeights) {
+	if t.Complexity == 0 || f.Combined <= WorstFitness().Combined {
+		return
 	}
+	f.Combined -= weights.Accuracy * weights.Transform * t.Complexity
+	f.Transform = t.Name
+}
 
-	// -log10(|computed - target| / |target|)
-	absTgt := new(big.Float).Abs(target)
-	if absTgt.Sign() == 0 {
-		// Target is zero, use absolute error
-		f, _ := diff.Float64()
-		if f == 0 {
-			return MaxDigits
-		}
-		return math.Max(0, -math.Log10(f))
+// DigitCap is the most correct digits a run at prec bits can score when
+// series are summed to at most maxTerms terms: the precision less the bits
+// that rounding in maxTerms additions can cost. A candidate at the cap cannot
+// be told from the target.
+func DigitCap(prec uint, maxTerms int64) float64 {
+	lost := math.Log2(float64(max(maxTerms, 1))) + 1
+	return math.Max(0, (float64(prec)-lost)*math.Log10(2))
+}
+
+// CorrectDigits returns the number of matching decimal digits between two
+// values: -log10 of the relative error, or of the absolute error when the
+// target is 0. The log is read off the exponent and mantissa, so errors far
+// below float64 range still count; an exact match scores every digit the
+// target's precision holds.
+func CorrectDigits(computed, target *big.Float) float64 {
+	if computed == nil || target == nil || computed.IsInf() || target.IsInf() {
+		return 0
 	}
 
-	relErr := new(big.Float).Quo(diff, absTgt)
-	errFloat, _ := relErr.Float64()
-	if errFloat == 0 {
-		return MaxDigits
+	diff := new(big.Float).SetPrec(max(computed.Prec(), target.Prec())).Sub(computed, target)
+	if diff.Sign() == 0 {
+		return float64(target.Prec()) * math.Log10(2)
 	}
 
-	digits := -math.Log10(errFloat)
+	// -log10(|computed - target| / |target|)
+	digits := -bigLogAbs(diff) / math.Ln10
+	if target.Sign() != 0 {
+		digits += bigLogAbs(target) / math.Ln10
+	}
 	return math.Max(0, digits)
 }
 
@@ -146,11 +237,7 @@ func ComputeFitnessF64(c *Candidate, result EvalResultF64, targetF64 float64, we
 		return WorstFitness()
 	}
 
-	if !expr.ContainsVar(c.Numerator) && !expr.ContainsVar(c.Denominator) {
-		return WorstFitness()
-	}
-
-	if !expr.ContainsVar(c.Denominator) {
+	if c.degenerate() {
 		return WorstFitness()
 	}
 
@@ -172,19 +259,37 @@ func ComputeFitnessF64(c *Candidate, result EvalResultF64, targetF64 float64, we
 		}
 	}
 
-	correctDigits := countCorrectDigitsF64(result.PartialSum, targetF64)
+	agreedDigits := countCorrectDigitsF64(result.PartialSum, targetF64)
+	certified := certifiedDigits(result.TailBound, targetF64, maxDigitsF64)
+	rawDigits := math.Min(agreedDigits, certified)
+	accelDigits := rawDigits
+	if result.AcceleratedSum != result.PartialSum {
+		accelDigits = countCorrectDigitsF64(result.AcceleratedSum, targetF64)
+	}
+	correctDigits := rawDigits
+	if weights.Accelerated {
+		correctDigits = math.Min(accelDigits, certified)
+	}
 	complexity := c.Complexity()
 	simplicity := 1.0 / math.Max(complexity, 1.0)
 
 	penaltyScale := math.Min(correctDigits, 5.0) / 5.0
 
 	combined := weights.Accuracy*correctDigits -
-		weights.Complexity*complexity*penaltyScale
+		weights.Complexity*complexity*penaltyScale +
+		weights.Convergence*convergenceScore(result.Convergence, result.DecayRate)
 
 	return Fitness{
-		Combined:      combined,
-		CorrectDigits: correctDigits,
-		Simplicity:    simplicity,
+		Combined:          combined,
+		CorrectDigits:     correctDigits,
+		RawDigits:         rawDigits,
+		AgreedDigits:      agreedDigits,
+		CertifiedDigits:   certified,
+		AcceleratedDigits: accelDigits,
+		Simplicity:        simplicity,
+		ConvergenceRate:   result.ConvergenceRate,
+		Convergence:       result.Convergence,
+		DecayRate:         result.DecayRate,
 	}
 }
 
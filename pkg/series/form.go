@@ -0,0 +1,236 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Form is how a candidate's two trees combine into a value.
+type Form int
+
+const (
+	FormSum     Form = iota // Sum_{n=Start}^{inf} Numerator(n) / Denominator(n)
+	FormProduct             // Prod_{n=Start}^{inf} Numerator(n) / Denominator(n)
+	FormCF                  // b(Start) + a(Start+1)/(b(Start+1) + a(Start+2)/(...)), a = Numerator, b = Denominator
+)
+
+var formNames = map[Form]string{
+	FormSum:     "sum",
+	FormProduct: "product",
+	FormCF:      "cf",
+}
+
+func (f Form) String() string { return formNames[f] }
+
+// ParseForm looks up a form by name.
+func ParseForm(s string) (Form, error) {
+	for f, name := range formNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	return FormSum, fmt.Errorf("unknown form %q (want sum, product or cf)", s)
+}
+
+// Products and continued fractions are evaluated by handing the summation
+// loop the differences S_N - S_{N-1} of their partial values S_N, the partial
+// products or the convergents. The partial sums are then S_N, so checkpoints,
+// the convergence test and acceleration work on them unchanged.
+
+// productTerms returns the differences of the partial products of factors.
+func productTerms(factors func(int64) (*big.Float, bool), prec uint) func(int64) (*big.Float, bool) {
+	prod := new(big.Float).SetPrec(prec).SetInt64(1)
+	prev := new(big.Float).SetPrec(prec)
+	return func(i int64) (*big.Float, bool) {
+		f, ok := factors(i)
+		if !ok {
+			return nil, false
+		}
+		prod.Mul(prod, f)
+		if prod.IsInf() {
+			return nil, false
+		}
+		term := new(big.Float).SetPrec(prec).Sub(prod, prev)
+		prev.Set(prod)
+		return term, true
+	}
+}
+
+// productTermsF64 is productTerms in float64.
+func productTermsF64(factors func(int64) (float64, bool)) func(int64) (float64, bool) {
+	prod, prev := 1.0, 0.0
+	return func(i int64) (float64, bool) {
+		f, ok := factors(i)
+		if !ok {
+			return 0, false
+		}
+		prod *= f
+		if math.IsInf(prod, 0) || math.IsNaN(prod) {
+			return 0, false
+		}
+		term := prod - prev
+		prev = prod
+		return term, true
+	}
+}
+
+// cfTerms returns the differences of the convergents A_i/B_i of a continued
+// fraction, from the recurrences A_i = b_i A_{i-1} + a_i A_{i-2} (and the same
+// for B) with A_s = b_s, B_s = 1, A_{s-1} = 1, B_{s-1} = 0. The four values
+// are rescaled by a power of two each step to keep exponents bounded.
+func cfTerms(c *Candidate, prec uint) func(int64) (*big.Float, bool) {
+	n := new(big.Float).SetPrec(prec)
+	var aPrev, aCur, bPrev, bCur *big.Float
+	prev := new(big.Float).SetPrec(prec)
+	return func(i int64) (*big.Float, bool) {
+		n.SetInt64(i)
+		b, ok := c.Denominator.Eval(n, prec)
+		if !ok {
+			return nil, false
+		}
+		if aCur == nil {
+			aPrev, aCur = new(big.Float).SetPrec(prec).SetInt64(1), b
+			bPrev, bCur = new(big.Float).SetPrec(prec), new(big.Float).SetPrec(prec).SetInt64(1)
+		} else {
+			a, ok := c.Numerator.Eval(n, prec)
+			if !ok {
+				return nil, false
+			}
+			aNext := new(big.Float).SetPrec(prec).Mul(b, aCur)
+			aNext.Add(aNext, new(big.Float).SetPrec(prec).Mul(a, aPrev))
+			bNext := new(big.Float).SetPrec(prec).Mul(b, bCur)
+			bNext.Add(bNext, new(big.Float).SetPrec(prec).Mul(a, bPrev))
+			aPrev, aCur, bPrev, bCur = aCur, aNext, bCur, bNext
+		}
+		if bCur.Sign() == 0 || aCur.IsInf() || bCur.IsInf() {
+			return nil, false
+		}
+		if e := bCur.MantExp(nil); e != 0 {
+			for _, x := range []*big.Float{aPrev, aCur, bPrev, bCur} {
+				x.SetMantExp(x, -e)
+			}
+		}
+		val := new(big.Float).SetPrec(prec).Quo(aCur, bCur)
+		term := new(big.Float).SetPrec(prec).Sub(val, prev)
+		prev = val
+		return term, true
+	}
+}
+
+// cfTermsF64 is cfTerms in float64.
+func cfTermsF64(c *Candidate) func(int64) (float64, bool) {
+	var aPrev, aCur, bPrev, bCur, prev float64
+	first := true
+	return func(i int64) (float64, bool) {
+		n := float64(i)
+		b, ok := c.Denominator.EvalF64(n)
+		if !ok {
+			return 0, false
+		}
+		if first {
+			aPrev, aCur, bPrev, bCur = 1, b, 0, 1
+			first = false
+		} else {
+			a, ok := c.Numerator.EvalF64(n)
+			if !ok {
+				return 0, false
+			}
+			aPrev, aCur, bPrev, bCur = aCur, b*aCur+a*aPrev, bCur, b*bCur+a*bPrev
+		}
+		if bCur == 0 || math.IsInf(aCur, 0) || math.IsInf(bCur, 0) {
+			return 0, false
+		}
+		if _, e := math.Frexp(bCur); e != 0 {
+			aPrev, aCur = math.Ldexp(aPrev, -e), math.Ldexp(aCur, -e)
+			bPrev, bCur = math.Ldexp(bPrev, -e), math.Ldexp(bCur, -e)
+		}
+		val := aCur / bCur
+		term := val - prev
+		prev = val
+		return term, true
+	}
+}
+
+// cfLead returns b(Start), the continued fraction's leading term, folded to
+// a constant where possible.
+func (c *Candidate) cfLead() expr.ExprNode {
+	return expr.SimplifyBigFloat(substituteVar(c.Denominator, c.Start), 128)
+}
+
+// substituteVar returns a copy of node with its variable replaced by v.
+func substituteVar(node expr.ExprNode, v int64) expr.ExprNode {
+	switch n := node.(type) {
+	case *expr.VarNode:
+		return &expr.ConstNode{Val: v}
+	case *expr.UnaryNode:
+		return &expr.UnaryNode{Op: n.Op, Child: substituteVar(n.Child, v)}
+	case *expr.BinaryNode:
+		return &expr.BinaryNode{Op: n.Op, Left: substituteVar(n.Left, v), Right: substituteVar(n.Right, v)}
+	default:
+		return node.Clone()
+	}
+}
+
+func (c *Candidate) formString() string {
+	switch c.Form {
+	case FormProduct:
+		return fmt.Sprintf("Prod_{n=%d}^{inf} (%s) / (%s)", c.Start, c.Numerator.String(), c.Denominator.String())
+	default:
+		return fmt.Sprintf("%s + K_{n=%d}^{inf} (%s) / (%s)", c.cfLead().String(), c.Start+1, c.Numerator.String(), c.Denominator.String())
+	}
+}
+
+func (c *Candidate) formLaTeX() string {
+	switch c.Form {
+	case FormProduct:
+		return fmt.Sprintf(`\prod_{n=%d}^{\infty} \frac{%s}{%s}`, c.Start, c.Numerator.LaTeX(), c.Denominator.LaTeX())
+	default:
+		return fmt.Sprintf(`{%s} + \mathop{K}_{n=%d}^{\infty} \frac{%s}{%s}`, c.cfLead().LaTeX(), c.Start+1, c.Numerator.LaTeX(), c.Denominator.LaTeX())
+	}
+}
+
+// parseProductLatex parses \prod_{n=0}^{\infty} BODY. A coefficient in front
+// of the product is not supported.
+func parseProductLatex(s string) (*Candidate, error) {
+	idx := strings.Index(s, `\prod_{`)
+	if strings.TrimSpace(s[:idx]) != "" {
+		return nil, fmt.Errorf("a coefficient before \\prod is not supported")
+	}
+	c, err := parseSeriesLatex(`\sum_{` + s[idx+len(`\prod_{`):])
+	if err != nil {
+		return nil, err
+	}
+	c.Form = FormProduct
+	return c, nil
+}
+
+// parseCFLatex parses LEAD + \mathop{K}_{n=1}^{\infty} \frac{A}{B}, Gauss's
+// notation for LEAD + A(1)/(B(1) + A(2)/(B(2) + ...)). LEAD must equal B at
+// the index before the first, since the candidate takes it from B.
+func parseCFLatex(s string) (*Candidate, error) {
+	idx := strings.Index(s, `\mathop{K}_{`)
+	lead := strings.TrimSpace(s[:idx])
+	if !strings.HasSuffix(lead, "+") {
+		return nil, fmt.Errorf("expected LEAD + \\mathop{K}_{n=...}")
+	}
+	c, err := parseSeriesLatex(`\sum_{` + s[idx+len(`\mathop{K}_{`):])
+	if err != nil {
+		return nil, err
+	}
+	c.Start, c.Form = c.Start-1, FormCF
+
+	leadNode, err := expr.ParseExprLatex(strings.TrimSpace(strings.TrimSuffix(lead, "+")))
+	if err != nil {
+		return nil, fmt.Errorf("parsing leading term: %w", err)
+	}
+	want, okW := c.Denominator.EvalF64(float64(c.Start))
+	got, okG := leadNode.EvalF64(0)
+	if !okW || !okG || math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+		return nil, fmt.Errorf("leading term %s must equal the partial denominator at n=%d", leadNode.String(), c.Start)
+	}
+	return c, nil
+}
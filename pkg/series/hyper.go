@@ -0,0 +1,371 @@
+package series
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// HyperFactor is a linear factor Mult*n + Offset of a term-ratio polynomial.
+type HyperFactor struct {
+	Mult   int64 `json:"mult"`
+	Offset int64 `json:"offset"`
+}
+
+// HyperPoly is an integer polynomial kept in factored form, Lead * Π Factors.
+// Keeping the factors (rather than expanded coefficients) is what lets every
+// genome be written back as factorials and powers.
+type HyperPoly struct {
+	Lead    int64         `json:"lead"`
+	Factors []HyperFactor `json:"factors,omitempty"`
+}
+
+// HyperGenome describes a hypergeometric series Sum_{n=0}^{inf} a_n through
+// its prefactor a_0 = PrefNum/PrefDen and term ratio a_{n+1}/a_n = P(n)/Q(n).
+type HyperGenome struct {
+	P       HyperPoly `json:"p"`
+	Q       HyperPoly `json:"q"`
+	PrefNum int64     `json:"pref_num"`
+	PrefDen int64     `json:"pref_den"`
+}
+
+// Bounds that keep Mult*n + Offset and the closed-form constants in int64.
+const (
+	maxHyperMult   = 1 << 16
+	maxHyperOffset = 1 << 31
+
+	// maxFactorialConst is the largest k with k! in an int64.
+	maxFactorialConst = 20
+)
+
+// Clone returns a deep copy of the genome. Clone of nil is nil.
+func (g *HyperGenome) Clone() *HyperGenome {
+	if g == nil {
+		return nil
+	}
+	out := *g
+	out.P.Factors = append([]HyperFactor(nil), g.P.Factors...)
+	out.Q.Factors = append([]HyperFactor(nil), g.Q.Factors...)
+	return &out
+}
+
+// Valid reports whether the genome defines a series whose terms are all
+// finite and nonzero: a nonzero prefactor and leads, positive factors.
+func (g *HyperGenome) Valid() bool {
+	if g.PrefNum == 0 || g.PrefDen <= 0 || g.P.Lead == 0 || g.Q.Lead <= 0 {
+		return false
+	}
+	for _, fs := range [][]HyperFactor{g.P.Factors, g.Q.Factors} {
+		for _, f := range fs {
+			if f.Mult < 1 || f.Mult > maxHyperMult || f.Offset < 1 || f.Offset > maxHyperOffset {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Candidate converts the genome into a candidate whose trees give a_n in
+// closed form. It returns false when some factor has no closed form in the
+// expression language (e.g. a lone 3n+1 outside a full (3n)! block).
+func (g *HyperGenome) Candidate() (*Candidate, bool) {
+	if !g.Valid() {
+		return nil, false
+	}
+	num, den := newHyperSide(), newHyperSide()
+	num.coef.SetInt64(g.PrefNum)
+	den.coef.SetInt64(g.PrefDen)
+	num.alt = g.P.Lead < 0
+	num.base.SetInt64(abs64(g.P.Lead))
+	den.base.SetInt64(g.Q.Lead)
+
+	p, q := cancelCommon(g.P.Factors, g.Q.Factors)
+	p, q = telescope(p, q, num, den)
+	q, p = telescope(q, p, den, num)
+	p = factorialBlocks(p, num)
+	q = factorialBlocks(q, den)
+	for _, f := range p {
+		if !risingFactorial(f, num, den) {
+			return nil, false
+		}
+	}
+	for _, f := range q {
+		if !risingFactorial(f, den, num) {
+			return nil, false
+		}
+	}
+
+	reduce(num.coef, den.coef)
+	reduce(num.base, den.base)
+	numTree, ok := num.tree()
+	if !ok {
+		return nil, false
+	}
+	denTree, ok := den.tree()
+	if !ok {
+		return nil, false
+	}
+	return &Candidate{Numerator: numTree, Denominator: denTree, Start: 0, Hyper: g.Clone()}, true
+}
+
+// hyperSide collects one side of a_n's closed form: coef * base^n * Π parts,
+// times (-1)^n when alt is set.
+type hyperSide struct {
+	coef  *big.Int
+	base  *big.Int
+	alt   bool
+	parts []expr.ExprNode
+}
+
+func newHyperSide() *hyperSide {
+	return &hyperSide{coef: big.NewInt(1), base: big.NewInt(1)}
+}
+
+// cancelCommon returns copies of p and q with factors they share removed.
+func cancelCommon(p, q []HyperFactor) ([]HyperFactor, []HyperFactor) {
+	q = append([]HyperFactor(nil), q...)
+	var rest []HyperFactor
+	for _, f := range p {
+		if j := indexOfFactor(q, f, nil); j >= 0 {
+			q = append(q[:j], q[j+1:]...)
+			continue
+		}
+		rest = append(rest, f)
+	}
+	return rest, q
+}
+
+// telescope cancels factor pairs (m*n + o) in top against (m*n + o - m) in
+// bottom, whose running product is (m*n + o - m) / (o - m).
+func telescope(top, bottom []HyperFactor, topSide, bottomSide *hyperSide) ([]HyperFactor, []HyperFactor) {
+	var rest []HyperFactor
+	for _, f := range top {
+		j := -1
+		for k, b := range bottom {
+			if b.Mult == f.Mult && b.Offset == f.Offset-f.Mult {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			rest = append(rest, f)
+			continue
+		}
+		bottom = append(bottom[:j:j], bottom[j+1:]...)
+		c := f.Offset - f.Mult
+		topSide.parts = append(topSide.parts, linearNode(f.Mult, c))
+		bottomSide.coef.Mul(bottomSide.coef, big.NewInt(c))
+	}
+	return rest, bottom
+}
+
+// factorialBlocks replaces each complete set m*n+1, ..., m*n+m, whose running
+// product is (m*n)!, with that factorial.
+func factorialBlocks(fs []HyperFactor, side *hyperSide) []HyperFactor {
+	for m := int64(6); m >= 2; m-- {
+		for {
+			idx := make([]int, 0, m)
+			for j := int64(1); j <= m; j++ {
+				k := indexOfFactor(fs, HyperFactor{m, j}, idx)
+				if k < 0 {
+					break
+				}
+				idx = append(idx, k)
+			}
+			if int64(len(idx)) < m {
+				break
+			}
+			var rest []HyperFactor
+			for k, f := range fs {
+				if !containsInt(idx, k) {
+					rest = append(rest, f)
+				}
+			}
+			fs = rest
+			side.parts = append(side.parts, factorialNode(linearNode(m, 0)))
+		}
+	}
+	return fs
+}
+
+// risingFactorial writes Π_{k<n} (m*k + o) as top/bottom where it has a
+// closed form: (o)_n for m = 1, m^n (o/m)_n when m divides o, and a ratio of
+// factorials for m = 2 with o odd.
+func risingFactorial(f HyperFactor, top, bottom *hyperSide) bool {
+	m, o := f.Mult, f.Offset
+	switch {
+	case m == 1 || o%m == 0:
+		q := o / m
+		if q-1 > maxFactorialConst {
+			return false
+		}
+		top.base.Mul(top.base, big.NewInt(m))
+		top.parts = append(top.parts, factorialNode(linearNode(1, q-1)))
+		bottom.coef.Mul(bottom.coef, factorialInt(q-1))
+	case m == 2 && o%2 == 1:
+		// (2n+o-1)! ((o-1)/2)! / ((o-1)! 2^n (n+(o-1)/2)!)
+		h := (o - 1) / 2
+		if o-1 > maxFactorialConst {
+			return false
+		}
+		top.parts = append(top.parts, factorialNode(linearNode(2, o-1)))
+		top.coef.Mul(top.coef, factorialInt(h))
+		bottom.coef.Mul(bottom.coef, factorialInt(o-1))
+		bottom.base.Mul(bottom.base, big.NewInt(2))
+		bottom.parts = append(bottom.parts, factorialNode(linearNode(1, h)))
+	default:
+		return false
+	}
+	return true
+}
+
+// tree assembles the side as a product, with repeated parts as powers.
+func (s *hyperSide) tree() (expr.ExprNode, bool) {
+	if !s.coef.IsInt64() || !s.base.IsInt64() {
+		return nil, false
+	}
+	var factors []expr.ExprNode
+	if c := s.coef.Int64(); c != 1 || (len(s.parts) == 0 && !s.alt && s.base.Int64() == 1) {
+		factors = append(factors, &expr.ConstNode{Val: c})
+	}
+	if s.alt {
+		factors = append(factors, &expr.UnaryNode{Op: expr.OpAltSign, Child: &expr.VarNode{}})
+	}
+
+	var order []string
+	groups := map[string][]expr.ExprNode{}
+	for _, p := range s.parts {
+		key := p.String()
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], p)
+	}
+	for _, key := range order {
+		p := groups[key][0]
+		if k := len(groups[key]); k > 1 {
+			p = &expr.BinaryNode{Op: expr.OpPow, Left: p, Right: &expr.ConstNode{Val: int64(k)}}
+		}
+		factors = append(factors, p)
+	}
+
+	if b := s.base.Int64(); b != 1 {
+		factors = append(factors, &expr.BinaryNode{Op: expr.OpPow, Left: &expr.ConstNode{Val: b}, Right: &expr.VarNode{}})
+	}
+
+	out := factors[0]
+	for _, f := range factors[1:] {
+		out = &expr.BinaryNode{Op: expr.OpMul, Left: out, Right: f}
+	}
+	return out, true
+}
+
+// terms returns the term function of the ratio recurrence. Calls must come in
+// order from i = 0; each returns a freshly allocated term.
+func (g *HyperGenome) terms(prec uint) func(i int64) (*big.Float, bool) {
+	term := new(big.Float).SetPrec(prec).SetInt64(g.PrefNum)
+	term.Quo(term, new(big.Float).SetPrec(prec).SetInt64(g.PrefDen))
+	p := new(big.Float).SetPrec(prec)
+	q := new(big.Float).SetPrec(prec)
+	x := new(big.Float).SetPrec(prec)
+	return func(i int64) (*big.Float, bool) {
+		if i > 0 {
+			n := i - 1
+			p.SetInt64(g.P.Lead)
+			for _, f := range g.P.Factors {
+				p.Mul(p, x.SetInt64(f.Mult*n+f.Offset))
+			}
+			q.SetInt64(g.Q.Lead)
+			for _, f := range g.Q.Factors {
+				q.Mul(q, x.SetInt64(f.Mult*n+f.Offset))
+			}
+			term.Mul(term, p)
+			term.Quo(term, q)
+		}
+		return new(big.Float).SetPrec(prec).Copy(term), true
+	}
+}
+
+// termsF64 is terms in float64. It fails once a term overflows.
+func (g *HyperGenome) termsF64() func(i int64) (float64, bool) {
+	term := float64(g.PrefNum) / float64(g.PrefDen)
+	return func(i int64) (float64, bool) {
+		if i > 0 {
+			n := i - 1
+			r := float64(g.P.Lead) / float64(g.Q.Lead)
+			for _, f := range g.P.Factors {
+				r *= float64(f.Mult*n + f.Offset)
+			}
+			for _, f := range g.Q.Factors {
+				r /= float64(f.Mult*n + f.Offset)
+			}
+			term *= r
+		}
+		if math.IsInf(term, 0) || math.IsNaN(term) {
+			return 0, false
+		}
+		return term, true
+	}
+}
+
+// linearNode builds m*n + c.
+func linearNode(m, c int64) expr.ExprNode {
+	var n expr.ExprNode = &expr.VarNode{}
+	if m != 1 {
+		n = &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: m}, Right: n}
+	}
+	if c != 0 {
+		n = &expr.BinaryNode{Op: expr.OpAdd, Left: n, Right: &expr.ConstNode{Val: c}}
+	}
+	return n
+}
+
+func factorialNode(child expr.ExprNode) expr.ExprNode {
+	return &expr.UnaryNode{Op: expr.OpFactorial, Child: child}
+}
+
+func factorialInt(k int64) *big.Int {
+	if k < 2 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).MulRange(1, k)
+}
+
+// reduce divides a and b by their gcd, keeping b positive.
+func reduce(a, b *big.Int) {
+	g := new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+	if g.Sign() != 0 {
+		a.Quo(a, g)
+		b.Quo(b, g)
+	}
+	if b.Sign() < 0 {
+		a.Neg(a)
+		b.Neg(b)
+	}
+}
+
+func indexOfFactor(fs []HyperFactor, f HyperFactor, skip []int) int {
+	for k, x := range fs {
+		if x == f && !containsInt(skip, k) {
+			return k
+		}
+	}
+	return -1
+}
+
+func containsInt(xs []int, v int) bool {
+	for _, x := range xs {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func abs64(a int64) int64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
@@ -2,6 +2,7 @@ package series
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -14,13 +15,36 @@ import (
 //	\sum_{n=0}^{\infty} EXPR
 //	\frac{A}{B} \sum_{n=0}^{\infty} \frac{NUM}{DEN}           (outer coefficient)
 //	COEFF \sum_{n=0}^{\infty} \frac{C}{D} \frac{E}{F}         (multiple fracs)
+//	4 \sum_{n=0}^{\infty} ... - \sum_{n=1}^{\infty} ...          (composite)
+//	\frac{1}{2} \left(\sum_{n=0}^{\infty} ... + \sum ...\right)  (composite with prefactor)
+//	\lim_{N \to \infty} \left(\sum_{n=1}^{N} \frac{1}{n} - \ln{(N)}\right) (difference)
+//	\prod_{n=1}^{\infty} \frac{4n^2}{4n^2 - 1}                        (product)
+//	{1} + \mathop{K}_{n=1}^{\infty} \frac{1}{2}                        (continued fraction)
 //
 // The summation variable can be any single letter (k, i, m, ...); it is
-// normalized to n internally.
+// normalized to n internally. In a composite, integer coefficients become
+// sub-series weights; other coefficients are folded into their series.
 func ParseCandidateLatex(s string) (*Candidate, error) {
 	// Normalize whitespace so newlines don't trip up the parser.
 	s = strings.Join(strings.Fields(s), " ")
 
+	if c, ok, err := parseCompositeLatex(s); ok {
+		return c, err
+	}
+	return parseSeriesLatex(s)
+}
+
+// parseSeriesLatex parses a single series with an optional coefficient, or
+// a difference limit, product or continued fraction.
+func parseSeriesLatex(s string) (*Candidate, error) {
+	switch {
+	case strings.HasPrefix(s, `\lim_{`):
+		return parseDifferenceLatex(s)
+	case strings.Contains(s, `\prod_{`):
+		return parseProductLatex(s)
+	case strings.Contains(s, `\mathop{K}_{`):
+		return parseCFLatex(s)
+	}
 	// Find \sum_{ and extract the variable name.
 	sumIdx := strings.Index(s, `\sum_{`)
 	if sumIdx < 0 {
@@ -90,9 +114,13 @@ func ParseCandidateLatex(s string) (*Candidate, error) {
 
 // splitFraction recursively decomposes an expression into (numerator, denominator).
 //   - Div(a, b)       → (a, b)
+//   - p/q             → (p, q)
 //   - Mul(a, b)       → (a_num * b_num, a_den * b_den)
 //   - anything else   → (expr, 1)
 func splitFraction(node expr.ExprNode) (num, den expr.ExprNode) {
+	if r, ok := node.(*expr.RatNode); ok {
+		return &expr.ConstNode{Val: r.Num}, &expr.ConstNode{Val: r.Den}
+	}
 	if b, ok := node.(*expr.BinaryNode); ok {
 		if b.Op == expr.OpDiv {
 			return b.Left, b.Right
@@ -121,3 +149,147 @@ func isOne(n expr.ExprNode) bool {
 	c, ok := n.(*expr.ConstNode)
 	return ok && c.Val == 1
 }
+
+// parseCompositeLatex parses a formula with several top-level sums, or one
+// sum inside a \left( \right) prefactor. ok is false for a plain series.
+func parseCompositeLatex(s string) (*Candidate, bool, error) {
+	prefNum, prefDen := int64(1), int64(1)
+	inner := s
+	if open := strings.Index(s, `\left(`); open >= 0 && strings.HasSuffix(s, `\right)`) &&
+		seriesStart(s[:open]) < 0 && matchingClose(s, open+len(`\left`)) == len(s)-1 {
+		if prefix := strings.TrimSpace(s[:open]); prefix != "" {
+			var err error
+			if prefNum, prefDen, err = parseRational(prefix); err != nil {
+				return nil, true, fmt.Errorf("parsing prefactor: %w", err)
+			}
+		}
+		inner = strings.TrimSpace(s[open+len(`\left(`) : len(s)-len(`\right)`)])
+	}
+
+	sums, signs := topLevelSums(inner)
+	if len(sums) == 0 || (len(sums) == 1 && inner == s) {
+		return nil, false, nil
+	}
+
+	cc := &CompositeCandidate{PrefNum: prefNum, PrefDen: prefDen}
+	begin := 0
+	for k := range sums {
+		end := len(inner)
+		if k+1 < len(sums) {
+			end = -1
+			for _, p := range signs {
+				if p > sums[k] && p < sums[k+1] {
+					end = p
+				}
+			}
+			if end < 0 {
+				return nil, true, fmt.Errorf("expected + or - between series at pos %d", sums[k+1])
+			}
+		}
+		term, err := parseWeightedSeries(inner[begin:end])
+		if err != nil {
+			return nil, true, fmt.Errorf("parsing series %d: %w", k+1, err)
+		}
+		cc.Terms = append(cc.Terms, term)
+		begin = end
+	}
+	return &Candidate{Composite: cc}, true, nil
+}
+
+// parseWeightedSeries parses "± COEFF \sum ..." (or "± COEFF \lim ...") into
+// a weight and a series.
+func parseWeightedSeries(seg string) (WeightedSeries, error) {
+	seg = strings.TrimSpace(seg)
+	sign := int64(1)
+	if strings.HasPrefix(seg, "-") {
+		sign, seg = -1, strings.TrimSpace(seg[1:])
+	} else if strings.HasPrefix(seg, "+") {
+		seg = strings.TrimSpace(seg[1:])
+	}
+	sumIdx := seriesStart(seg)
+	coeff := strings.TrimSpace(seg[:sumIdx])
+	weight := int64(1)
+	if coeff != "" {
+		w, err := strconv.ParseInt(coeff, 10, 64)
+		if err != nil {
+			// Not an integer: leave the coefficient on the series itself.
+			c, err := parseSeriesLatex(seg)
+			return WeightedSeries{Weight: sign, Series: c}, err
+		}
+		weight, seg = w, seg[sumIdx:]
+	}
+	c, err := parseSeriesLatex(seg)
+	return WeightedSeries{Weight: sign * weight, Series: c}, err
+}
+
+// topLevelSums returns the positions of \sum and \lim and of + and - signs
+// that sit outside all braces and parentheses.
+func topLevelSums(s string) (sums, signs []int) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '(':
+			depth++
+		case '}', ')':
+			depth--
+		case '+', '-':
+			if depth == 0 {
+				signs = append(signs, i)
+			}
+		case '\\':
+			if depth == 0 && (strings.HasPrefix(s[i:], `\sum`) || strings.HasPrefix(s[i:], `\lim`)) {
+				sums = append(sums, i)
+			}
+		}
+	}
+	return sums, signs
+}
+
+// seriesStart returns the index of the first \sum or \lim in s, or -1.
+func seriesStart(s string) int {
+	i, j := strings.Index(s, `\sum`), strings.Index(s, `\lim`)
+	if i < 0 || (j >= 0 && j < i) {
+		return j
+	}
+	return i
+}
+
+// matchingClose returns the index of the parenthesis closing the one at
+// open, or -1.
+func matchingClose(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseRational parses an integer or \frac{p}{q} coefficient, optionally negated.
+func parseRational(s string) (int64, int64, error) {
+	sign := int64(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, strings.TrimSpace(s[1:])
+	}
+	node, err := expr.ParseExprLatex(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	num, den := splitFraction(node)
+	p, okP := num.(*expr.ConstNode)
+	q, okQ := den.(*expr.ConstNode)
+	if !okP || !okQ || q.Val == 0 {
+		return 0, 0, fmt.Errorf("expected a rational number, got %q", s)
+	}
+	if q.Val < 0 {
+		return -sign * p.Val, -q.Val, nil
+	}
+	return sign * p.Val, q.Val, nil
+}
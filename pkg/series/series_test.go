@@ -1,10 +1,14 @@
 package series
 
 import (
+	"encoding/json"
 	"math"
 	"math/big"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/wildfunctions/genetic_series/pkg/constants"
 	"github.com/wildfunctions/genetic_series/pkg/expr"
 )
 
@@ -94,6 +98,48 @@ func TestFitness_BadCandidate(t *testing.T) {
 	}
 }
 
+func TestCorrectDigits_HighPrecision(t *testing.T) {
+	const prec = 2048
+	pi := constants.Get("pi", prec).Value
+	off := func(exp10 int) *big.Float {
+		eps, _, _ := big.ParseFloat("1e-"+strconv.Itoa(exp10), 10, prec, big.ToNearestEven)
+		x := new(big.Float).SetPrec(prec).Mul(pi, eps)
+		return x.Add(x, pi)
+	}
+
+	// Both errors underflow float64; the counts must still tell them apart.
+	near, nearer := CorrectDigits(off(400), pi), CorrectDigits(off(500), pi)
+	if math.Abs(near-400) > 0.5 || math.Abs(nearer-500) > 0.5 {
+		t.Errorf("digits = %.1f, %.1f, want 400, 500", near, nearer)
+	}
+	if exact := CorrectDigits(pi, pi); exact < 616 {
+		t.Errorf("exact match scored %.1f digits, want all 616", exact)
+	}
+	if digitCap := DigitCap(prec, 4096); digitCap <= 600 || digitCap >= 616 {
+		t.Errorf("DigitCap(2048, 4096) = %.1f, want just under 616", digitCap)
+	}
+}
+
+// TestComputeFitness_DigitCap checks that a sum agreeing with the target to
+// every bit is scored no higher than the digit cap for its terms.
+func TestComputeFitness_DigitCap(t *testing.T) {
+	c, err := ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := EvaluateCandidate(c, 1024, testPrec)
+	e := constants.Get("e", testPrec).Value
+	digitCap := DigitCap(testPrec, r.TermsComputed)
+	if agreed := CorrectDigits(r.PartialSum, e); agreed <= digitCap {
+		t.Fatalf("1/n! agrees to %.1f digits, within the cap %.1f: nothing to clamp", agreed, digitCap)
+	}
+	f := ComputeFitness(c, r, e, DefaultWeights())
+	if f.CorrectDigits > digitCap || f.RawDigits > digitCap || f.AcceleratedDigits > digitCap {
+		t.Errorf("digits %.1f (raw %.1f, accelerated %.1f), above the cap %.1f",
+			f.CorrectDigits, f.RawDigits, f.AcceleratedDigits, digitCap)
+	}
+}
+
 func TestCandidateClone(t *testing.T) {
 	c := &Candidate{
 		Numerator:   &expr.ConstNode{Val: 1},
@@ -215,3 +261,691 @@ func TestComputeFitnessF64_KnownSeries(t *testing.T) {
 
 	t.Logf("F64 1/n! fitness: combined=%.2f, digits=%.1f", fitness.Combined, fitness.CorrectDigits)
 }
+
+// leibniz returns Sum_{n=0}^{inf} 4(-1)^n / (2n+1) = pi.
+func leibniz() *Candidate {
+	return &Candidate{
+		Numerator: &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: 4},
+			Right: &expr.UnaryNode{Op: expr.OpAltSign, Child: &expr.VarNode{}}},
+		Denominator: &expr.BinaryNode{Op: expr.OpAdd,
+			Left:  &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: 2}, Right: &expr.VarNode{}},
+			Right: &expr.ConstNode{Val: 1}},
+		Start: 0,
+	}
+}
+
+const piDigits = "3.14159265358979323846264338327950288419716939937510582097494459"
+
+// TestAcceleration_Leibniz verifies that acceleration lifts the O(1/n)
+// Leibniz estimate well above its raw digit count on both evaluation paths,
+// while the score stays within the certified digits.
+func TestAcceleration_Leibniz(t *testing.T) {
+	c := leibniz()
+	pi, _ := new(big.Float).SetPrec(testPrec).SetString(piDigits)
+
+	result := EvaluateCandidate(c, 1024, testPrec)
+	if !result.OK || !result.Converged {
+		t.Fatalf("Leibniz: OK=%v Converged=%v", result.OK, result.Converged)
+	}
+	fitness := ComputeFitness(c, result, pi, DefaultWeights())
+	if fitness.RawDigits > 4 {
+		t.Errorf("raw digits = %.1f, expected ~3", fitness.RawDigits)
+	}
+	if fitness.AcceleratedDigits < 10 {
+		t.Errorf("accelerated digits = %.1f (%s), want >= 10", fitness.AcceleratedDigits, result.Acceleration)
+	}
+	if fitness.CorrectDigits != math.Min(fitness.AcceleratedDigits, fitness.CertifiedDigits) {
+		t.Errorf("default weights should score accelerated digits up to the certified ones, got %.1f", fitness.CorrectDigits)
+	}
+
+	raw := DefaultWeights()
+	raw.Accelerated = false
+	if f := ComputeFitness(c, result, pi, raw); f.CorrectDigits != f.RawDigits {
+		t.Errorf("Accelerated=false should score raw digits, got %.1f vs %.1f", f.CorrectDigits, f.RawDigits)
+	}
+
+	r64 := EvaluateCandidateF64(c, 1024)
+	f64 := ComputeFitnessF64(c, r64, math.Pi, DefaultWeights())
+	if f64.AcceleratedDigits < 8 {
+		t.Errorf("f64 accelerated digits = %.1f (%s), want >= 8", f64.AcceleratedDigits, r64.Acceleration)
+	}
+	if f64.CorrectDigits > f64.CertifiedDigits {
+		t.Errorf("f64 scored %.1f digits, past the %.1f certified", f64.CorrectDigits, f64.CertifiedDigits)
+	}
+
+	t.Logf("Leibniz: raw %.1f, accelerated %.1f (%s); f64 raw %.1f, accelerated %.1f (%s)",
+		fitness.RawDigits, fitness.AcceleratedDigits, result.Acceleration,
+		f64.RawDigits, f64.AcceleratedDigits, r64.Acceleration)
+}
+
+// TestAcceleration_FastSeriesUnchanged verifies acceleration doesn't disturb a
+// series that has already converged to working precision.
+func TestAcceleration_FastSeriesUnchanged(t *testing.T) {
+	c := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.UnaryNode{Op: expr.OpFactorial, Child: &expr.VarNode{}},
+		Start:       0,
+	}
+	result := EvaluateCandidate(c, 128, testPrec)
+	e, _ := new(big.Float).SetPrec(testPrec).SetString("2.71828182845904523536028747135266249775724709369995")
+	fitness := ComputeFitness(c, result, e, DefaultWeights())
+	if fitness.AcceleratedDigits < fitness.RawDigits {
+		t.Errorf("acceleration lost digits: raw %.1f, accelerated %.1f (%s)",
+			fitness.RawDigits, fitness.AcceleratedDigits, result.Acceleration)
+	}
+}
+
+// TestComputeFitnessTransforms_Basel checks that sum 1/n^2 is credited as
+// pi^2/6 when transforms are on, and that Leibniz still matches pi itself.
+func TestComputeFitnessTransforms_Basel(t *testing.T) {
+	basel := &Candidate{
+		Numerator: &expr.ConstNode{Val: 1},
+		Denominator: &expr.BinaryNode{Op: expr.OpPow, Left: &expr.VarNode{},
+			Right: &expr.ConstNode{Val: 2}},
+		Start: 1,
+	}
+	transforms := constants.Transforms("pi", testPrec)
+	w := DefaultWeights()
+
+	result := EvaluateCandidate(basel, 1024, testPrec)
+	plain := ComputeFitness(basel, result, constants.Get("pi", testPrec).Value, w)
+	f := ComputeFitnessTransforms(basel, result, transforms, w)
+	if f.Transform != "pi^2/6" {
+		t.Fatalf("transform = %q, want pi^2/6", f.Transform)
+	}
+	if f.AcceleratedDigits < 10 || f.Combined <= plain.Combined {
+		t.Errorf("pi^2/6 match: %.1f accelerated digits, combined %.2f (plain %.2f)", f.AcceleratedDigits, f.Combined, plain.Combined)
+	}
+	f64 := ComputeFitnessTransformsF64(basel, EvaluateCandidateF64(basel, 1024), transforms, w)
+	if f64.Transform != "pi^2/6" {
+		t.Errorf("f64 transform = %q, want pi^2/6", f64.Transform)
+	}
+
+	leib := leibniz()
+	lf := ComputeFitnessTransforms(leib, EvaluateCandidate(leib, 1024, testPrec), transforms, w)
+	if lf.Transform != "" {
+		t.Errorf("Leibniz matched %q, want pi itself", lf.Transform)
+	}
+}
+
+// TestComputeFitnessTransforms_ScaledSqrt checks that a sum of sqrt(2 pi)
+// is credited as sqrt(2*pi), which no rational multiple of sqrt(pi) reaches.
+func TestComputeFitnessTransforms_ScaledSqrt(t *testing.T) {
+	c := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.UnaryNode{Op: expr.OpFactorial, Child: &expr.VarNode{}},
+	}
+	r := EvaluateCandidate(c, 256, testPrec)
+	// Stand in a sum of sqrt(2 pi), certified by the same tail bound.
+	sum := new(big.Float).SetPrec(testPrec).Mul(constants.Get("pi", testPrec).Value, big.NewFloat(2))
+	sum.Sqrt(sum)
+	r.PartialSum, r.AcceleratedSum, r.ExactSum = sum, sum, nil
+
+	f := ComputeFitnessTransforms(c, r, constants.Transforms("pi", testPrec), DefaultWeights())
+	if f.Transform != "sqrt(2*pi)" || f.CorrectDigits < 50 {
+		t.Errorf("matched %q with %.1f digits, want sqrt(2*pi)", f.Transform, f.CorrectDigits)
+	}
+}
+
+func TestHyperGenome_Ramanujan(t *testing.T) {
+	// a_n = 1103 (4n)! (1103 + 26390n) / (1103 (n!)^4 396^{4n}), summing to
+	// 9801 / (2 sqrt(2) pi).
+	g := &HyperGenome{
+		P: HyperPoly{Lead: 1, Factors: []HyperFactor{{4, 1}, {4, 2}, {4, 3}, {4, 4}, {26390, 27493}}},
+		Q: HyperPoly{Lead: 24591257856, Factors: []HyperFactor{{1, 1}, {1, 1}, {1, 1}, {1, 1}, {26390, 1103}}},
+
+		PrefNum: 1103,
+		PrefDen: 1,
+	}
+	c, ok := g.Candidate()
+	if !ok {
+		t.Fatal("genome has no closed form")
+	}
+	if got, want := c.String(), "Sum_{n=0}^{inf} ((((26390 * n) + 1103) * ((4 * n))!)) / ((((n)!)^(4) * (24591257856)^(n)))"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+
+	pi := constants.Get("pi", testPrec).Value
+	want := new(big.Float).SetPrec(testPrec).Sqrt(big.NewFloat(8))
+	want.Mul(want, pi)
+	want.Quo(new(big.Float).SetPrec(testPrec).SetInt64(9801), want)
+
+	viaTree := &Candidate{Numerator: c.Numerator, Denominator: c.Denominator}
+	for _, cand := range []*Candidate{c, viaTree} {
+		r := EvaluateCandidate(cand, 12, testPrec)
+		if !r.OK {
+			t.Fatal("EvaluateCandidate returned OK=false")
+		}
+		diff := new(big.Float).Sub(r.PartialSum, want)
+		if d, _ := diff.Abs(diff).Float64(); d > 1e-90 {
+			t.Errorf("hyper=%v: partial sum off by %g", cand.Hyper != nil, d)
+		}
+	}
+
+	r := EvaluateCandidateF64(c, 12)
+	wantF64, _ := want.Float64()
+	if !r.OK || math.Abs(r.PartialSum-wantF64) > 1e-12 {
+		t.Errorf("F64 partial sum = %v, want %v", r.PartialSum, wantF64)
+	}
+}
+
+func TestHyperGenome_ClosedForms(t *testing.T) {
+	cases := []struct {
+		name string
+		g    HyperGenome
+		want string
+	}{
+		{"exp", HyperGenome{P: HyperPoly{Lead: 1}, Q: HyperPoly{Lead: 1, Factors: []HyperFactor{{1, 1}}}, PrefNum: 1, PrefDen: 1},
+			"Sum_{n=0}^{inf} (1) / ((n)!)"},
+		{"arctan", HyperGenome{P: HyperPoly{Lead: -1, Factors: []HyperFactor{{2, 1}}}, Q: HyperPoly{Lead: 1, Factors: []HyperFactor{{2, 3}}}, PrefNum: 1, PrefDen: 1},
+			"Sum_{n=0}^{inf} ((-1)^(n)) / (((2 * n) + 1))"},
+		{"odd rising", HyperGenome{P: HyperPoly{Lead: 1, Factors: []HyperFactor{{2, 1}}}, Q: HyperPoly{Lead: 4, Factors: []HyperFactor{{1, 1}}}, PrefNum: 1, PrefDen: 1},
+			"Sum_{n=0}^{inf} (((2 * n))!) / ((((n)!)^(2) * (8)^(n)))"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, ok := tc.g.Candidate()
+			if !ok {
+				t.Fatal("genome has no closed form")
+			}
+			if c.String() != tc.want {
+				t.Errorf("String() = %s, want %s", c.String(), tc.want)
+			}
+		})
+	}
+
+	lone := HyperGenome{P: HyperPoly{Lead: 1, Factors: []HyperFactor{{3, 1}}}, Q: HyperPoly{Lead: 1, Factors: []HyperFactor{{1, 1}}}, PrefNum: 1, PrefDen: 1}
+	if _, ok := lone.Candidate(); ok {
+		t.Error("lone 3n+1 factor should have no closed form")
+	}
+}
+
+// machinCandidate is pi = 4 (4 arctan(1/5) - arctan(1/239)), each arctan
+// written as Sum (-1)^n / ((2n+1) x^(2n+1)).
+func machinCandidate() *Candidate {
+	arctan := func(x int64) *Candidate {
+		odd := &expr.BinaryNode{Op: expr.OpAdd,
+			Left:  &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: 2}, Right: &expr.VarNode{}},
+			Right: &expr.ConstNode{Val: 1}}
+		return &Candidate{
+			Numerator: &expr.UnaryNode{Op: expr.OpAltSign, Child: &expr.VarNode{}},
+			Denominator: &expr.BinaryNode{Op: expr.OpMul,
+				Left:  odd,
+				Right: &expr.BinaryNode{Op: expr.OpPow, Left: &expr.ConstNode{Val: x}, Right: odd.Clone()}},
+		}
+	}
+	c := NewComposite(arctan(5), arctan(239))
+	c.Composite.Terms[0].Weight = 4
+	c.Composite.Terms[1].Weight = -1
+	c.Composite.PrefNum = 4
+	return c
+}
+
+func TestComposite_Machin(t *testing.T) {
+	c := machinCandidate()
+	pi := constants.Get("pi", testPrec).Value
+
+	r := EvaluateCandidate(c, 256, testPrec)
+	if !r.OK || !r.Converged {
+		t.Fatalf("EvaluateCandidate: OK=%v Converged=%v", r.OK, r.Converged)
+	}
+	f := ComputeFitness(c, r, pi, DefaultWeights())
+	if digitCap := DigitCap(testPrec, 256); f.CorrectDigits < digitCap {
+		t.Errorf("Machin composite matches pi to %.1f digits, want %.1f", f.CorrectDigits, digitCap)
+	}
+
+	r64 := EvaluateCandidateF64(c, 64)
+	if !r64.OK || math.Abs(r64.PartialSum-math.Pi) > 1e-14 {
+		t.Errorf("F64 partial sum = %v, want pi", r64.PartialSum)
+	}
+
+	parts := c.Composite.Terms[0].Series.Complexity() + c.Composite.Terms[1].Series.Complexity()
+	if c.Complexity() <= parts {
+		t.Errorf("composite complexity %.1f should exceed its parts' %.1f", c.Complexity(), parts)
+	}
+}
+
+func TestComposite_LatexRoundTrip(t *testing.T) {
+	c := machinCandidate()
+	parsed, err := ParseCandidateLatex(c.LaTeX())
+	if err != nil {
+		t.Fatalf("parsing %s: %v", c.LaTeX(), err)
+	}
+	if parsed.String() != c.String() {
+		t.Errorf("round trip:\n got %s\nwant %s", parsed.String(), c.String())
+	}
+
+	// A non-integer coefficient stays on its series.
+	parsed, err = ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!} - \frac{1}{2} \sum_{k=1}^{\infty} \frac{1}{k^{2}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc := parsed.Composite; cc == nil || len(cc.Terms) != 2 || cc.Terms[1].Weight != -1 {
+		t.Fatalf("parsed %s, want two terms with the second negated", parsed)
+	}
+	if got, want := parsed.Composite.Terms[1].Series.String(), "Sum_{n=1}^{inf} (1) / ((2 * (n)^(2)))"; got != want {
+		t.Errorf("second series = %s, want %s", got, want)
+	}
+}
+
+// harmonicMinusLog is lim_{N->inf} (Sum_{n=1}^{N} 1/n - ln N) = gamma.
+func harmonicMinusLog() *Candidate {
+	return &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.VarNode{},
+		Start:       1,
+		Correction:  &expr.UnaryNode{Op: expr.OpLn, Child: &expr.VarNode{}},
+	}
+}
+
+func TestDifference_EulerGamma(t *testing.T) {
+	c := harmonicMinusLog()
+	gamma := constants.Get("euler_gamma", testPrec)
+
+	r := EvaluateCandidate(c, 1024, testPrec)
+	if !r.OK || !r.Converged {
+		t.Fatalf("EvaluateCandidate: OK=%v Converged=%v", r.OK, r.Converged)
+	}
+	f := ComputeFitness(c, r, gamma.Value, DefaultWeights())
+	// D_N - gamma ~ 1/(2N): the raw difference has about three digits,
+	// extrapolation should recover many more, though none are certified.
+	if f.AcceleratedDigits < 10 {
+		t.Errorf("H_N - ln N extrapolates to gamma to %.1f digits (accel %v), want >= 10", f.AcceleratedDigits, r.Acceleration)
+	}
+	if f.CorrectDigits > f.CertifiedDigits {
+		t.Errorf("scored %.1f digits, past the %.1f certified", f.CorrectDigits, f.CertifiedDigits)
+	}
+
+	r64 := EvaluateCandidateF64(c, 1024)
+	if !r64.OK || !r64.Converged || math.Abs(r64.AcceleratedSum-gamma.Float64Value) > 1e-8 {
+		t.Errorf("F64: OK=%v Converged=%v accelerated=%v, want gamma", r64.OK, r64.Converged, r64.AcceleratedSum)
+	}
+
+	// H_N - 2 ln N diverges.
+	c.Correction = &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: 2}, Right: c.Correction}
+	if r := EvaluateCandidate(c, 1024, testPrec); r.Converged {
+		t.Errorf("H_N - 2 ln N reported as converged to %s", r.PartialSum.Text('g', 10))
+	}
+	// A correction without N cancels nothing.
+	c.Correction = &expr.ConstNode{Val: 1}
+	if f := ComputeFitness(c, EvaluateCandidate(c, 1024, testPrec), gamma.Value, DefaultWeights()); f.Combined > -1e8 {
+		t.Errorf("constant correction scored %v, want the degenerate penalty", f.Combined)
+	}
+}
+
+func TestDifference_LatexRoundTrip(t *testing.T) {
+	c := harmonicMinusLog()
+	if got, want := c.String(), "lim_{N->inf} (Sum_{n=1}^{N} (1) / (n) - ln(N))"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+	c.Correction = &expr.BinaryNode{Op: expr.OpSub, Left: c.Correction, Right: &expr.ConstNode{Val: 1}}
+	for _, cand := range []*Candidate{c, NewComposite(c.Clone(), harmonicMinusLog())} {
+		parsed, err := ParseCandidateLatex(cand.LaTeX())
+		if err != nil {
+			t.Fatalf("parsing %s: %v", cand.LaTeX(), err)
+		}
+		if parsed.String() != cand.String() {
+			t.Errorf("round trip:\n got %s\nwant %s", parsed.String(), cand.String())
+		}
+	}
+
+	parsed, err := ParseCandidateLatex(`\lim_{M\to\infty} \left(\sum_{k=1}^{M} \frac{1}{k} - \ln{(M + 1)}\right)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := parsed.String(), "lim_{N->inf} (Sum_{n=1}^{N} (1) / (n) - ln((N + 1)))"; got != want {
+		t.Errorf("parsed %s, want %s", got, want)
+	}
+}
+
+// wallis is Prod_{n=1}^{inf} 4n^2 / (4n^2 - 1) = pi/2.
+func wallis() *Candidate {
+	fourN2 := func() expr.ExprNode {
+		return &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: 4},
+			Right: &expr.BinaryNode{Op: expr.OpPow, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: 2}}}
+	}
+	return &Candidate{
+		Numerator:   fourN2(),
+		Denominator: &expr.BinaryNode{Op: expr.OpSub, Left: fourN2(), Right: &expr.ConstNode{Val: 1}},
+		Start:       1,
+		Form:        FormProduct,
+	}
+}
+
+// fourOverPi is 1 + 1/(3 + 4/(5 + 9/(7 + ...))) = 4/pi: a(n) = n^2, b(n) = 2n + 1.
+func fourOverPi() *Candidate {
+	return &Candidate{
+		Numerator: &expr.BinaryNode{Op: expr.OpPow, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: 2}},
+		Denominator: &expr.BinaryNode{Op: expr.OpAdd,
+			Left:  &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: 2}, Right: &expr.VarNode{}},
+			Right: &expr.ConstNode{Val: 1}},
+		Form: FormCF,
+	}
+}
+
+func TestForm_WallisProduct(t *testing.T) {
+	c := wallis()
+	halfPi := new(big.Float).SetPrec(testPrec).Quo(constants.Get("pi", testPrec).Value, big.NewFloat(2))
+
+	r := EvaluateCandidate(c, 1024, testPrec)
+	if !r.OK || !r.Converged {
+		t.Fatalf("EvaluateCandidate: OK=%v Converged=%v", r.OK, r.Converged)
+	}
+	// The partial products approach pi/2 like 1/N; extrapolation does much better.
+	if f := ComputeFitness(c, r, halfPi, DefaultWeights()); f.RawDigits < 2.5 || f.AcceleratedDigits < 8 {
+		t.Errorf("Wallis product: raw %.1f, accelerated %.1f digits of pi/2", f.RawDigits, f.AcceleratedDigits)
+	}
+	r64 := EvaluateCandidateF64(c, 1024)
+	if !r64.OK || !r64.Converged || math.Abs(r64.AcceleratedSum-math.Pi/2) > 1e-6 {
+		t.Errorf("F64: OK=%v Converged=%v accelerated=%v, want pi/2", r64.OK, r64.Converged, r64.AcceleratedSum)
+	}
+
+	// Prod (n+1)/n = N+1 diverges.
+	c.Numerator = &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: 1}}
+	c.Denominator = &expr.VarNode{}
+	if r := EvaluateCandidate(c, 1024, testPrec); r.Converged {
+		t.Errorf("Prod (n+1)/n reported as converged to %s", r.PartialSum.Text('g', 10))
+	}
+}
+
+func TestForm_ContinuedFraction(t *testing.T) {
+	c := fourOverPi()
+	fourOverPi := new(big.Float).SetPrec(testPrec).Quo(big.NewFloat(4), constants.Get("pi", testPrec).Value)
+
+	r := EvaluateCandidate(c, 256, testPrec)
+	if !r.OK || !r.Converged {
+		t.Fatalf("EvaluateCandidate: OK=%v Converged=%v", r.OK, r.Converged)
+	}
+	if f := ComputeFitness(c, r, fourOverPi, DefaultWeights()); f.RawDigits < 50 {
+		t.Errorf("continued fraction matches 4/pi to %.1f digits, want 50+", f.RawDigits)
+	}
+	r64 := EvaluateCandidateF64(c, 256)
+	if !r64.OK || math.Abs(r64.PartialSum-4/math.Pi) > 1e-14 {
+		t.Errorf("F64 convergent = %v, want 4/pi", r64.PartialSum)
+	}
+}
+
+func TestForm_LatexRoundTrip(t *testing.T) {
+	for _, c := range []*Candidate{wallis(), fourOverPi()} {
+		parsed, err := ParseCandidateLatex(c.LaTeX())
+		if err != nil {
+			t.Fatalf("parsing %s: %v", c.LaTeX(), err)
+		}
+		if parsed.String() != c.String() {
+			t.Errorf("round trip:\n got %s\nwant %s", parsed.String(), c.String())
+		}
+	}
+	if got, want := fourOverPi().String(), "1 + K_{n=1}^{inf} ((n)^(2)) / (((2 * n) + 1))"; got != want {
+		t.Errorf("String = %s, want %s", got, want)
+	}
+	// The leading term has to be b at the index before the first.
+	if _, err := ParseCandidateLatex(`{1} + \mathop{K}_{n=1}^{\infty} \frac{1}{2}`); err == nil {
+		t.Error("expected an error for a leading term other than b(0)")
+	}
+	for _, name := range []string{"sum", "product", "cf"} {
+		if f, err := ParseForm(name); err != nil || f.String() != name {
+			t.Errorf("ParseForm(%q) = %v, %v", name, f, err)
+		}
+	}
+}
+
+func TestDescriptor_EquivalentForms(t *testing.T) {
+	parse := func(s string) *Candidate {
+		c, err := ParseCandidateLatex(s)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", s, err)
+		}
+		return c
+	}
+	a := Descriptor(parse(`\sum_{n=1}^{\infty} \frac{1}{n!}`), 16)
+	b := Descriptor(parse(`\sum_{n=1}^{\infty} \frac{n}{n \cdot n!}`), 16)
+	c := Descriptor(parse(`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`), 16)
+	if len(a) != 16 || len(b) != 16 || len(c) != 16 {
+		t.Fatalf("descriptor lengths %d, %d, %d, want 16", len(a), len(b), len(c))
+	}
+	if d := DescriptorDistance(a, b); d > 1e-12 {
+		t.Errorf("1/n! and n/(n n!) are %g apart, want 0", d)
+	}
+	if d := DescriptorDistance(a, c); d < 0.05 {
+		t.Errorf("1/n! and 1/n^2 are only %g apart", d)
+	}
+
+	m := machinCandidate()
+	if d := Descriptor(m, 16); d == nil || math.Abs(math.Sinh(d[15])-math.Pi) > 1e-6 {
+		t.Errorf("Machin composite descriptor ends at %v, want asinh(pi)", d)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	parse := func(s string) *Candidate {
+		c, err := ParseCandidateLatex(s)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", s, err)
+		}
+		return c
+	}
+	fp := func(s string) uint64 {
+		f, ok := Fingerprint(parse(s), 64)
+		if !ok {
+			t.Fatalf("no fingerprint for %s", s)
+		}
+		return f
+	}
+	if fp(`\sum_{n=1}^{\infty} \frac{1}{n!}`) != fp(`\sum_{n=1}^{\infty} \frac{n}{n \cdot n!}`) {
+		t.Error("1/n! and n/(n n!) should share a fingerprint")
+	}
+	if fp(`\sum_{n=1}^{\infty} \frac{1}{n!}`) == fp(`\sum_{n=0}^{\infty} \frac{1}{n!}`) {
+		t.Error("sums from different starts should not share a fingerprint")
+	}
+	if fp(`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`) == fp(`\sum_{n=1}^{\infty} \frac{1}{n^{2} + 1}`) {
+		t.Error("different series should not share a fingerprint")
+	}
+	if _, ok := Fingerprint(parse(`\sum_{n=0}^{\infty} \frac{1}{n}`), 64); ok {
+		t.Error("a series whose first term fails should have no fingerprint")
+	}
+}
+
+func TestCandidate_CancelCommon(t *testing.T) {
+	parse := func(s string) *Candidate {
+		c, err := ParseCandidateLatex(s)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", s, err)
+		}
+		return c
+	}
+
+	c := parse(`\sum_{n=1}^{\infty} \frac{n}{n \cdot n!}`)
+	c.CancelCommon()
+	if got, want := c.String(), parse(`\sum_{n=1}^{\infty} \frac{1}{n!}`).String(); got != want {
+		t.Errorf("CancelCommon gave %s, want %s", got, want)
+	}
+
+	same := []string{
+		`\sum_{n=1}^{\infty} \frac{n + 1}{(n + 1)^{3}}`,
+		`\sum_{n=1}^{\infty} \frac{1}{n^{2} + 2 \cdot n + 1}`,
+		`\sum_{n=1}^{\infty} \frac{2}{2 \cdot n \cdot n + 4 \cdot n + 2}`,
+	}
+	want := parse(same[0]).CanonicalString()
+	for _, s := range same[1:] {
+		if got := parse(s).CanonicalString(); got != want {
+			t.Errorf("CanonicalString of %s = %s, want %s", s, got, want)
+		}
+	}
+	if parse(`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`).CanonicalString() == want {
+		t.Error("different series should not share a canonical string")
+	}
+}
+
+func TestEvaluateCandidate_Exact(t *testing.T) {
+	// Sum_{n=1}^{4} 1/(n(n+1)) telescopes to 4/5.
+	telescoping := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.BinaryNode{Op: expr.OpMul, Left: &expr.VarNode{}, Right: &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: 1}}},
+		Start:       1,
+	}
+	r := EvaluateCandidate(telescoping, 4, testPrec)
+	if r.ExactSum == nil || r.ExactSum.RatString() != "4/5" {
+		t.Errorf("exact partial sum = %v, want 4/5", r.ExactSum)
+	}
+
+	// Sum 1/n! matches e to every digit the target holds once rounding is out
+	// of the way; only the target's 1024 bits limit it.
+	c := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.UnaryNode{Op: expr.OpFactorial, Child: &expr.VarNode{}},
+	}
+	const prec = 1024
+	r = EvaluateCandidate(c, 256, prec)
+	if r.ExactSum == nil {
+		t.Fatal("1/n! should be evaluated exactly")
+	}
+	e := new(big.Float).SetPrec(prec)
+	for i, term := int64(0), big.NewFloat(1).SetPrec(prec); i < 300; i++ {
+		e.Add(e, term)
+		term.Quo(term, big.NewFloat(float64(i+1)))
+	}
+	if got := ComputeFitness(c, r, e, DefaultWeights()).RawDigits; got < 300 {
+		t.Errorf("exact 1/n! matched e to %.1f digits, want >= 300", got)
+	}
+
+	// Irrational terms fall back to big.Float.
+	sqrt := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.BinaryNode{Op: expr.OpPow, Left: &expr.VarNode{}, Right: &expr.UnaryNode{Op: expr.OpSqrt, Child: &expr.ConstNode{Val: 9}}},
+		Start:       1,
+	}
+	if r := EvaluateCandidate(sqrt, 64, testPrec); !r.OK || r.ExactSum != nil {
+		t.Errorf("1/n^sqrt(9) should be evaluated in big.Float, got OK=%v exact=%v", r.OK, r.ExactSum != nil)
+	}
+}
+
+// TestTailBound checks that each tail test bounds the true remainder without
+// overstating it by much, and that raw digits are capped by the bound.
+func TestTailBound(t *testing.T) {
+	basel, _ := new(big.Float).SetPrec(testPrec).SetString("1.64493406684822643647241516664602518921894990120679843773555822937")
+	pi, _ := new(big.Float).SetPrec(testPrec).SetString(piDigits)
+	for _, tc := range []struct {
+		latex  string
+		target *big.Float
+		method TailMethod
+	}{
+		{`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`, basel, TailIntegral},
+		{`\sum_{n=0}^{\infty} \frac{4 \cdot (-1)^{n}}{2 \cdot n + 1}`, pi, TailAlternating},
+		{`\sum_{n=0}^{\infty} \frac{1}{2^{n}}`, big.NewFloat(2).SetPrec(testPrec), TailRatio},
+	} {
+		c, err := ParseCandidateLatex(tc.latex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := EvaluateCandidate(c, 512, testPrec)
+		tail, _ := new(big.Float).Sub(tc.target, r.PartialSum).Float64()
+		if r.TailMethod != tc.method || r.TailBound < math.Abs(tail) || r.TailBound > 4*math.Abs(tail) {
+			t.Errorf("%s: tail bound %g (%s), true tail %g, want %s", tc.latex, r.TailBound, r.TailMethod, tail, tc.method)
+		}
+		f := ComputeFitness(c, r, tc.target, DefaultWeights())
+		digitCap := DigitCap(testPrec, r.TermsComputed)
+		if f.RawDigits != math.Min(math.Min(f.AgreedDigits, f.CertifiedDigits), digitCap) {
+			t.Errorf("%s: raw digits %.1f, want min(%.1f agreed, %.1f certified, %.1f cap)", tc.latex, f.RawDigits, f.AgreedDigits, f.CertifiedDigits, digitCap)
+		}
+	}
+
+	// A continued fraction's differences vanish at working precision.
+	if r := EvaluateCandidate(fourOverPi(), 256, testPrec); r.TailMethod != TailFloor {
+		t.Errorf("4/pi continued fraction: tail method %s, want floor", r.TailMethod)
+	}
+}
+
+// TestTailBound_CapsUnaccelerated checks that with DefaultWeights, which
+// score the accelerated estimate, a series no accelerator helps is still held
+// to its certified digits.
+func TestTailBound_CapsUnaccelerated(t *testing.T) {
+	basel, _ := new(big.Float).SetPrec(testPrec).SetString("1.64493406684822643647241516664602518921894990120679843773555822937")
+	c, err := ParseCandidateLatex(`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := EvaluateCandidate(c, 512, testPrec)
+	r.AcceleratedSum, r.Acceleration = r.PartialSum, AccelNone
+	f := ComputeFitness(c, r, basel, DefaultWeights())
+	if f.AgreedDigits <= f.CertifiedDigits {
+		t.Fatalf("agreed %.4f digits within certified %.4f: nothing to cap", f.AgreedDigits, f.CertifiedDigits)
+	}
+	if f.CorrectDigits > f.CertifiedDigits {
+		t.Errorf("correct digits %.4f, above certified %.4f", f.CorrectDigits, f.CertifiedDigits)
+	}
+
+	r64 := EvaluateCandidateF64(c, 512)
+	r64.AcceleratedSum, r64.Acceleration = r64.PartialSum, AccelNone
+	basel64, _ := basel.Float64()
+	if f := ComputeFitnessF64(c, r64, basel64, DefaultWeights()); f.CorrectDigits > f.CertifiedDigits {
+		t.Errorf("f64: correct digits %.4f, above certified %.4f", f.CorrectDigits, f.CertifiedDigits)
+	}
+}
+
+// TestConvergenceClass checks the classifier on series the checkpoint ratio
+// heuristic mislabels or cannot tell apart.
+func TestConvergenceClass(t *testing.T) {
+	for _, tc := range []struct {
+		latex string
+		class ConvergenceClass
+		rate  float64
+	}{
+		{`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`, ConvAlgebraic, 2},
+		{`\sum_{n=1}^{\infty} \frac{1}{n}`, ConvDivergent, 0},
+		{`\sum_{n=2}^{\infty} \frac{1}{n \ln(n)}`, ConvDivergent, 0},
+		{`\sum_{n=0}^{\infty} \frac{4 \cdot (-1)^{n}}{2 \cdot n + 1}`, ConvAlternating, 1},
+		{`\sum_{n=0}^{\infty} \frac{(-1)^{n}}{3^{n}}`, ConvGeometric, 1.0 / 3},
+		{`\sum_{n=0}^{\infty} \frac{1}{n!}`, ConvSuperGeometric, -1},
+		{`\sum_{n=1}^{\infty} \sin(n)`, ConvOscillating, 0},
+		{`\sum_{n=1}^{\infty} n`, ConvDivergent, 0},
+	} {
+		c, err := ParseCandidateLatex(tc.latex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := EvaluateCandidate(c, 1024, testPrec)
+		if r.Convergence != tc.class || r.Converged != tc.class.Converges() ||
+			tc.rate >= 0 && math.Abs(r.DecayRate-tc.rate) > 0.01 {
+			t.Errorf("%s: %s, rate %.4g, converged %v; want %s, rate %.4g", tc.latex, r.Convergence, r.DecayRate, r.Converged, tc.class, tc.rate)
+		}
+		if r64 := EvaluateCandidateF64(c, 1024); r64.Convergence != tc.class {
+			t.Errorf("%s: f64 %s, want %s", tc.latex, r64.Convergence, tc.class)
+		}
+	}
+
+	b, err := json.Marshal(Fitness{Convergence: ConvGeometric})
+	var f Fitness
+	if err != nil || json.Unmarshal(b, &f) != nil || f.Convergence != ConvGeometric || !strings.Contains(string(b), `"geometric"`) {
+		t.Errorf("convergence class JSON round trip: %s, %v -> %s", b, err, f.Convergence)
+	}
+}
+
+// TestEvaluateCandidateBudget checks that summing stops once the terms vanish
+// and goes on past the budget for a converging series that has not settled.
+func TestEvaluateCandidateBudget(t *testing.T) {
+	fact, err := ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := Budget{Terms: 1024, Ceiling: 4096}
+	if r := EvaluateCandidateBudget(fact, b, testPrec); !r.OK || r.TermsComputed > 200 || r.Elapsed <= 0 {
+		t.Errorf("1/n!: OK=%v, %d terms in %v; want under 200", r.OK, r.TermsComputed, r.Elapsed)
+	}
+	if r64 := EvaluateCandidateBudgetF64(fact, b); !r64.OK || r64.TermsComputed > 50 {
+		t.Errorf("1/n! in f64: OK=%v, %d terms; want under 50", r64.OK, r64.TermsComputed)
+	}
+
+	basel, err := ParseCandidateLatex(`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := EvaluateCandidateBudget(basel, Budget{Terms: 256}, testPrec)
+	long := EvaluateCandidateBudget(basel, Budget{Terms: 256, Ceiling: 1024}, testPrec)
+	if short.TermsComputed != 256 || long.TermsComputed != 1024 || long.TailBound >= short.TailBound {
+		t.Errorf("1/n^2: %d terms (tail %.3g) without a ceiling, %d (tail %.3g) with; want 256 and 1024",
+			short.TermsComputed, short.TailBound, long.TermsComputed, long.TailBound)
+	}
+	if r64 := EvaluateCandidateBudgetF64(basel, Budget{Terms: 256, Ceiling: 1024}); r64.TermsComputed != 1024 {
+		t.Errorf("1/n^2 in f64: %d terms, want 1024", r64.TermsComputed)
+	}
+}
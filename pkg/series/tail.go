@@ -0,0 +1,192 @@
+package series
+
+import (
+	"math"
+	"math/big"
+)
+
+// Tail bounds. A partial sum S_N that agrees with the target to d digits
+// only shows the series converges there if the unsummed tail is below that
+// too. The trailing terms give a bound on |S - S_N| by one of three tests,
+// each valid as long as the terms keep the pattern seen at the end of the
+// window:
+//
+//   - alternating: signs alternate and magnitudes decrease, so the tail is
+//     at most |a_N|;
+//   - ratio: |a_{k+1}/a_k| <= r < 1 and not growing, so the tail is at most
+//     |a_N| r/(1-r);
+//   - integral: terms of one sign decrease like C/n^p with p > 1, so the
+//     tail is at most the integral of C/x^p from N, a_N N/(p-1).
+//
+// The smallest applicable bound is kept. Terms that have all dropped below
+// the rounding floor of the sum, as a continued fraction's do once its
+// convergents settle, leave a tail at that floor. The tests are run on logs
+// of the term magnitudes, so terms far below float64's range still bound the
+// tail.
+
+// TailMethod identifies the test behind a tail bound.
+type TailMethod int
+
+const (
+	TailNone        TailMethod = iota // no test applies: the tail is unbounded
+	TailAlternating                   // alternating series test
+	TailRatio                         // ratio test, geometric bound
+	TailIntegral                      // integral test on fitted n^-p decay
+	TailFloor                         // terms below the working precision
+)
+
+var tailNames = map[TailMethod]string{
+	TailNone:        "none",
+	TailAlternating: "alternating",
+	TailRatio:       "ratio",
+	TailIntegral:    "integral",
+	TailFloor:       "floor",
+}
+
+func (m TailMethod) String() string { return tailNames[m] }
+
+// ratioSlack absorbs rounding in the logs when checking that term ratios do
+// not grow, so a geometric series' constant ratio passes.
+const ratioSlack = 1e-9
+
+// floorUlps is the log2 of the rounding noise, in units in the last place of
+// the sum, below which terms count as vanished.
+const floorUlps = 2
+
+// tailBound bounds the tail after the trailing terms of a series, given the
+// natural logs of their magnitudes and their signs, oldest first. last is the
+// index n of the newest term and floor the log of the sum's rounding error.
+// It returns the log of the bound, +Inf with TailNone when no test applies.
+func tailBound(logs []float64, signs []int, last int64, floor float64) (float64, TailMethod) {
+	k := len(logs)
+	if k < 4 {
+		return math.Inf(1), TailNone
+	}
+	negligible := true
+	for _, l := range logs {
+		negligible = negligible && l <= floor
+	}
+	if negligible {
+		return floor, TailFloor
+	}
+	decreasing, alternating, oneSign := true, true, true
+	for j := 1; j < k; j++ {
+		if signs[j] == 0 || math.IsInf(logs[j], 0) || math.IsNaN(logs[j]) {
+			return math.Inf(1), TailNone
+		}
+		decreasing = decreasing && logs[j] <= logs[j-1]
+		alternating = alternating && signs[j] == -signs[j-1]
+		oneSign = oneSign && signs[j] == signs[j-1]
+	}
+	if !decreasing {
+		return math.Inf(1), TailNone
+	}
+	aN := logs[k-1]
+
+	best, method := math.Inf(1), TailNone
+	consider := func(b float64, m TailMethod) {
+		if b < best {
+			best, method = b, m
+		}
+	}
+
+	if alternating {
+		consider(aN, TailAlternating)
+	}
+
+	// Ratio test: the largest log-ratio in the window, provided the ratios
+	// are not growing towards 1.
+	maxRatio := logs[1] - logs[0]
+	growing := false
+	for j := 2; j < k; j++ {
+		lr := logs[j] - logs[j-1]
+		growing = growing || lr > logs[j-1]-logs[j-2]+ratioSlack
+		maxRatio = math.Max(maxRatio, lr)
+	}
+	if !growing && maxRatio < 0 {
+		// log(a_N r/(1-r)) = log a_N + log r - log(1-r)
+		consider(aN+maxRatio-math.Log(-math.Expm1(maxRatio)), TailRatio)
+	}
+
+	// Integral test: p fitted from the ends of the window.
+	if first := last - int64(k) + 1; oneSign && first >= 1 {
+		p := (logs[0] - aN) / (math.Log(float64(last)) - math.Log(float64(first)))
+		if p > 1 {
+			consider(aN+math.Log(float64(last))-math.Log(p-1), TailIntegral)
+		}
+	}
+	return best, method
+}
+
+// tailBound bounds the tail after the buffered terms of a sum held to prec
+// bits, or exactly when prec is 0; see tailBound.
+func (w *termWindow) tailBound(last int64, prec uint) (float64, TailMethod) {
+	terms, sums := w.ordered()
+	if len(sums) == 0 {
+		return math.Inf(1), TailNone
+	}
+	floor := math.Inf(-1)
+	if prec > 0 {
+		floor = bigLogAbs(sums[len(sums)-1]) - float64(prec-floorUlps)*math.Ln2
+	}
+	logs := make([]float64, len(terms))
+	signs := make([]int, len(terms))
+	for i, t := range terms {
+		logs[i], signs[i] = bigLogAbs(t), t.Sign()
+	}
+	return expBound(tailBound(logs, signs, last, floor))
+}
+
+// tailBound is termWindow.tailBound for float64 sums.
+func (w *f64TermWindow) tailBound(last int64) (float64, TailMethod) {
+	terms, sums := w.ordered()
+	if len(sums) == 0 {
+		return math.Inf(1), TailNone
+	}
+	floor := math.Log(math.Abs(sums[len(sums)-1])) - (53-floorUlps)*math.Ln2
+	logs := make([]float64, len(terms))
+	signs := make([]int, len(terms))
+	for i, t := range terms {
+		logs[i] = math.Log(math.Abs(t))
+		switch {
+		case t > 0:
+			signs[i] = 1
+		case t < 0:
+			signs[i] = -1
+		}
+	}
+	return expBound(tailBound(logs, signs, last, floor))
+}
+
+// expBound converts a log bound to the bound itself, which underflows to 0
+// for tails far below anything float64 or the digit counts can resolve.
+func expBound(logBound float64, m TailMethod) (float64, TailMethod) {
+	return math.Exp(logBound), m
+}
+
+// bigLogAbs returns log|x| without underflowing on tiny x; -Inf for 0.
+func bigLogAbs(x *big.Float) float64 {
+	if x.Sign() == 0 {
+		return math.Inf(-1)
+	}
+	mant := new(big.Float)
+	exp := x.MantExp(mant)
+	m, _ := mant.Float64()
+	return math.Log(math.Abs(m)) + float64(exp)*math.Ln2
+}
+
+// certifiedDigits converts a tail bound into the decimal digits of target
+// it guarantees. A bound that underflowed to 0 certifies maxDigits, an
+// infinite one none.
+func certifiedDigits(bound, target, maxDigits float64) float64 {
+	if math.IsInf(bound, 0) || math.IsNaN(bound) {
+		return 0
+	}
+	if bound == 0 {
+		return maxDigits
+	}
+	if target = math.Abs(target); target != 0 {
+		bound /= target
+	}
+	return math.Max(0, -math.Log10(bound))
+}
@@ -14,6 +14,7 @@ const (
 	constTuneEliteRate      = 0.10 // top 10% carried over (higher than tournament to preserve good combos)
 	constTuneTournamentSize = 5
 	constTuneWideRate       = 0.10 // fraction of non-elite that get wide exploration
+	constTuneRatRate        = 0.25 // fraction of hill-climb steps taken in the Stern–Brocot tree
 )
 
 func init() {
@@ -21,7 +22,9 @@ func init() {
 }
 
 // ConstantTuneStrategy freezes the expression tree structure and only varies
-// integer constants, using hill-climbing with tournament selection.
+// constants, using hill-climbing with tournament selection. Steps are small
+// integer shifts or Stern–Brocot moves, which turn integers into fractions
+// and refine or coarsen fractions.
 type ConstantTuneStrategy struct {
 	seed *series.Candidate
 }
@@ -99,16 +102,16 @@ func (s *ConstantTuneStrategy) Evolve(
 			// Normal hill-climb: 1-2 small perturbations.
 			nPerturbs := rng.Intn(2) + 1
 			for j := 0; j < nPerturbs; j++ {
-				constPerturb(child.Numerator, rng)
+				sub := randomSeries(child, rng)
+				sub.Numerator = tunePerturb(sub.Numerator, rng)
 				if rng.Float64() < 0.5 {
-					constPerturb(child.Denominator, rng)
+					sub.Denominator = tunePerturb(sub.Denominator, rng)
 				}
 			}
 		}
 
 		// Simplify (constant folding may collapse sub-expressions).
-		child.Numerator = expr.SimplifyBigFloat(child.Numerator, 128)
-		child.Denominator = expr.SimplifyBigFloat(child.Denominator, 128)
+		simplifyCandidate(child)
 
 		next = append(next, child)
 		nonEliteFilled++
@@ -133,44 +136,48 @@ func constTuneSelect(pop []*series.Candidate, fitnesses []series.Fitness, rng *r
 	return pop[bestIdx]
 }
 
+// tunePerturb shifts a random constant by ±1 to ±3 or, at constTuneRatRate,
+// moves it one step in the Stern–Brocot tree.
+func tunePerturb(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
+	if rng.Float64() < constTuneRatRate {
+		return ratPerturb(root, rng)
+	}
+	return constPerturb(root, rng)
+}
+
 // perturbConstWide perturbs a random constant in the candidate by ±1 to ±maxDelta.
 func perturbConstWide(c *series.Candidate, rng *rand.Rand, maxDelta int) {
-	// Pick numerator or denominator.
-	tree := c.Numerator
+	// Pick a series, then its numerator or denominator.
+	sub := randomSeries(c, rng)
+	tree := &sub.Numerator
 	if rng.Float64() < 0.5 {
-		tree = c.Denominator
+		tree = &sub.Denominator
 	}
 
-	consts := collectConsts(tree)
-	if len(consts) == 0 {
+	slots := collectConstSlots(tree)
+	if len(slots) == 0 {
 		return
 	}
-	target := consts[rng.Intn(len(consts))]
-	delta := int64(rng.Intn(maxDelta) + 1)
-	if rng.Float64() < 0.5 {
-		delta = -delta
-	}
-	target.Val += delta
-	if target.Val == 0 {
-		target.Val = 1
-	}
+	target := *slots[rng.Intn(len(slots))]
+	shiftConst(target, randomDelta(rng, maxDelta))
 }
 
-// replaceRandomConst replaces a random constant in the candidate with a new value in [-maxVal, maxVal].
+// replaceRandomConst replaces a random constant in the candidate with a new integer in [-maxVal, maxVal].
 func replaceRandomConst(c *series.Candidate, rng *rand.Rand, maxVal int) {
-	tree := c.Numerator
+	sub := randomSeries(c, rng)
+	tree := &sub.Numerator
 	if rng.Float64() < 0.5 {
-		tree = c.Denominator
+		tree = &sub.Denominator
 	}
 
-	consts := collectConsts(tree)
-	if len(consts) == 0 {
+	slots := collectConstSlots(tree)
+	if len(slots) == 0 {
 		return
 	}
-	target := consts[rng.Intn(len(consts))]
+	target := slots[rng.Intn(len(slots))]
 	newVal := int64(rng.Intn(2*maxVal+1)) - int64(maxVal)
 	if newVal == 0 {
 		newVal = 1
 	}
-	target.Val = newVal
+	*target = &expr.ConstNode{Val: newVal}
 }
@@ -8,10 +8,17 @@ import (
 )
 
 // CrossoverCandidates performs subtree crossover between two candidates,
-// returning two new offspring. Both numerator and denominator trees are crossed.
+// returning two new offspring. Both numerator and denominator trees are crossed,
+// and the corrections when both parents are differences.
 func CrossoverCandidates(a, b *series.Candidate, rng *rand.Rand) (*series.Candidate, *series.Candidate) {
 	c1 := a.Clone()
 	c2 := b.Clone()
+	c1.Hyper, c2.Hyper = nil, nil // genomes no longer describe the crossed trees
+
+	if c1.Composite != nil || c2.Composite != nil {
+		crossoverComposites(c1, c2, rng)
+		return c1, c2
+	}
 
 	// Cross numerators
 	c1.Numerator, c2.Numerator = crossoverTrees(c1.Numerator, c2.Numerator, rng)
@@ -19,9 +26,30 @@ func CrossoverCandidates(a, b *series.Candidate, rng *rand.Rand) (*series.Candid
 	// Cross denominators
 	c1.Denominator, c2.Denominator = crossoverTrees(c1.Denominator, c2.Denominator, rng)
 
+	if c1.Correction != nil && c2.Correction != nil {
+		c1.Correction, c2.Correction = crossoverTrees(c1.Correction, c2.Correction, rng)
+	}
+
 	return c1, c2
 }
 
+// crossoverComposites picks one series from each candidate (a plain
+// candidate is its own only series) and either swaps them whole or crosses
+// their trees.
+func crossoverComposites(c1, c2 *series.Candidate, rng *rand.Rand) {
+	s1, s2 := randomSeries(c1, rng), randomSeries(c2, rng)
+	if rng.Float64() < 0.5 {
+		*s1, *s2 = *s2, *s1
+		return
+	}
+	s1.Hyper, s2.Hyper = nil, nil
+	s1.Numerator, s2.Numerator = crossoverTrees(s1.Numerator, s2.Numerator, rng)
+	s1.Denominator, s2.Denominator = crossoverTrees(s1.Denominator, s2.Denominator, rng)
+	if s1.Correction != nil && s2.Correction != nil {
+		s1.Correction, s2.Correction = crossoverTrees(s1.Correction, s2.Correction, rng)
+	}
+}
+
 // crossoverTrees swaps random subtrees between two expression trees.
 func crossoverTrees(a, b expr.ExprNode, rng *rand.Rand) (expr.ExprNode, expr.ExprNode) {
 	nodesA := collectNodes(a)
@@ -4,7 +4,6 @@ import (
 	"math/rand"
 	"sort"
 
-	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
@@ -21,14 +20,40 @@ func init() {
 // HillClimbStrategy implements directed hill-climbing with population.
 // For each candidate: clone + directed mutation, keep whichever is better.
 // Periodically injects random candidates to escape local optima.
-type HillClimbStrategy struct{}
+type HillClimbStrategy struct {
+	maxSeries  int
+	difference bool
+	form       series.Form
+}
 
 func (s *HillClimbStrategy) Name() string { return "hillclimb" }
 
+// SetMaxSeries lets mutation grow candidates into composites of up to n series.
+func (s *HillClimbStrategy) SetMaxSeries(n int) { s.maxSeries = n }
+
+// SetDifference makes new candidates difference limits
+// lim_{N->inf} [partial sum - g(N)], as needed for constants like gamma.
+func (s *HillClimbStrategy) SetDifference(on bool) { s.difference = on }
+
+// SetForm makes new candidates products or continued fractions instead of sums.
+func (s *HillClimbStrategy) SetForm(f series.Form) { s.form = f }
+
+// random creates a fresh candidate of the configured kind.
+func (s *HillClimbStrategy) random(p pool.Pool, rng *rand.Rand) *series.Candidate {
+	var c *series.Candidate
+	if s.difference {
+		c = randomDifference(p, rng, hillclimbMaxDepth)
+	} else {
+		c = randomCandidate(p, rng, hillclimbMaxDepth)
+	}
+	c.Form = s.form
+	return c
+}
+
 func (s *HillClimbStrategy) Initialize(p pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
 	pop := make([]*series.Candidate, popSize)
 	for i := range pop {
-		pop[i] = randomCandidate(p, rng, hillclimbMaxDepth)
+		pop[i] = s.random(p, rng)
 	}
 	return pop
 }
@@ -46,11 +71,13 @@ func (s *HillClimbStrategy) Evolve(
 		// Clone and mutate
 		child := population[i].Clone()
 		MutateCandidate(child, p, rng)
-		child.Numerator = expr.SimplifyBigFloat(child.Numerator, 128)
-		child.Denominator = expr.SimplifyBigFloat(child.Denominator, 128)
+		if s.maxSeries > 1 && rng.Float64() < compositeGrowRate {
+			growComposite(child, p, rng, s.maxSeries, hillclimbMaxDepth)
+		}
+		simplifyCandidate(child)
 
 		if !candidateOK(child) {
-			child = randomCandidate(p, rng, hillclimbMaxDepth)
+			child = s.random(p, rng)
 		}
 
 		next[i] = child
@@ -76,7 +103,7 @@ func (s *HillClimbStrategy) Evolve(
 	}
 	for i := 0; i < injectionCount && i < n; i++ {
 		idx := ranked[i].idx
-		next[idx] = randomCandidate(p, rng, hillclimbMaxDepth)
+		next[idx] = s.random(p, rng)
 	}
 
 	// Elitism: keep the best from the old generation if it's better
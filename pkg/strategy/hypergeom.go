@@ -0,0 +1,246 @@
+package strategy
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+const (
+	hyperEliteRate     = 0.05
+	hyperCrossoverRate = 0.3
+	hyperInjectionRate = 0.05 // fraction of non-elite slots replaced with random each gen
+	hyperLinearRate    = 0.3  // chance a random genome gets a linear numerator factor
+	hyperMaxFactors    = 8    // per polynomial; (6n)! alone takes six
+	hyperMutateTries   = 10   // mutations tried before giving up on a parent
+)
+
+// hyperBases are the geometric ratio denominators random genomes start from.
+var hyperBases = []int64{1, 2, 3, 4, 8, 9, 16, 27, 64, 256}
+
+func init() {
+	Register("hypergeom", func() Strategy { return &HypergeomStrategy{} })
+}
+
+// HypergeomStrategy evolves hypergeometric series, whose term ratio
+// a_{n+1}/a_n = P(n)/Q(n) is rational in n. The genome is the factored integer
+// polynomials P and Q plus the prefactor a_0; candidates carry it in Hyper and
+// are evaluated through the ratio recurrence. The pool is not used.
+type HypergeomStrategy struct{}
+
+func (s *HypergeomStrategy) Name() string { return "hypergeom" }
+
+func (s *HypergeomStrategy) Initialize(_ pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
+	pop := make([]*series.Candidate, popSize)
+	for i := range pop {
+		pop[i] = randomHyperCandidate(rng)
+	}
+	return pop
+}
+
+func (s *HypergeomStrategy) Evolve(
+	population []*series.Candidate,
+	fitnesses []series.Fitness,
+	_ pool.Pool,
+	rng *rand.Rand,
+) []*series.Candidate {
+	n := len(population)
+	next := make([]*series.Candidate, 0, n)
+
+	// Sort indices by fitness (descending)
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.Slice(indices, func(a, b int) bool {
+		return fitnesses[indices[a]].Combined > fitnesses[indices[b]].Combined
+	})
+
+	eliteCount := int(float64(n) * hyperEliteRate)
+	if eliteCount < 1 {
+		eliteCount = 1
+	}
+	for i := 0; i < eliteCount; i++ {
+		next = append(next, population[indices[i]].Clone())
+	}
+
+	for len(next) < n {
+		g := genomeOf(tournamentSelect(population, fitnesses, rng), rng)
+		if rng.Float64() < hyperCrossoverRate {
+			g = crossoverGenomes(g, genomeOf(tournamentSelect(population, fitnesses, rng), rng), rng)
+		}
+		next = append(next, hyperOffspring(g, rng))
+	}
+
+	injectionCount := int(float64(n) * hyperInjectionRate)
+	if injectionCount < 1 {
+		injectionCount = 1
+	}
+	for i := 0; i < injectionCount && eliteCount < n; i++ {
+		idx := eliteCount + rng.Intn(n-eliteCount)
+		next[idx] = randomHyperCandidate(rng)
+	}
+
+	return next[:n]
+}
+
+// genomeOf returns a candidate's genome, or a random one for candidates that
+// have none (such as trees restored without their genome).
+func genomeOf(c *series.Candidate, rng *rand.Rand) *series.HyperGenome {
+	if c.Hyper != nil {
+		return c.Hyper.Clone()
+	}
+	return randomGenome(rng)
+}
+
+// hyperOffspring mutates g until the result has a closed form, falling back
+// to a fresh random candidate.
+func hyperOffspring(g *series.HyperGenome, rng *rand.Rand) *series.Candidate {
+	for try := 0; try < hyperMutateTries; try++ {
+		child := g.Clone()
+		mutateGenome(child, rng)
+		if c, ok := child.Candidate(); ok {
+			return c
+		}
+	}
+	return randomHyperCandidate(rng)
+}
+
+func randomHyperCandidate(rng *rand.Rand) *series.Candidate {
+	for {
+		if c, ok := randomGenome(rng).Candidate(); ok {
+			return c
+		}
+	}
+}
+
+// randomGenome starts from the exponential-like ratio ±1/(b(n+1)) and adds a
+// few factorial blocks and, sometimes, a linear numerator factor.
+func randomGenome(rng *rand.Rand) *series.HyperGenome {
+	g := &series.HyperGenome{
+		P:       series.HyperPoly{Lead: 1},
+		Q:       series.HyperPoly{Lead: hyperBases[rng.Intn(len(hyperBases))], Factors: []series.HyperFactor{{Mult: 1, Offset: 1}}},
+		PrefNum: 1,
+		PrefDen: 1,
+	}
+	if rng.Float64() < 0.5 {
+		g.P.Lead = -1
+	}
+	for i := rng.Intn(3); i > 0; i-- {
+		addHyperFactor(&g.P, rng)
+	}
+	for i := rng.Intn(3); i > 0; i-- {
+		addHyperFactor(&g.Q, rng)
+	}
+	if rng.Float64() < hyperLinearRate {
+		addLinearPair(g, rng)
+	}
+	return g
+}
+
+// addHyperFactor adds a factorial block (m*n)!, a shifted factorial (n+o)!
+// or an odd double-factorial step 2n+o to p.
+func addHyperFactor(p *series.HyperPoly, rng *rand.Rand) {
+	var fs []series.HyperFactor
+	switch rng.Intn(3) {
+	case 0:
+		m := []int64{1, 2, 3, 4, 6}[rng.Intn(5)]
+		for j := int64(1); j <= m; j++ {
+			fs = append(fs, series.HyperFactor{Mult: m, Offset: j})
+		}
+	case 1:
+		fs = []series.HyperFactor{{Mult: 1, Offset: int64(rng.Intn(4) + 1)}}
+	default:
+		fs = []series.HyperFactor{{Mult: 2, Offset: int64(2*rng.Intn(2) + 1)}}
+	}
+	if len(p.Factors)+len(fs) <= hyperMaxFactors {
+		p.Factors = append(p.Factors, fs...)
+	}
+}
+
+// addLinearPair multiplies a_n by (m*n + o)/o: P gains m*n + o + m, Q gains
+// m*n + o.
+func addLinearPair(g *series.HyperGenome, rng *rand.Rand) {
+	if len(g.P.Factors) >= hyperMaxFactors || len(g.Q.Factors) >= hyperMaxFactors {
+		return
+	}
+	m := int64(rng.Intn(10) + 1)
+	o := int64(rng.Intn(20) + 1)
+	g.P.Factors = append(g.P.Factors, series.HyperFactor{Mult: m, Offset: o + m})
+	g.Q.Factors = append(g.Q.Factors, series.HyperFactor{Mult: m, Offset: o})
+}
+
+// mutateGenome applies one random change to g in place. The result may lack
+// a closed form; callers check with Candidate.
+func mutateGenome(g *series.HyperGenome, rng *rand.Rand) {
+	poly := &g.P
+	if rng.Float64() < 0.5 {
+		poly = &g.Q
+	}
+	switch rng.Intn(8) {
+	case 0: // nudge P's lead, keeping it nonzero
+		g.P.Lead += randomDelta(rng, 3)
+		if g.P.Lead == 0 {
+			g.P.Lead = 1
+		}
+	case 1: // nudge or replace the geometric denominator
+		if rng.Float64() < 0.5 {
+			g.Q.Lead = hyperBases[rng.Intn(len(hyperBases))]
+		} else if g.Q.Lead += randomDelta(rng, 3); g.Q.Lead < 1 {
+			g.Q.Lead = 1
+		}
+	case 2: // toggle alternation
+		g.P.Lead = -g.P.Lead
+	case 3:
+		addHyperFactor(poly, rng)
+	case 4:
+		if len(poly.Factors) > 0 {
+			i := rng.Intn(len(poly.Factors))
+			poly.Factors = append(poly.Factors[:i], poly.Factors[i+1:]...)
+		}
+	case 5: // shift one factor
+		if len(poly.Factors) > 0 {
+			f := &poly.Factors[rng.Intn(len(poly.Factors))]
+			if f.Offset += randomDelta(rng, 2); f.Offset < 1 {
+				f.Offset = 1
+			}
+		}
+	case 6: // nudge the prefactor
+		if rng.Float64() < 0.5 {
+			if g.PrefNum += randomDelta(rng, 3); g.PrefNum == 0 {
+				g.PrefNum = 1
+			}
+		} else if g.PrefDen += randomDelta(rng, 3); g.PrefDen < 1 {
+			g.PrefDen = 1
+		}
+	default:
+		addLinearPair(g, rng)
+	}
+}
+
+// crossoverGenomes takes a's genome with either P or Q from b, and b's
+// prefactor half of the time.
+func crossoverGenomes(a, b *series.HyperGenome, rng *rand.Rand) *series.HyperGenome {
+	child := a.Clone()
+	donor := b.Clone()
+	if rng.Float64() < 0.5 {
+		child.P = donor.P
+	} else {
+		child.Q = donor.Q
+	}
+	if rng.Float64() < 0.5 {
+		child.PrefNum, child.PrefDen = donor.PrefNum, donor.PrefDen
+	}
+	return child
+}
+
+// randomDelta returns a nonzero integer in [-max, max].
+func randomDelta(rng *rand.Rand, max int) int64 {
+	d := int64(rng.Intn(max) + 1)
+	if rng.Float64() < 0.5 {
+		d = -d
+	}
+	return d
+}
@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"math/big"
 	"math/rand"
 
 	"github.com/wildfunctions/genetic_series/pkg/expr"
@@ -18,12 +19,22 @@ const (
 	MutConstPerturb                      // adjust a constant value by ±1-3
 	MutGrow                              // wrap a leaf in a new operation
 	MutShrink                            // replace a node with one of its children
+	MutRatPerturb                        // move a constant one step in the Stern–Brocot tree
 )
 
 const maxMutationDepth = 4
 
 // MutateCandidate applies a random mutation to a candidate (modifies in place).
 func MutateCandidate(c *series.Candidate, p pool.Pool, rng *rand.Rand) {
+	c.Hyper = nil // the trees are edited directly, so a genome no longer describes them
+	if c.Composite != nil {
+		mutateComposite(c.Composite, p, rng)
+		return
+	}
+	if c.Correction != nil && rng.Float64() < correctionMutateRate {
+		c.Correction = mutateTree(c.Correction, p, rng)
+		return
+	}
 	r := rng.Float64()
 	switch {
 	case r < 0.1:
@@ -36,8 +47,57 @@ func MutateCandidate(c *series.Candidate, p pool.Pool, rng *rand.Rand) {
 	}
 }
 
+// mutateComposite mutates one sub-series, one weight or the prefactor, or
+// drops a sub-series.
+func mutateComposite(cc *series.CompositeCandidate, p pool.Pool, rng *rand.Rand) {
+	i := rng.Intn(len(cc.Terms))
+	r := rng.Float64()
+	switch {
+	case r < 0.6:
+		MutateCandidate(cc.Terms[i].Series, p, rng)
+	case r < 0.8:
+		if cc.Terms[i].Weight += randomDelta(rng, 2); cc.Terms[i].Weight == 0 {
+			cc.Terms[i].Weight = 1
+		}
+	case r < 0.9:
+		if rng.Float64() < 0.5 {
+			if cc.PrefNum += randomDelta(rng, 2); cc.PrefNum == 0 {
+				cc.PrefNum = 1
+			}
+		} else if cc.PrefDen += randomDelta(rng, 2); cc.PrefDen < 1 {
+			cc.PrefDen = 1
+		}
+	default:
+		if len(cc.Terms) > 1 {
+			cc.Terms = append(cc.Terms[:i], cc.Terms[i+1:]...)
+		}
+	}
+}
+
+// growComposite adds a random weighted sub-series to c, turning a plain
+// candidate into a composite, unless c already holds maxSeries series.
+func growComposite(c *series.Candidate, p pool.Pool, rng *rand.Rand, maxSeries, maxDepth int) {
+	if c.Composite == nil {
+		*c = *series.NewComposite(&series.Candidate{
+			Numerator:   c.Numerator,
+			Denominator: c.Denominator,
+			Start:       c.Start,
+			Form:        c.Form,
+			Hyper:       c.Hyper,
+			Correction:  c.Correction,
+		})
+	}
+	if len(c.Composite.Terms) >= maxSeries {
+		return
+	}
+	c.Composite.Terms = append(c.Composite.Terms, series.WeightedSeries{
+		Weight: randomDelta(rng, 4),
+		Series: randomCandidate(p, rng, maxDepth),
+	})
+}
+
 func mutateTree(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
-	mut := MutationType(rng.Intn(6))
+	mut := MutationType(rng.Intn(7))
 	switch mut {
 	case MutPoint:
 		return pointMutate(root, p, rng)
@@ -51,6 +111,8 @@ func mutateTree(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
 		return growMutate(root, p, rng)
 	case MutShrink:
 		return shrinkMutate(root, rng)
+	case MutRatPerturb:
+		return ratPerturb(root, rng)
 	default:
 		return root
 	}
@@ -68,7 +130,7 @@ func pointMutate(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode
 	switch n := (*target).(type) {
 	case *expr.VarNode:
 		*target = p.RandomLeaf(rng)
-	case *expr.ConstNode:
+	case *expr.ConstNode, *expr.RatNode:
 		*target = p.RandomLeaf(rng)
 	case *expr.UnaryNode:
 		n.Op = p.RandomUnary(rng)
@@ -101,22 +163,100 @@ func hoistMutate(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
 
 // constPerturb adjusts a random constant by ±1 to ±3.
 func constPerturb(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
-	consts := collectConsts(root)
-	if len(consts) == 0 {
+	slots := collectConstSlots(&root)
+	if len(slots) == 0 {
 		return root
 	}
-	target := consts[rng.Intn(len(consts))]
-	delta := int64(rng.Intn(3) + 1)
-	if rng.Float64() < 0.5 {
-		delta = -delta
+	shiftConst(*slots[rng.Intn(len(slots))], randomDelta(rng, 3))
+	return root
+}
+
+// shiftConst adds delta to a ConstNode or RatNode in place. A fraction keeps
+// its denominator; a sum overflowing int64 leaves it unchanged.
+func shiftConst(node expr.ExprNode, delta int64) {
+	switch c := node.(type) {
+	case *expr.ConstNode:
+		c.Val += delta
+		if c.Val == 0 {
+			c.Val = 1 // avoid zero constants
+		}
+	case *expr.RatNode:
+		num := new(big.Int).Mul(big.NewInt(delta), big.NewInt(c.Den))
+		if num.Add(num, big.NewInt(c.Num)); num.IsInt64() {
+			c.Num = num.Int64()
+		}
 	}
-	target.Val += delta
-	if target.Val == 0 {
-		target.Val = 1 // avoid zero constants
+}
+
+// ratPerturb moves a random constant one step in the Stern–Brocot tree, to
+// its parent or one of its two children. Integers thus gain fractional
+// parts, and fractions refine or coarsen by one partial quotient.
+func ratPerturb(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
+	slots := collectConstSlots(&root)
+	if len(slots) == 0 {
+		return root
+	}
+	slot := slots[rng.Intn(len(slots))]
+	var x *big.Rat
+	switch c := (*slot).(type) {
+	case *expr.ConstNode:
+		x = big.NewRat(c.Val, 1)
+	case *expr.RatNode:
+		x = big.NewRat(c.Num, c.Den)
+	}
+	y := sternBrocotStep(x, rng)
+	if y.Sign() == 0 || !y.Num().IsInt64() || !y.Denom().IsInt64() {
+		return root // avoid zero constants
+	}
+	if c, ok := expr.NewRat(y.Num().Int64(), y.Denom().Int64()); ok {
+		*slot = c
 	}
 	return root
 }
 
+// sternBrocotStep returns a random neighbour of x in the Stern–Brocot tree,
+// read off its continued fraction [a0; a1, ..., ak]: the children are
+// [a0; ..., ak + 1] and [a0; ..., ak - 1, 2], the parent [a0; ..., ak - 1].
+// An integer's parent is the integer below it.
+func sternBrocotStep(x *big.Rat, rng *rand.Rand) *big.Rat {
+	cf := continuedFraction(x)
+	last := cf[len(cf)-1]
+	switch rng.Intn(3) {
+	case 0:
+		last.Add(last, big.NewInt(1))
+	case 1:
+		last.Sub(last, big.NewInt(1))
+		cf = append(cf, big.NewInt(2))
+	default:
+		last.Sub(last, big.NewInt(1))
+	}
+	return fromContinuedFraction(cf)
+}
+
+// continuedFraction returns the terms of x = [a0; a1, ..., ak], a0 = floor x,
+// with ak >= 2 when k >= 1.
+func continuedFraction(x *big.Rat) []*big.Int {
+	p := new(big.Int).Set(x.Num())
+	q := new(big.Int).Set(x.Denom())
+	var cf []*big.Int
+	for q.Sign() != 0 {
+		a, r := new(big.Int).DivMod(p, q, new(big.Int)) // floor, for q > 0
+		cf = append(cf, a)
+		p, q = q, r
+	}
+	return cf
+}
+
+// fromContinuedFraction folds [a0; a1, ..., ak] back into a rational.
+func fromContinuedFraction(cf []*big.Int) *big.Rat {
+	x := new(big.Rat).SetInt(cf[len(cf)-1])
+	for i := len(cf) - 2; i >= 0; i-- {
+		x.Inv(x)
+		x.Add(x, new(big.Rat).SetInt(cf[i]))
+	}
+	return x
+}
+
 // growMutate wraps a random leaf in a new unary or binary operation.
 func growMutate(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
 	nodes := collectNodes(root)
@@ -176,21 +316,22 @@ func collectNodesHelper(node *expr.ExprNode, result *[]*expr.ExprNode) {
 	}
 }
 
-// collectConsts returns pointers to all ConstNodes in the tree.
-func collectConsts(root expr.ExprNode) []*expr.ConstNode {
-	var result []*expr.ConstNode
-	collectConstsHelper(root, &result)
+// collectConstSlots returns pointers to the slots holding constants, integer
+// or rational, so that a constant can be replaced by one of the other kind.
+func collectConstSlots(root *expr.ExprNode) []*expr.ExprNode {
+	var result []*expr.ExprNode
+	collectConstSlotsHelper(root, &result)
 	return result
 }
 
-func collectConstsHelper(node expr.ExprNode, result *[]*expr.ConstNode) {
-	switch n := node.(type) {
-	case *expr.ConstNode:
-		*result = append(*result, n)
+func collectConstSlotsHelper(node *expr.ExprNode, result *[]*expr.ExprNode) {
+	switch n := (*node).(type) {
+	case *expr.ConstNode, *expr.RatNode:
+		*result = append(*result, node)
 	case *expr.UnaryNode:
-		collectConstsHelper(n.Child, result)
+		collectConstSlotsHelper(&n.Child, result)
 	case *expr.BinaryNode:
-		collectConstsHelper(n.Left, result)
-		collectConstsHelper(n.Right, result)
+		collectConstSlotsHelper(&n.Left, result)
+		collectConstSlotsHelper(&n.Right, result)
 	}
 }
@@ -0,0 +1,194 @@
+package strategy
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+const (
+	nsga2MaxDepth      = 4
+	nsga2SurvivorRate  = 0.5  // fraction of the population kept by Pareto rank each gen
+	nsga2InjectionRate = 0.05 // fraction of offspring slots replaced with random each gen
+)
+
+func init() {
+	Register("nsga2", func() Strategy { return &NSGA2Strategy{} })
+}
+
+// NSGA2Strategy implements NSGA-II: selection by Pareto rank over correct
+// digits, simplicity and convergence rate (see series.Fitness.Dominates),
+// with ties within a front broken by crowding distance so the survivors
+// spread along the front instead of bunching at one trade-off.
+type NSGA2Strategy struct {
+	maxSeries  int
+	difference bool
+	form       series.Form
+}
+
+func (s *NSGA2Strategy) Name() string { return "nsga2" }
+
+// SetMaxSeries lets mutation grow candidates into composites of up to n series.
+func (s *NSGA2Strategy) SetMaxSeries(n int) { s.maxSeries = n }
+
+// SetDifference makes new candidates difference limits
+// lim_{N->inf} [partial sum - g(N)], as needed for constants like gamma.
+func (s *NSGA2Strategy) SetDifference(on bool) { s.difference = on }
+
+// SetForm makes new candidates products or continued fractions instead of sums.
+func (s *NSGA2Strategy) SetForm(f series.Form) { s.form = f }
+
+// random creates a fresh candidate of the configured kind.
+func (s *NSGA2Strategy) random(p pool.Pool, rng *rand.Rand) *series.Candidate {
+	var c *series.Candidate
+	if s.difference {
+		c = randomDifference(p, rng, nsga2MaxDepth)
+	} else {
+		c = randomCandidate(p, rng, nsga2MaxDepth)
+	}
+	c.Form = s.form
+	return c
+}
+
+func (s *NSGA2Strategy) Initialize(p pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
+	pop := make([]*series.Candidate, popSize)
+	for i := range pop {
+		pop[i] = s.random(p, rng)
+	}
+	return pop
+}
+
+// Evolve keeps the best half of the population by (rank, crowding) and fills
+// the other half with offspring of survivors picked by binary tournaments on
+// the same order. Survivors compete with their offspring next generation.
+func (s *NSGA2Strategy) Evolve(
+	population []*series.Candidate,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng *rand.Rand,
+) []*series.Candidate {
+	n := len(population)
+	rank, crowd := paretoRank(fitnesses)
+	better := func(a, b int) bool {
+		if rank[a] != rank[b] {
+			return rank[a] < rank[b]
+		}
+		return crowd[a] > crowd[b]
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return better(order[a], order[b]) })
+
+	survivors := max(1, int(float64(n)*nsga2SurvivorRate))
+	next := make([]*series.Candidate, 0, n)
+	for _, idx := range order[:survivors] {
+		next = append(next, population[idx].Clone())
+	}
+
+	pick := func() *series.Candidate {
+		a, b := order[rng.Intn(survivors)], order[rng.Intn(survivors)]
+		if better(b, a) {
+			a = b
+		}
+		return population[a].Clone()
+	}
+	for len(next) < n {
+		c1, c2 := CrossoverCandidates(pick(), pick(), rng)
+		for _, c := range []*series.Candidate{c1, c2} {
+			if len(next) == n {
+				break
+			}
+			if rng.Float64() < mutationRate {
+				MutateCandidate(c, p, rng)
+			}
+			if s.maxSeries > 1 && rng.Float64() < compositeGrowRate {
+				growComposite(c, p, rng, s.maxSeries, nsga2MaxDepth)
+			}
+			simplifyCandidate(c)
+			if !candidateOK(c) {
+				c = s.random(p, rng)
+			}
+			next = append(next, c)
+		}
+	}
+
+	if offspring := n - survivors; offspring > 0 {
+		injectionCount := max(1, int(float64(n)*nsga2InjectionRate))
+		for i := 0; i < injectionCount; i++ {
+			next[survivors+rng.Intn(offspring)] = s.random(p, rng)
+		}
+	}
+
+	return next
+}
+
+// paretoRank sorts fitnesses into non-dominated fronts. rank[i] is the front
+// of candidate i (0 = non-dominated) and crowd[i] its crowding distance
+// within that front: the normalized size of the box its neighbours span,
+// infinite at the ends of each objective's range.
+func paretoRank(fitnesses []series.Fitness) (rank []int, crowd []float64) {
+	n := len(fitnesses)
+	rank = make([]int, n)
+	crowd = make([]float64, n)
+	dominatedBy := make([]int, n) // how many candidates dominate i
+	dominates := make([][]int, n) // the candidates i dominates
+	var front []int
+	for i := range fitnesses {
+		for j := range fitnesses {
+			switch {
+			case fitnesses[i].Dominates(fitnesses[j]):
+				dominates[i] = append(dominates[i], j)
+			case fitnesses[j].Dominates(fitnesses[i]):
+				dominatedBy[i]++
+			}
+		}
+		if dominatedBy[i] == 0 {
+			front = append(front, i)
+		}
+	}
+
+	for r := 0; len(front) > 0; r++ {
+		var nextFront []int
+		for _, i := range front {
+			rank[i] = r
+			for _, j := range dominates[i] {
+				if dominatedBy[j]--; dominatedBy[j] == 0 {
+					nextFront = append(nextFront, j)
+				}
+			}
+		}
+		crowding(front, fitnesses, crowd)
+		front = nextFront
+	}
+	return rank, crowd
+}
+
+// crowding accumulates the crowding distances of one front into crowd.
+func crowding(front []int, fitnesses []series.Fitness, crowd []float64) {
+	objectives := []func(series.Fitness) float64{
+		func(f series.Fitness) float64 { return f.CorrectDigits },
+		func(f series.Fitness) float64 { return f.Simplicity },
+		func(f series.Fitness) float64 { return f.ConvergenceRate },
+	}
+	sorted := append([]int(nil), front...)
+	for _, obj := range objectives {
+		sort.SliceStable(sorted, func(a, b int) bool {
+			return obj(fitnesses[sorted[a]]) < obj(fitnesses[sorted[b]])
+		})
+		lo, hi := obj(fitnesses[sorted[0]]), obj(fitnesses[sorted[len(sorted)-1]])
+		crowd[sorted[0]] = math.Inf(1)
+		crowd[sorted[len(sorted)-1]] = math.Inf(1)
+		if hi == lo {
+			continue
+		}
+		for k := 1; k < len(sorted)-1; k++ {
+			crowd[sorted[k]] += (obj(fitnesses[sorted[k+1]]) - obj(fitnesses[sorted[k-1]])) / (hi - lo)
+		}
+	}
+}
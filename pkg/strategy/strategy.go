@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
@@ -43,13 +44,60 @@ func Names() []string {
 const (
 	maxTreeDepth = 10 // reject trees deeper than this
 	maxNodeCount = 25 // reject candidates with more total nodes than this
+
+	// compositeGrowRate is the chance a child gains a sub-series when the
+	// strategy allows composites.
+	compositeGrowRate = 0.05
+
+	correctionMutateRate = 0.3 // chance a difference candidate's mutation hits its correction
+	correctionLnRate     = 0.5 // chance a random correction is wrapped in ln
 )
 
-// candidateOK checks that a candidate isn't too deep or bloated.
+// candidateOK checks that a candidate isn't too deep or bloated. Each
+// sub-series of a composite is held to the same limits.
 func candidateOK(c *series.Candidate) bool {
-	return c.Numerator.Depth() <= maxTreeDepth &&
-		c.Denominator.Depth() <= maxTreeDepth &&
-		c.NodeCount() <= maxNodeCount
+	for _, s := range subSeries(c) {
+		if s.Numerator.Depth() > maxTreeDepth ||
+			s.Denominator.Depth() > maxTreeDepth ||
+			(s.Correction != nil && s.Correction.Depth() > maxTreeDepth) ||
+			s.NodeCount() > maxNodeCount {
+			return false
+		}
+	}
+	return true
+}
+
+// subSeries returns the plain series making up c: its composite terms, or c itself.
+func subSeries(c *series.Candidate) []*series.Candidate {
+	if c.Composite == nil {
+		return []*series.Candidate{c}
+	}
+	out := make([]*series.Candidate, len(c.Composite.Terms))
+	for i, t := range c.Composite.Terms {
+		out[i] = t.Series
+	}
+	return out
+}
+
+// randomSeries picks one of c's plain series. It draws from rng only for composites.
+func randomSeries(c *series.Candidate, rng *rand.Rand) *series.Candidate {
+	if c.Composite == nil {
+		return c
+	}
+	return c.Composite.Terms[rng.Intn(len(c.Composite.Terms))].Series
+}
+
+// simplifyCandidate constant-folds the trees of every series in c and cancels
+// the factors their numerators and denominators share.
+func simplifyCandidate(c *series.Candidate) {
+	for _, s := range subSeries(c) {
+		s.Numerator = expr.SimplifyBigFloat(s.Numerator, 128)
+		s.Denominator = expr.SimplifyBigFloat(s.Denominator, 128)
+		if s.Correction != nil {
+			s.Correction = expr.SimplifyBigFloat(s.Correction, 128)
+		}
+	}
+	c.CancelCommon()
 }
 
 // randomCandidate creates a random candidate with trees of given max depth.
@@ -60,3 +108,15 @@ func randomCandidate(p pool.Pool, rng *rand.Rand, maxDepth int) *series.Candidat
 		Start:       int64(rng.Intn(2)), // 0 or 1
 	}
 }
+
+// randomDifference creates a random difference candidate: a random series
+// minus a random correction g(N). The correction is often a logarithm, which
+// the pools don't offer but most divergences worth cancelling need.
+func randomDifference(p pool.Pool, rng *rand.Rand, maxDepth int) *series.Candidate {
+	c := randomCandidate(p, rng, maxDepth)
+	c.Correction = p.RandomTree(rng, maxDepth-1)
+	if rng.Float64() < correctionLnRate {
+		c.Correction = &expr.UnaryNode{Op: expr.OpLn, Child: c.Correction}
+	}
+	return c
+}
@@ -1,10 +1,12 @@
 package strategy
 
 import (
+	"math"
 	"math/big"
 	"math/rand"
 	"testing"
 
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
@@ -101,6 +103,44 @@ func TestMutation_TreeRemainValid(t *testing.T) {
 	}
 }
 
+func TestSternBrocotStep(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, tc := range []struct {
+		x    *big.Rat
+		want []string // children, then parent
+	}{
+		{big.NewRat(3, 2), []string{"4/3", "5/3", "2"}},
+		{big.NewRat(2, 1), []string{"3", "3/2", "1"}},
+		{big.NewRat(-1, 2), []string{"-2/3", "-1/3", "0"}},
+	} {
+		seen := map[string]bool{}
+		for i := 0; i < 50; i++ {
+			seen[sternBrocotStep(tc.x, rng).RatString()] = true
+		}
+		for _, w := range tc.want {
+			if !seen[w] {
+				t.Errorf("neighbours of %s = %v, missing %s", tc.x.RatString(), seen, w)
+			}
+		}
+		if len(seen) != len(tc.want) {
+			t.Errorf("neighbours of %s = %v, want %v", tc.x.RatString(), seen, tc.want)
+		}
+	}
+
+	// Walking a constant leaf turns it into a fraction and keeps it a leaf.
+	var root expr.ExprNode = &expr.ConstNode{Val: 2}
+	for i := 0; i < 20; i++ {
+		root = ratPerturb(root, rng)
+		if _, ok := root.(*expr.RatNode); ok {
+			return
+		}
+		if _, ok := root.(*expr.ConstNode); !ok {
+			t.Fatalf("ratPerturb made %s from a constant", root)
+		}
+	}
+	t.Errorf("20 Stern–Brocot steps from 2 never left the integers: %s", root)
+}
+
 func TestCrossover_ProducesTwoCandidates(t *testing.T) {
 	p, _ := pool.Get("conservative")
 	rng := rand.New(rand.NewSource(42))
@@ -144,3 +184,111 @@ func TestStrategyRegistry(t *testing.T) {
 		}
 	}
 }
+
+func TestHypergeom_FindsE(t *testing.T) {
+	s, _ := Get("hypergeom")
+	rng := rand.New(rand.NewSource(3))
+
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+	population := s.Initialize(nil, rng, 50)
+
+	var best series.Fitness
+	for gen := 0; gen < 20; gen++ {
+		for _, c := range population {
+			if c.Hyper == nil {
+				t.Fatalf("gen %d: candidate %s has no genome", gen, c)
+			}
+		}
+		fitnesses := evalPopulation(population, target)
+		for _, f := range fitnesses {
+			if f.Combined > best.Combined {
+				best = f
+			}
+		}
+		population = s.Evolve(population, fitnesses, nil, rng)
+	}
+
+	// Random genomes start from 1/(b^n n!), a few mutations away from Sum 1/n!.
+	if best.CorrectDigits < 15 {
+		t.Errorf("best match has %.1f correct digits, want >= 15", best.CorrectDigits)
+	}
+}
+
+func TestComposite_CrossoverAndMutation(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	rng := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 100; i++ {
+		a := series.NewComposite(randomCandidate(p, rng, 3), randomCandidate(p, rng, 3))
+		b := randomCandidate(p, rng, 3)
+
+		c1, c2 := CrossoverCandidates(a, b, rng)
+		if got := len(subSeries(c1)) + len(subSeries(c2)); got != 3 {
+			t.Fatalf("crossover turned 3 series into %d", got)
+		}
+		if len(subSeries(a)) != 2 || a.Composite.Terms[0].Series == c1.Composite.Terms[0].Series {
+			t.Fatal("crossover modified its parents")
+		}
+
+		MutateCandidate(c1, p, rng)
+		growComposite(c2, p, rng, 3, 3)
+		simplifyCandidate(c1)
+		simplifyCandidate(c2)
+		for _, c := range []*series.Candidate{c1, c2} {
+			if _, err := series.ParseCandidateLatex(c.LaTeX()); err != nil {
+				t.Fatalf("%s does not parse back: %v", c.LaTeX(), err)
+			}
+			series.EvaluateCandidateF64(c, 64)
+		}
+	}
+}
+
+func TestNSGA2_ParetoRank(t *testing.T) {
+	fit := func(digits, simplicity, rate float64) series.Fitness {
+		return series.Fitness{Combined: digits, CorrectDigits: digits, Simplicity: simplicity, ConvergenceRate: rate}
+	}
+	fitnesses := []series.Fitness{
+		fit(10, 0.1, 0.5),     // 0: accurate but complex
+		fit(2, 0.5, 0.5),      // 1: simple but inaccurate
+		fit(5, 0.2, 0.5),      // 2: in between
+		fit(4, 0.2, 0.5),      // 3: dominated by 2
+		fit(10, 0.1, 0.9),     // 4: dominated by 0 (slower)
+		series.WorstFitness(), // 5: failed
+	}
+	rank, crowd := paretoRank(fitnesses)
+	want := []int{0, 0, 0, 1, 1, 2}
+	for i := range want {
+		if rank[i] != want[i] {
+			t.Errorf("rank[%d] = %d, want %d", i, rank[i], want[i])
+		}
+	}
+	if !math.IsInf(crowd[0], 1) || !math.IsInf(crowd[1], 1) {
+		t.Errorf("front ends should have infinite crowding, got %v and %v", crowd[0], crowd[1])
+	}
+	if math.IsInf(crowd[2], 1) || crowd[2] <= 0 {
+		t.Errorf("interior crowding = %v, want finite and positive", crowd[2])
+	}
+}
+
+func TestNSGA2_Evolve(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	s, _ := Get("nsga2")
+	rng := rand.New(rand.NewSource(42))
+
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+	population := s.Initialize(p, rng, 50)
+
+	var best float64
+	for gen := 0; gen < 20; gen++ {
+		fitnesses := evalPopulation(population, target)
+		for _, f := range fitnesses {
+			best = math.Max(best, f.CorrectDigits)
+		}
+		population = s.Evolve(population, fitnesses, p, rng)
+		if len(population) != 50 {
+			t.Fatalf("gen %d: population size %d, want 50", gen, len(population))
+		}
+	}
+
+	t.Logf("NSGA-II best digits after 20 gens: %.1f", best)
+}
@@ -4,7 +4,6 @@ import (
 	"math/rand"
 	"sort"
 
-	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
@@ -22,14 +21,40 @@ func init() {
 }
 
 // TournamentStrategy implements tournament selection with crossover and mutation.
-type TournamentStrategy struct{}
+type TournamentStrategy struct {
+	maxSeries  int
+	difference bool
+	form       series.Form
+}
 
 func (s *TournamentStrategy) Name() string { return "tournament" }
 
+// SetMaxSeries lets mutation grow candidates into composites of up to n series.
+func (s *TournamentStrategy) SetMaxSeries(n int) { s.maxSeries = n }
+
+// SetDifference makes new candidates difference limits
+// lim_{N->inf} [partial sum - g(N)], as needed for constants like gamma.
+func (s *TournamentStrategy) SetDifference(on bool) { s.difference = on }
+
+// SetForm makes new candidates products or continued fractions instead of sums.
+func (s *TournamentStrategy) SetForm(f series.Form) { s.form = f }
+
+// random creates a fresh candidate of the configured kind.
+func (s *TournamentStrategy) random(p pool.Pool, rng *rand.Rand) *series.Candidate {
+	var c *series.Candidate
+	if s.difference {
+		c = randomDifference(p, rng, tournamentMaxDepth)
+	} else {
+		c = randomCandidate(p, rng, tournamentMaxDepth)
+	}
+	c.Form = s.form
+	return c
+}
+
 func (s *TournamentStrategy) Initialize(p pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
 	pop := make([]*series.Candidate, popSize)
 	for i := range pop {
-		pop[i] = randomCandidate(p, rng, tournamentMaxDepth)
+		pop[i] = s.random(p, rng)
 	}
 	return pop
 }
@@ -72,26 +97,30 @@ func (s *TournamentStrategy) Evolve(
 		if rng.Float64() < mutationRate {
 			MutateCandidate(c1, p, rng)
 		}
-		c1.Numerator = expr.SimplifyBigFloat(c1.Numerator, 128)
-		c1.Denominator = expr.SimplifyBigFloat(c1.Denominator, 128)
+		if s.maxSeries > 1 && rng.Float64() < compositeGrowRate {
+			growComposite(c1, p, rng, s.maxSeries, tournamentMaxDepth)
+		}
+		simplifyCandidate(c1)
 
 		if rng.Float64() < mutationRate {
 			MutateCandidate(c2, p, rng)
 		}
-		c2.Numerator = expr.SimplifyBigFloat(c2.Numerator, 128)
-		c2.Denominator = expr.SimplifyBigFloat(c2.Denominator, 128)
+		if s.maxSeries > 1 && rng.Float64() < compositeGrowRate {
+			growComposite(c2, p, rng, s.maxSeries, tournamentMaxDepth)
+		}
+		simplifyCandidate(c2)
 
 		// Reject overly deep trees
 		if candidateOK(c1) {
 			next = append(next, c1)
 		} else {
-			next = append(next, randomCandidate(p, rng, tournamentMaxDepth))
+			next = append(next, s.random(p, rng))
 		}
 		if len(next) < n {
 			if candidateOK(c2) {
 				next = append(next, c2)
 			} else {
-				next = append(next, randomCandidate(p, rng, tournamentMaxDepth))
+				next = append(next, s.random(p, rng))
 			}
 		}
 	}
@@ -103,7 +132,7 @@ func (s *TournamentStrategy) Evolve(
 	}
 	for i := 0; i < injectionCount; i++ {
 		idx := eliteCount + rng.Intn(n-eliteCount)
-		next[idx] = randomCandidate(p, rng, tournamentMaxDepth)
+		next[idx] = s.random(p, rng)
 	}
 
 	return next[:n]